@@ -9,6 +9,7 @@ import (
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var DB *gorm.DB
@@ -28,15 +29,50 @@ func ConnectToDatabase() {
 	DB.Exec(`CREATE TYPE resource_status AS ENUM ('ACTIVE', 'INACTIVE', 'ON_BENCH');`)
 	DB.Exec(`CREATE TYPE vendor_status AS ENUM ('ACTIVE', 'INACTIVE', 'PREFERRED');`)
 	DB.Exec(`CREATE TYPE payment_terms AS ENUM ('NET_30', 'NET_60', 'NET_90');`)
+	DB.Exec(`CREATE TYPE lead_stage AS ENUM ('NEW', 'IN_PROGRESS', 'FOLLOW_UP', 'CLOSED_WON', 'CLOSED_LOST');`)
+	DB.Exec(`CREATE TYPE deal_status AS ENUM ('STARTED', 'PENDING', 'COMPLETED', 'CANCELLED');`)
+	DB.Exec(`CREATE TYPE line_item_unit AS ENUM ('HOUR', 'DAY', 'MONTH');`)
+	DB.Exec(`CREATE TYPE quote_status AS ENUM ('DRAFT', 'SENT', 'ACCEPTED', 'REJECTED');`)
+	DB.Exec(`CREATE TYPE campaign_status AS ENUM ('DRAFT', 'ACTIVE', 'PAUSED', 'COMPLETED', 'ARCHIVED');`)
+	DB.Exec(`CREATE TYPE campaign_role AS ENUM ('OWNER', 'MEMBER', 'VIEWER');`)
+	DB.Exec(`CREATE TYPE assignment_rule_type AS ENUM ('ROUND_ROBIN', 'TERRITORY');`)
+	normalizeEnumColumn("leads", "lead_stage", []string{"NEW", "IN_PROGRESS", "FOLLOW_UP", "CLOSED_WON", "CLOSED_LOST"}, "NEW")
+	// Deals were auto-created with the status "Active", which is now STARTED
+	normalizeEnumColumn("deals", "deal_status", []string{"STARTED", "PENDING", "COMPLETED", "CANCELLED"}, "STARTED")
+	migrateMoneyAndDates()
+
+	// campaign_users carries the role and join date of a membership
+	for model, field := range map[interface{}]string{&models.Campaign{}: "Users", &models.User{}: "Campaigns"} {
+		if err := DB.SetupJoinTable(model, field, &models.CampaignUser{}); err != nil {
+			log.Fatalf("Failed to set up campaign_users: %v", err)
+		}
+	}
 
 	// DB.AutoMigrate(&models.Activity{})
 	err = DB.AutoMigrate(
 		&models.User{},
+		&models.Session{},
+		&models.PasswordResetToken{},
+		&models.LoginThrottle{},
+		&models.LockoutEvent{},
+		&models.RecoveryCode{},
+		&models.MfaPolicy{},
+		&models.APIKey{},
+		&models.DataMigrationIssue{},
 		&models.Campaign{},
 		&models.Organization{},
+		&models.AssignmentRule{},
 		&models.Lead{},
+		&models.LeadStageHistory{},
 		&models.Activity{},
 		&models.Deals{},
+		&models.DealLineItem{},
+		&models.QuoteTemplate{},
+		&models.Quote{},
+		&models.CaseStudy{},
+		&models.DealStageProbability{},
+		&models.DealAutomationConfig{},
+		&models.ExchangeRate{},
 		&models.ResourceProfile{},   // New Model
 		&models.Vendor{},            // New Model
 		&models.Skill{},             // Supporting model
@@ -47,4 +83,43 @@ func ConnectToDatabase() {
 	if err != nil {
 		log.Fatalf("Failed to migrate database schema: %v", err)
 	}
+	if err := backfillLeadStageHistory(DB); err != nil {
+		log.Fatalf("Failed to backfill lead stage history: %v", err)
+	}
+}
+
+// backfillLeadStageHistory gives every lead without stage history an initial entry in its
+// current stage, so leads created before the history was recorded show up in the funnel and
+// time-in-stage reports. Leads without a creation time start at the time of the migration.
+func backfillLeadStageHistory(tx *gorm.DB) error {
+	result := tx.Exec(`INSERT INTO lead_stage_histories (lead_id, to_stage, changed_at, created_at, updated_at)
+		SELECT leads.lead_id, leads.lead_stage, COALESCE(leads.created_at, NOW()), NOW(), NOW() FROM leads
+		WHERE NOT EXISTS (SELECT 1 FROM lead_stage_histories WHERE lead_stage_histories.lead_id = leads.lead_id)`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Backfilled the initial stage of %d leads", result.RowsAffected)
+	}
+	return nil
+}
+
+// normalizeEnumColumn cleans up the free text values stored before a column became a
+// Postgres enum, so AutoMigrate can convert it. Values that do not match (ignoring case
+// and surrounding spaces) are replaced with the fallback.
+func normalizeEnumColumn(table, column string, values []string, fallback string) {
+	var dataType string
+	DB.Raw(`SELECT data_type FROM information_schema.columns WHERE table_name = ? AND column_name = ?`, table, column).Scan(&dataType)
+	if dataType == "" || dataType == "USER-DEFINED" {
+		return
+	}
+	result := DB.Exec(`UPDATE ? SET ? = CASE WHEN UPPER(TRIM(?)) IN ? THEN UPPER(TRIM(?)) ELSE ? END WHERE ? IS NULL OR ? NOT IN ?`,
+		clause.Table{Name: table}, clause.Column{Name: column}, clause.Column{Name: column}, values,
+		clause.Column{Name: column}, fallback, clause.Column{Name: column}, clause.Column{Name: column}, values)
+	if result.Error != nil {
+		log.Fatalf("Failed to normalize %s.%s: %v", table, column, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Normalized %s.%s of %d rows", table, column, result.RowsAffected)
+	}
 }
@@ -0,0 +1,143 @@
+package initializers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const moneyAndDatesMigration = "money_and_dates"
+
+// legacyValue is one row of a free text column that is about to get a real type
+type legacyValue struct {
+	ID    uint
+	Value *string
+}
+
+// migrateMoneyAndDates rewrites the free text amounts and dates of deals and organizations
+// into a form Postgres can cast, so AutoMigrate can turn the columns into numeric and date.
+// Values that cannot be parsed are replaced (with 0 for required amounts, NULL otherwise)
+// and recorded as DataMigrationIssue rows together with the original text.
+func migrateMoneyAndDates() {
+	if err := DB.AutoMigrate(&models.DataMigrationIssue{}); err != nil {
+		log.Fatalf("Failed to migrate data_migration_issues: %v", err)
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := convertMoneyColumn(tx, "deals", "deal_amount", "deal_currency", true); err != nil {
+			return err
+		}
+		if err := convertDateColumn(tx, "deals", "deal_start_date"); err != nil {
+			return err
+		}
+		if err := convertDateColumn(tx, "deals", "deal_end_date"); err != nil {
+			return err
+		}
+		return convertMoneyColumn(tx, "organizations", "annual_revenue", "annual_revenue_currency", false)
+	})
+	if err != nil {
+		log.Fatalf("Failed to convert amounts and dates: %v", err)
+	}
+}
+
+func columnDataType(tx *gorm.DB, table, column string) string {
+	var dataType string
+	tx.Raw(`SELECT data_type FROM information_schema.columns WHERE table_name = ? AND column_name = ?`, table, column).Scan(&dataType)
+	return dataType
+}
+
+// loadLegacyValues returns every row of the column, soft deleted ones included. ok is false
+// when the column does not exist or has already been converted.
+func loadLegacyValues(tx *gorm.DB, table, column string) (rows []legacyValue, ok bool, err error) {
+	if columnDataType(tx, table, column) != "text" {
+		return nil, false, nil
+	}
+	err = tx.Raw(`SELECT id, ? AS value FROM ?`, clause.Column{Name: column}, clause.Table{Name: table}).Scan(&rows).Error
+	return rows, true, err
+}
+
+func convertMoneyColumn(tx *gorm.DB, table, column, currencyColumn string, required bool) error {
+	rows, ok, err := loadLegacyValues(tx, table, column)
+	if err != nil || !ok {
+		return err
+	}
+	if err := tx.Exec(`ALTER TABLE ? ADD COLUMN IF NOT EXISTS ? varchar(3)`, clause.Table{Name: table}, clause.Column{Name: currencyColumn}).Error; err != nil {
+		return err
+	}
+
+	issues := 0
+	for _, row := range rows {
+		var amount, currency interface{}
+		if required {
+			amount, currency = "0", scalars.DefaultCurrency()
+		}
+		if row.Value != nil && *row.Value != "" {
+			money, err := scalars.ParseMoney(*row.Value)
+			if err == nil {
+				amount, currency = money.Amount.String(), money.Currency
+			} else {
+				if err := recordMigrationIssue(tx, table, column, row, err); err != nil {
+					return err
+				}
+				issues++
+			}
+		}
+		err := tx.Exec(`UPDATE ? SET ? = ?, ? = ? WHERE id = ?`, clause.Table{Name: table},
+			clause.Column{Name: column}, amount, clause.Column{Name: currencyColumn}, currency, row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	logConversion(table, column, len(rows), issues)
+	return nil
+}
+
+func convertDateColumn(tx *gorm.DB, table, column string) error {
+	rows, ok, err := loadLegacyValues(tx, table, column)
+	if err != nil || !ok {
+		return err
+	}
+
+	issues := 0
+	for _, row := range rows {
+		var date interface{}
+		if row.Value != nil && *row.Value != "" {
+			parsed, err := scalars.ParseLegacyTime(*row.Value)
+			if err == nil {
+				date = parsed.Format("2006-01-02")
+			} else {
+				if err := recordMigrationIssue(tx, table, column, row, err); err != nil {
+					return err
+				}
+				issues++
+			}
+		}
+		err := tx.Exec(`UPDATE ? SET ? = ? WHERE id = ?`, clause.Table{Name: table}, clause.Column{Name: column}, date, row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	logConversion(table, column, len(rows), issues)
+	return nil
+}
+
+func recordMigrationIssue(tx *gorm.DB, table, column string, row legacyValue, problem error) error {
+	return tx.Create(&models.DataMigrationIssue{
+		Migration:   moneyAndDatesMigration,
+		SourceTable: table,
+		RowID:       fmt.Sprint(row.ID),
+		Column:      column,
+		Value:       *row.Value,
+		Problem:     problem.Error(),
+	}).Error
+}
+
+func logConversion(table, column string, total, issues int) {
+	log.Printf("Converted %s.%s of %d rows", table, column, total)
+	if issues > 0 {
+		log.Printf("%d values of %s.%s could not be converted, see data_migration_issues", issues, table, column)
+	}
+}
@@ -0,0 +1,78 @@
+package initializers
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+)
+
+func TestConvertMoneyColumn(t *testing.T) {
+	t.Setenv("DEFAULT_CURRENCY", "USD")
+	gdb, db := testdb.Open(t)
+	db.On(testdb.Rule{Contains: []string{"information_schema.columns"}, Columns: []string{"data_type"}, Rows: [][]driver.Value{{"text"}}})
+	db.On(testdb.Rule{
+		Contains: []string{"AS value", `FROM "deals"`},
+		Columns:  []string{"id", "value"},
+		Rows: [][]driver.Value{
+			{int64(1), "$1,200.50"},
+			{int64(2), "1.200,50 EUR"},
+			{int64(3), "1.200 EUR"},
+			{int64(4), nil},
+		},
+	})
+
+	if err := convertMoneyColumn(gdb, "deals", "deal_amount", "deal_currency", true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64][]driver.Value{
+		1: {"1200.5", "USD"},
+		2: {"1200.5", "EUR"},
+		3: {"0", "USD"},
+		4: {"0", "USD"},
+	}
+	updates := db.Statements(`UPDATE "deals" SET "deal_amount"`)
+	if len(updates) != len(want) {
+		t.Fatalf("got %d updates, want %d", len(updates), len(want))
+	}
+	for _, update := range updates {
+		id, _ := update.Args[2].(uint)
+		expected := want[int64(id)]
+		if update.Args[0] != expected[0] || update.Args[1] != expected[1] {
+			t.Errorf("row %d was converted to %v %v, want %v %v", id, update.Args[0], update.Args[1], expected[0], expected[1])
+		}
+	}
+
+	issues := db.Statements(`INSERT INTO "data_migration_issues"`)
+	if len(issues) != 1 || !containsArg(issues[0].Args, "1.200 EUR") {
+		t.Fatalf("the ambiguous amount was not reported: %v", issues)
+	}
+}
+
+func containsArg(args []driver.Value, value driver.Value) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBackfillLeadStageHistory(t *testing.T) {
+	gdb, db := testdb.Open(t)
+	if err := backfillLeadStageHistory(gdb); err != nil {
+		t.Fatal(err)
+	}
+
+	inserts := db.Statements("INSERT INTO lead_stage_histories", "FROM leads")
+	if len(inserts) != 1 {
+		t.Fatalf("got %d backfills, want 1", len(inserts))
+	}
+	for _, fragment := range []string{"leads.lead_stage", "COALESCE(leads.created_at, NOW())", "NOT EXISTS"} {
+		if !strings.Contains(inserts[0].SQL, fragment) {
+			t.Errorf("the backfill does not use %s: %s", fragment, inserts[0].SQL)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// RoleService is the role of the synthetic principal behind an API key. It is not a
+// UserRole, so API keys never pass @hasRole.
+const RoleService = "SERVICE"
+
+const (
+	APIKeyHeader  = "X-API-Key"
+	APIKeyCtxKey  = "api_key"
+	apiKeyPrefix  = "crm"
+	lastUsedDelay = time.Minute
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyPrincipal is the caller behind an API key. Its claims are stored under UserCtxKey
+// like those of an access token, so resolvers attribute writes to the admin who created the key.
+type APIKeyPrincipal struct {
+	KeyID     string
+	Name      string
+	CreatorID uint
+	Scopes    []string
+}
+
+func (p *APIKeyPrincipal) Claims() jwt.MapClaims {
+	scopes := make([]interface{}, len(p.Scopes))
+	for i, scope := range p.Scopes {
+		scopes[i] = scope
+	}
+	return jwt.MapClaims{
+		"user_id":    fmt.Sprintf("%v", p.CreatorID),
+		"name":       p.Name,
+		"role":       RoleService,
+		"api_key_id": p.KeyID,
+		"scopes":     scopes,
+	}
+}
+
+func (p *APIKeyPrincipal) hasScope(field string) bool {
+	return contains(p.Scopes, field)
+}
+
+// GetAPIKeyPrincipal returns the API key principal of the request, if it was made with one
+func GetAPIKeyPrincipal(ctx context.Context) (*APIKeyPrincipal, bool) {
+	principal, ok := ctx.Value(APIKeyCtxKey).(*APIKeyPrincipal)
+	return principal, ok
+}
+
+// ServiceAccess implements the @serviceAccess directive. It is only a marker read by PublicFields.
+func ServiceAccess(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	return next(ctx)
+}
+
+// ServiceAccessFields lists the root fields marked with @serviceAccess, the operations
+// API keys can be scoped to
+func ServiceAccessFields() []string {
+	schema := generated.NewExecutableSchema(generated.Config{}).Schema()
+	var fields []string
+	for _, root := range []string{"Query", "Mutation"} {
+		def := schema.Types[root]
+		if def == nil {
+			continue
+		}
+		for _, field := range def.Fields {
+			if field.Directives.ForName("serviceAccess") != nil {
+				fields = append(fields, field.Name)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// NormalizeAPIKeyScopes deduplicates the scopes and rejects operations API keys cannot be scoped to
+func NormalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	allowed := ServiceAccessFields()
+	seen := map[string]bool{}
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !contains(allowed, scope) {
+			return nil, fmt.Errorf("invalid scope %q, allowed scopes are: %s", scope, strings.Join(allowed, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// CreateAPIKey stores a new API key and returns its plaintext value, which is only shown once.
+// Keys look like crm_<prefix>_<secret>; the prefix identifies the key, only a hash of the whole key is stored.
+func CreateAPIKey(name string, scopes []string, creatorID uint) (*models.APIKey, string, error) {
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	secret, _, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := base64.RawURLEncoding.EncodeToString(prefixBytes)
+	// The prefix must not contain the separator
+	prefix = strings.NewReplacer("_", "x", "-", "y").Replace(prefix)
+	plaintext := apiKeyPrefix + "_" + prefix + "_" + secret
+
+	key := models.APIKey{
+		Name:        name,
+		Prefix:      prefix,
+		KeyHash:     hashToken(plaintext),
+		Scopes:      scopes,
+		CreatedByID: creatorID,
+	}
+	if err := initializers.DB.Create(&key).Error; err != nil {
+		return nil, "", err
+	}
+	return &key, plaintext, nil
+}
+
+// ValidateAPIKey resolves the principal of an active API key and records its use
+func ValidateAPIKey(plaintext string) (*APIKeyPrincipal, error) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := initializers.DB.Where("prefix = ? AND key_hash = ? AND revoked_at IS NULL", parts[1], hashToken(plaintext)).
+		First(&key).Error; err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	// Keys die with the admin who created them
+	var creator models.User
+	if err := initializers.DB.First(&creator, "id = ?", key.CreatedByID).Error; err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// last_used_at is only refreshed once per lastUsedDelay to keep busy keys from writing on every request
+	now := time.Now()
+	if err := initializers.DB.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", key.ID, now.Add(-lastUsedDelay)).
+		Update("last_used_at", now).Error; err != nil {
+		log.Printf("Error recording use of API key %s: %v", key.ID, err)
+	}
+
+	return &APIKeyPrincipal{
+		KeyID:     key.ID.String(),
+		Name:      key.Name,
+		CreatorID: key.CreatedByID,
+		Scopes:    key.Scopes,
+	}, nil
+}
+
+func scopeError(field string) error {
+	return &gqlerror.Error{
+		Message:    fmt.Sprintf("forbidden: API key is not scoped to %s", field),
+		Extensions: map[string]interface{}{"code": ErrCodeForbidden},
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes returned in the extensions of authorization errors
+const (
+	ErrCodeUnauthenticated = "UNAUTHENTICATED"
+	ErrCodeForbidden       = "FORBIDDEN"
+)
+
+func unauthenticatedError() error {
+	return &gqlerror.Error{
+		Message:    "unauthenticated",
+		Extensions: map[string]interface{}{"code": ErrCodeUnauthenticated},
+	}
+}
+
+func forbiddenError() error {
+	return &gqlerror.Error{
+		Message:    "forbidden: insufficient role",
+		Extensions: map[string]interface{}{"code": ErrCodeForbidden},
+	}
+}
+
+// Directives returns the implementations of the schema directives
+func Directives() generated.DirectiveRoot {
+	return generated.DirectiveRoot{
+		Authenticated: Authenticated,
+		HasRole:       HasRole,
+		MfaEnrollment: MfaEnrollment,
+		Public:        Public,
+		ServiceAccess: ServiceAccess,
+	}
+}
+
+// Authenticated implements the @authenticated directive
+func Authenticated(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	if _, ok := ctx.Value(UserCtxKey).(jwt.MapClaims); !ok {
+		return nil, unauthenticatedError()
+	}
+	return next(ctx)
+}
+
+// HasRole implements the @hasRole directive
+func HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, roles []generated.UserRole) (interface{}, error) {
+	role, err := GetUserRoleFromJWT(ctx)
+	if err != nil {
+		return nil, unauthenticatedError()
+	}
+	for _, allowed := range roles {
+		if role == allowed.String() {
+			return next(ctx)
+		}
+	}
+	return nil, forbiddenError()
+}
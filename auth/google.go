@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultGoogleJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+	defaultGoogleIssuers = "https://accounts.google.com,accounts.google.com"
+
+	jwksCacheTTL        = time.Hour
+	jwksMinRefetchDelay = time.Minute
+)
+
+var ErrInvalidGoogleToken = errors.New("invalid Google ID token")
+
+// GoogleIdentity is the verified subset of the claims of a Google ID token
+type GoogleIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// googleConfig is read from the environment on every call so a stub issuer can be
+// swapped in for local development by pointing GOOGLE_JWKS_URL and GOOGLE_ISSUERS at it.
+type googleConfig struct {
+	jwksURL   string
+	issuers   []string
+	clientIDs []string
+}
+
+func loadGoogleConfig() (*googleConfig, error) {
+	cfg := &googleConfig{
+		jwksURL: os.Getenv("GOOGLE_JWKS_URL"),
+		issuers: splitList(os.Getenv("GOOGLE_ISSUERS")),
+		// GOOGLE_CLIENT_ID may list several OAuth clients, e.g. web and mobile
+		clientIDs: splitList(os.Getenv("GOOGLE_CLIENT_ID")),
+	}
+	if cfg.jwksURL == "" {
+		cfg.jwksURL = defaultGoogleJWKSURL
+	}
+	if len(cfg.issuers) == 0 {
+		cfg.issuers = splitList(defaultGoogleIssuers)
+	}
+	if len(cfg.clientIDs) == 0 {
+		return nil, errors.New("Google sign-in is not configured, set GOOGLE_CLIENT_ID")
+	}
+	return cfg, nil
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// VerifyGoogleIDToken checks the signature of an OpenID Connect ID token against the
+// configured JWKS and validates its issuer, audience and expiry.
+func VerifyGoogleIDToken(ctx context.Context, idToken string) (*GoogleIdentity, error) {
+	cfg, err := loadGoogleConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return googleKeys.key(ctx, cfg.jwksURL, kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		log.Printf("Google ID token rejected: %v", err)
+		return nil, ErrInvalidGoogleToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidGoogleToken
+	}
+
+	issuer, _ := claims.GetIssuer()
+	if !contains(cfg.issuers, issuer) {
+		return nil, ErrInvalidGoogleToken
+	}
+	audiences, _ := claims.GetAudience()
+	audienceOK := false
+	for _, aud := range audiences {
+		if contains(cfg.clientIDs, aud) {
+			audienceOK = true
+			break
+		}
+	}
+	if !audienceOK {
+		return nil, ErrInvalidGoogleToken
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, ErrInvalidGoogleToken
+	}
+
+	identity := &GoogleIdentity{Subject: subject}
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	// email_verified is a boolean for Google but some issuers send it as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	return identity, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// jwksCache caches the public keys of the issuer and refetches them when they expire
+// or a token references a key ID that is not known yet (key rotation on Google's side).
+type jwksCache struct {
+	mu        sync.Mutex
+	url       string
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+var googleKeys = &jwksCache{}
+
+var jwksClient = &http.Client{Timeout: 10 * time.Second}
+
+func (c *jwksCache) key(ctx context.Context, url, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stale := c.url != url || time.Since(c.fetchedAt) > jwksCacheTTL
+	_, known := c.keys[kid]
+	if stale || (!known && time.Since(c.fetchedAt) > jwksMinRefetchDelay) {
+		keys, err := fetchJWKS(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		c.url, c.keys, c.fetchedAt = url, keys, time.Now()
+	}
+
+	key, ok := c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func fetchJWKS(ctx context.Context, url string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := jwksClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	stubIssuerURL = "https://issuer.example.com"
+	stubClientID  = "crm-web"
+	stubKeyID     = "stub-key"
+)
+
+// stubIssuer serves the JWKS of a freshly generated key and points the Google sign-in
+// configuration at it
+func stubIssuer(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": stubKeyID,
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("GOOGLE_JWKS_URL", server.URL)
+	t.Setenv("GOOGLE_ISSUERS", stubIssuerURL)
+	t.Setenv("GOOGLE_CLIENT_ID", "crm-mobile, "+stubClientID)
+	previous := googleKeys
+	googleKeys = &jwksCache{}
+	t.Cleanup(func() { googleKeys = previous })
+	return key
+}
+
+func googleClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            stubIssuerURL,
+		"aud":            stubClientID,
+		"sub":            "1234567890",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane Doe",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func signIDToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyGoogleIDToken(t *testing.T) {
+	key := stubIssuer(t)
+
+	identity, err := VerifyGoogleIDToken(context.Background(), signIDToken(t, jwt.SigningMethodRS256, key, stubKeyID, googleClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GoogleIdentity{Subject: "1234567890", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}
+	if *identity != want {
+		t.Fatalf("got %+v, want %+v", *identity, want)
+	}
+}
+
+func TestVerifyGoogleIDTokenRejects(t *testing.T) {
+	key := stubIssuer(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+		kid    string
+		change func(jwt.MapClaims)
+	}{
+		{"wrong audience", jwt.SigningMethodRS256, key, stubKeyID, func(c jwt.MapClaims) { c["aud"] = "another-app" }},
+		{"wrong issuer", jwt.SigningMethodRS256, key, stubKeyID, func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"expired", jwt.SigningMethodRS256, key, stubKeyID, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"without expiry", jwt.SigningMethodRS256, key, stubKeyID, func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"without subject", jwt.SigningMethodRS256, key, stubKeyID, func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"unknown key ID", jwt.SigningMethodRS256, key, "rotated-away", func(jwt.MapClaims) {}},
+		{"signed with another key", jwt.SigningMethodRS256, otherKey, stubKeyID, func(jwt.MapClaims) {}},
+		{"RS512", jwt.SigningMethodRS512, key, stubKeyID, func(jwt.MapClaims) {}},
+		{"HS256", jwt.SigningMethodHS256, []byte("shared-secret"), stubKeyID, func(jwt.MapClaims) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := googleClaims()
+			tt.change(claims)
+
+			identity, err := VerifyGoogleIDToken(context.Background(), signIDToken(t, tt.method, tt.key, tt.kid, claims))
+			if !errors.Is(err, ErrInvalidGoogleToken) {
+				t.Fatalf("got %+v, %v, want %v", identity, err, ErrInvalidGoogleToken)
+			}
+		})
+	}
+}
+
+func TestVerifyGoogleIDTokenRequiresClientID(t *testing.T) {
+	key := stubIssuer(t)
+	t.Setenv("GOOGLE_CLIENT_ID", "")
+
+	_, err := VerifyGoogleIDToken(context.Background(), signIDToken(t, jwt.SigningMethodRS256, key, stubKeyID, googleClaims()))
+	if err == nil || errors.Is(err, ErrInvalidGoogleToken) {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+}
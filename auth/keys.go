@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// keySet holds the HMAC keys used to sign and verify tokens, indexed by key ID (kid)
+type keySet struct {
+	activeKID string
+	keys      map[string][]byte
+}
+
+var (
+	keysOnce   sync.Once
+	loadedKeys *keySet
+	keysErr    error
+)
+
+// signingKeys lazily loads the key set so the .env file loaded by the initializers is honoured.
+//
+// JWT_SIGNING_KEYS holds a comma separated list of kid:secret pairs. Every listed key is
+// accepted when validating tokens, which allows a new key to be introduced before the old
+// one is retired. JWT_ACTIVE_KEY_ID picks the key new tokens are signed with and defaults
+// to the first pair. For a single key setup JWT_SECRET can be used instead.
+func signingKeys() (*keySet, error) {
+	keysOnce.Do(func() {
+		loadedKeys, keysErr = loadKeys()
+	})
+	return loadedKeys, keysErr
+}
+
+func loadKeys() (*keySet, error) {
+	ks := &keySet{keys: map[string][]byte{}}
+
+	if raw := os.Getenv("JWT_SIGNING_KEYS"); raw != "" {
+		for _, pair := range strings.Split(raw, ",") {
+			kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || kid == "" || secret == "" {
+				return nil, fmt.Errorf("invalid JWT_SIGNING_KEYS entry %q, expected kid:secret", pair)
+			}
+			if _, exists := ks.keys[kid]; exists {
+				return nil, fmt.Errorf("duplicate kid %q in JWT_SIGNING_KEYS", kid)
+			}
+			ks.keys[kid] = []byte(secret)
+			if ks.activeKID == "" {
+				ks.activeKID = kid
+			}
+		}
+	} else if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		ks.keys["default"] = []byte(secret)
+		ks.activeKID = "default"
+	}
+
+	if len(ks.keys) == 0 {
+		return nil, errors.New("no JWT signing keys configured, set JWT_SIGNING_KEYS or JWT_SECRET")
+	}
+
+	if active := os.Getenv("JWT_ACTIVE_KEY_ID"); active != "" {
+		if _, ok := ks.keys[active]; !ok {
+			return nil, fmt.Errorf("JWT_ACTIVE_KEY_ID %q is not listed in JWT_SIGNING_KEYS", active)
+		}
+		ks.activeKID = active
+	}
+
+	return ks, nil
+}
+
+// durationFromEnv reads a time.ParseDuration value such as "15m" or "720h"
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		fmt.Printf("Invalid %s value %q, using %s\n", name, raw, fallback)
+		return fallback
+	}
+	return d
+}
+
+func accessTokenTTL() time.Duration {
+	return durationFromEnv("JWT_ACCESS_TTL", defaultAccessTokenTTL)
+}
+
+func refreshTokenTTL() time.Duration {
+	return durationFromEnv("JWT_REFRESH_TTL", defaultRefreshTokenTTL)
+}
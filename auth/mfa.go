@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"gorm.io/gorm"
+)
+
+// Token types stored in the "typ" claim. Only access tokens are accepted by the middleware.
+const (
+	tokenTypeAccess       = "access"
+	tokenTypeMFAChallenge = "mfa_challenge"
+)
+
+const (
+	mfaChallengeTTL    = 5 * time.Minute
+	recoveryCodeCount  = 10
+	defaultTOTPIssuer  = "IT CRM"
+	ErrCodeMFARequired = "MFA_ENROLLMENT_REQUIRED"
+)
+
+var (
+	ErrInvalidMFAChallenge = errors.New("invalid or expired MFA challenge")
+	ErrInvalidMFACode      = errors.New("invalid verification code")
+)
+
+// TOTPIssuer is the account issuer shown in authenticator apps
+func TOTPIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return defaultTOTPIssuer
+}
+
+// MFARequiredForRole reports whether admins made MFA mandatory for the role
+func MFARequiredForRole(role string) bool {
+	var policy models.MfaPolicy
+	if err := initializers.DB.First(&policy, "role = ?", role).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error loading MFA policy for role %s: %v", role, err)
+		}
+		return false
+	}
+	return policy.Required
+}
+
+// mfaEnrollmentPending reports whether the user has to enroll in MFA before using the API
+func mfaEnrollmentPending(user *models.User) bool {
+	return !user.TotpEnabled && MFARequiredForRole(user.Role)
+}
+
+// NewMFAChallenge issues the short lived token exchanged together with a TOTP or recovery
+// code for a session once the password (or Google sign-in) has been verified.
+func NewMFAChallenge(user *models.User) (string, time.Time, error) {
+	ks, err := signingKeys()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(mfaChallengeTTL)
+	claims := jwt.MapClaims{
+		"user_id": fmt.Sprintf("%v", user.ID),
+		"typ":     tokenTypeMFAChallenge,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = ks.activeKID
+	signed, err := token.SignedString(ks.keys[ks.activeKID])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ParseMFAChallenge validates a challenge token and returns the ID of the user it was issued for
+func ParseMFAChallenge(challengeToken string) (string, error) {
+	claims, err := parseToken(challengeToken, tokenTypeMFAChallenge)
+	if err != nil {
+		return "", ErrInvalidMFAChallenge
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return "", ErrInvalidMFAChallenge
+	}
+	return userID, nil
+}
+
+// VerifyMFACode accepts either a current TOTP code or an unused recovery code of the user.
+// Both are single use: the matched TOTP time step and the recovery code are consumed in
+// conditional updates so a concurrent request cannot reuse them.
+func VerifyMFACode(user *models.User, code string) (bool, error) {
+	if !user.TotpEnabled {
+		return false, nil
+	}
+
+	if step, ok := ValidateTOTP(user.TotpSecret, code, user.TotpLastUsedStep, time.Now()); ok {
+		result := initializers.DB.Model(&models.User{}).
+			Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+			Update("totp_last_used_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected == 1, nil
+	}
+
+	result := initializers.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes discards the user's previous recovery codes and returns a new set.
+// Only their hashes are stored, the plaintext codes are shown to the user once.
+func ReplaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: hashToken(code)})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// 32 symbols without the easily confused i, l and o
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+
+// newRecoveryCode returns a code formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, c := range buf {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(recoveryCodeAlphabet[c&31])
+	}
+	return b.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+func mfaEnrollmentRequiredError() error {
+	return &gqlerror.Error{
+		Message:    "multi-factor authentication must be set up before continuing",
+		Extensions: map[string]interface{}{"code": ErrCodeMFARequired},
+	}
+}
+
+// MfaEnrollment implements the @mfaEnrollment directive. It is only a marker read by PublicFields.
+func MfaEnrollment(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	return next(ctx)
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+// openDB points the package at a scripted database for the duration of the test
+func openDB(t *testing.T) *testdb.DB {
+	t.Helper()
+	gdb, db := testdb.Open(t)
+	previous := initializers.DB
+	initializers.DB = gdb
+	t.Cleanup(func() { initializers.DB = previous })
+	return db
+}
+
+// RFC 6238 appendix B secret, "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The last six digits of the SHA1 test vectors of RFC 6238
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if code := totpCode(key, tt.unix/totpPeriod); code != tt.code {
+			t.Errorf("code at %d is %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		now          time.Time
+		ok           bool
+	}{
+		{"current code", "005924", 0, now, true},
+		{"code with spaces", " 005 924 ", 0, now, true},
+		{"previous period", "005924", 0, now.Add(totpPeriod * time.Second), true},
+		{"next period", "005924", 0, now.Add(-totpPeriod * time.Second), true},
+		{"expired code", "005924", 0, now.Add(2 * totpPeriod * time.Second), false},
+		{"replayed code", "005924", current, now, false},
+		{"wrong code", "005925", 0, now, false},
+		{"short code", "05924", 0, now, false},
+		{"long code", "0059240", 0, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfcSecret, tt.code, tt.lastUsedStep, tt.now)
+			if ok != tt.ok {
+				t.Fatalf("ValidateTOTP = %v, want %v", ok, tt.ok)
+			}
+			if ok && step != current {
+				t.Fatalf("matched step %d, want %d", step, current)
+			}
+		})
+	}
+
+	if _, ok := ValidateTOTP("not base32!", "005924", 0, now); ok {
+		t.Error("a code was accepted for an invalid secret")
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := map[string]string{
+		"abcde-fghjk":   "abcde-fghjk",
+		"ABCDE-FGHJK":   "abcde-fghjk",
+		" abcdefghjk ":  "abcde-fghjk",
+		"abcde fghjk":   "abcde-fghjk",
+		"abcde-fghjk-x": "abcde-fghjk-x",
+	}
+	for code, want := range tests {
+		if got := normalizeRecoveryCode(code); got != want {
+			t.Errorf("normalizeRecoveryCode(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestReplaceRecoveryCodes(t *testing.T) {
+	db := openDB(t)
+
+	codes, err := ReplaceRecoveryCodes(initializers.DB, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+	format := regexp.MustCompile(`^[` + recoveryCodeAlphabet + `]{5}-[` + recoveryCodeAlphabet + `]{5}$`)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+	}
+
+	if len(db.Statements(`UPDATE "recovery_codes" SET "deleted_at"`)) != 1 {
+		t.Error("the previous recovery codes were not discarded")
+	}
+	inserts := db.Statements(`INSERT INTO "recovery_codes"`)
+	if len(inserts) != 1 {
+		t.Fatalf("got %d inserts, want 1", len(inserts))
+	}
+	stored := map[interface{}]bool{}
+	for _, arg := range inserts[0].Args {
+		stored[arg] = true
+	}
+	for _, code := range codes {
+		if stored[code] {
+			t.Errorf("recovery code %q was stored in plaintext", code)
+		}
+		if !stored[hashToken(code)] {
+			t.Errorf("the hash of recovery code %q was not stored", code)
+		}
+	}
+}
+
+func TestVerifyMFACode(t *testing.T) {
+	now := time.Now()
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := totpCode(key, now.Unix()/totpPeriod)
+	user := func(lastUsedStep int64) *models.User {
+		return &models.User{TotpEnabled: true, TotpSecret: rfcSecret, TotpLastUsedStep: lastUsedStep}
+	}
+
+	t.Run("TOTP code consumes its step", func(t *testing.T) {
+		db := openDB(t)
+		ok, err := VerifyMFACode(user(0), current)
+		if err != nil || !ok {
+			t.Fatalf("VerifyMFACode = %v, %v", ok, err)
+		}
+		if len(db.Statements(`UPDATE "users"`, "totp_last_used_step")) != 1 {
+			t.Fatal("the time step was not consumed")
+		}
+	})
+
+	t.Run("TOTP code used concurrently", func(t *testing.T) {
+		db := openDB(t)
+		db.On(testdb.Rule{Contains: []string{`UPDATE "users"`}, RowsAffected: 0})
+		if ok, err := VerifyMFACode(user(0), current); err != nil || ok {
+			t.Fatalf("VerifyMFACode = %v, %v", ok, err)
+		}
+	})
+
+	t.Run("replayed TOTP code", func(t *testing.T) {
+		db := openDB(t)
+		db.On(testdb.Rule{Contains: []string{`UPDATE "recovery_codes"`}, RowsAffected: 0})
+		if ok, err := VerifyMFACode(user(now.Unix()/totpPeriod+totpSkew), current); err != nil || ok {
+			t.Fatalf("VerifyMFACode = %v, %v", ok, err)
+		}
+		if len(db.Statements(`UPDATE "users"`)) != 0 {
+			t.Fatal("a replayed code reached the database")
+		}
+	})
+
+	t.Run("recovery code is single use", func(t *testing.T) {
+		db := openDB(t)
+		db.On(testdb.Rule{Contains: []string{`UPDATE "recovery_codes"`}, RowsAffected: 0})
+		db.On(testdb.Rule{Contains: []string{`UPDATE "recovery_codes"`}, RowsAffected: 1, Times: 1})
+		if ok, err := VerifyMFACode(user(0), "ABCDE FGHJK"); err != nil || !ok {
+			t.Fatalf("first use: VerifyMFACode = %v, %v", ok, err)
+		}
+		if ok, err := VerifyMFACode(user(0), "abcde-fghjk"); err != nil || ok {
+			t.Fatalf("second use: VerifyMFACode = %v, %v", ok, err)
+		}
+		for _, statement := range db.Statements(`UPDATE "recovery_codes"`, "used_at IS NULL") {
+			if !containsValue(statement.Args, hashToken("abcde-fghjk")) {
+				t.Fatalf("the recovery code was not looked up by its hash: %v", statement.Args)
+			}
+		}
+	})
+
+	t.Run("MFA disabled", func(t *testing.T) {
+		openDB(t)
+		disabled := user(0)
+		disabled.TotpEnabled = false
+		if ok, err := VerifyMFACode(disabled, current); err != nil || ok {
+			t.Fatalf("VerifyMFACode = %v, %v", ok, err)
+		}
+	})
+}
+
+func TestMFAChallengeIsNotAnAccessToken(t *testing.T) {
+	openDB(t)
+	challenge, _, err := NewMFAChallenge(&models.User{TotpEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateJWT(challenge); err == nil {
+		t.Fatal("an MFA challenge was accepted as access token")
+	}
+
+	access, err := GenerateJWT(&models.User{}, "6f1c2a52-3b1e-4c55-9f0c-0d5a8c1e7b10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseMFAChallenge(access); err == nil {
+		t.Fatal("an access token was accepted as MFA challenge")
+	}
+}
+
+func containsValue(values []driver.Value, value driver.Value) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Public implements the @public directive. It is only a marker read by PublicFields.
+func Public(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	return next(ctx)
+}
+
+// PublicFields denies every root field to anonymous callers unless the field is
+// marked with @public in the schema. Nested fields are covered by their root field.
+// Callers that still have to enroll in MFA are limited to @public and @mfaEnrollment fields,
+// API keys to the @serviceAccess fields they are scoped to.
+type PublicFields struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = PublicFields{}
+
+func (PublicFields) ExtensionName() string {
+	return "PublicFields"
+}
+
+func (PublicFields) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (PublicFields) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !isRootObject(fc.Object) || strings.HasPrefix(fc.Field.Name, "__") {
+		return next(ctx)
+	}
+	if fc.Field.Definition != nil && fc.Field.Definition.Directives.ForName("public") != nil {
+		return next(ctx)
+	}
+	claims, ok := ctx.Value(UserCtxKey).(jwt.MapClaims)
+	if !ok {
+		return nil, unauthenticatedError()
+	}
+	if principal, ok := GetAPIKeyPrincipal(ctx); ok {
+		if fc.Field.Definition == nil || fc.Field.Definition.Directives.ForName("serviceAccess") == nil ||
+			!principal.hasScope(fc.Field.Name) {
+			return nil, scopeError(fc.Field.Name)
+		}
+		return next(ctx)
+	}
+	if pending, _ := claims["mfa_enrollment"].(bool); pending &&
+		(fc.Field.Definition == nil || fc.Field.Definition.Directives.ForName("mfaEnrollment") == nil) {
+		return nil, mfaEnrollmentRequiredError()
+	}
+	return next(ctx)
+}
+
+func isRootObject(name string) bool {
+	return name == "Query" || name == "Mutation" || name == "Subscription"
+}
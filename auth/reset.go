@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm/clause"
+)
+
+const defaultPasswordResetTTL = time.Hour
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// CreatePasswordResetToken issues a single use reset token for the user. Only its hash is
+// stored and any reset token issued before is invalidated.
+func CreatePasswordResetToken(userID uint) (string, error) {
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	now := time.Now()
+	if err := initializers.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", now).Error; err != nil {
+		return "", err
+	}
+
+	resetToken := models.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(durationFromEnv("PASSWORD_RESET_TTL", defaultPasswordResetTTL)),
+	}
+	if err := initializers.DB.Create(&resetToken).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken marks the token as used and returns the user it was issued for.
+// The check and the update happen in one statement so a token can never be used twice.
+func ConsumePasswordResetToken(token string) (uint, error) {
+	var consumed []models.PasswordResetToken
+	result := initializers.DB.Model(&consumed).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "user_id"}}}).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 || len(consumed) == 0 {
+		return 0, ErrInvalidResetToken
+	}
+	return consumed[0].UserID, nil
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// bcryptHashPattern matches bcrypt hashes as produced by golang.org/x/crypto/bcrypt
+var bcryptHashPattern = regexp.MustCompile(`\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}`)
+
+// SecretGuard keeps credentials out of the API. When the server starts it refuses a
+// schema whose output types expose a secret bearing field (password, secret, *hash), and
+// every response is scanned for password hashes before it leaves the server, so neither
+// a schema change nor a careless mapping can reintroduce the leak.
+type SecretGuard struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = SecretGuard{}
+
+func (SecretGuard) ExtensionName() string {
+	return "SecretGuard"
+}
+
+func (SecretGuard) Validate(schema graphql.ExecutableSchema) error {
+	if fields := SecretBearingFields(schema.Schema()); len(fields) > 0 {
+		return fmt.Errorf("schema exposes secret bearing fields: %s", strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// SecretBearingFields lists the output fields of the schema whose name suggests they carry
+// credentials. Fields of the root operation types are operations, not data, and are skipped.
+func SecretBearingFields(schema *ast.Schema) []string {
+	roots := map[string]bool{}
+	for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+		if root != nil {
+			roots[root.Name] = true
+		}
+	}
+
+	var found []string
+	for name, def := range schema.Types {
+		if roots[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+		for _, field := range def.Fields {
+			if isSecretFieldName(field.Name) {
+				found = append(found, name+"."+field.Name)
+			}
+		}
+	}
+	return found
+}
+
+func isSecretFieldName(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.HasSuffix(lower, "hash")
+}
+
+func (SecretGuard) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || !bcryptHashPattern.Match(resp.Data) {
+		return resp
+	}
+
+	log.Printf("Blocked a response containing a password hash")
+	return &graphql.Response{
+		Errors: gqlerror.List{{
+			Message:    "internal error: response withheld",
+			Extensions: map[string]interface{}{"code": "INTERNAL_SERVER_ERROR"},
+		}},
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSecretBearingFields(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { user(password: String): User }
+		type User { name: String passwordHash: String totpSecret: String }
+		interface Credential { keyHash: String }
+		input LoginInput { password: String }
+	`})
+	fields := SecretBearingFields(schema)
+	sort.Strings(fields)
+	if want := []string{"Credential.keyHash", "User.passwordHash", "User.totpSecret"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("got %v, want %v", fields, want)
+	}
+}
+
+func TestSecretGuardWithholdsPasswordHashes(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]string{"googleId": string(hash)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := SecretGuard{}.InterceptResponse(context.Background(), func(context.Context) *graphql.Response {
+		return &graphql.Response{Data: data}
+	})
+	if resp.Data != nil || len(resp.Errors) != 1 {
+		t.Fatalf("a response with a password hash was let through: %s", resp.Data)
+	}
+
+	clean := []byte(`{"name":"Jane"}`)
+	resp = SecretGuard{}.InterceptResponse(context.Background(), func(context.Context) *graphql.Response {
+		return &graphql.Response{Data: clean}
+	})
+	if string(resp.Data) != string(clean) {
+		t.Fatalf("a clean response was changed to %s", resp.Data)
+	}
+}
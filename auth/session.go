@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// newOpaqueToken returns a random URL safe token and the hash that is persisted for it
+func newOpaqueToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+// hashToken hashes opaque tokens before they are stored so a database leak does not leak live tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSession starts a server side session for the user and returns a short lived
+// access token together with the refresh token used to obtain new access tokens.
+func NewSession(user *models.User) (accessToken string, refreshToken string, err error) {
+	_, accessToken, refreshToken, err = newSession(initializers.DB, user)
+	return accessToken, refreshToken, err
+}
+
+func newSession(db *gorm.DB, user *models.User) (*models.Session, string, string, error) {
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        time.Now().Add(refreshTokenTTL()),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, "", "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	accessToken, err := GenerateJWT(user, session.ID.String())
+	if err != nil {
+		return nil, "", "", err
+	}
+	return &session, accessToken, refreshToken, nil
+}
+
+// RefreshSession rotates the refresh token: the presented session is revoked and
+// replaced by a new one. Presenting a refresh token that was already rotated is
+// treated as token theft and revokes every session of the user.
+func RefreshSession(refreshToken string) (*models.User, string, string, error) {
+	var (
+		user          models.User
+		accessToken   string
+		newRefreshTok string
+		reused        bool
+		reusedUserID  uint
+	)
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&session, "refresh_token_hash = ?", hashToken(refreshToken)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if session.RevokedAt != nil {
+			if session.ReplacedByID != nil {
+				reused = true
+				reusedUserID = session.UserID
+			}
+			return ErrInvalidRefreshToken
+		}
+		if time.Now().After(session.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := tx.First(&user, "id = ?", session.UserID).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		replacement, access, refresh, err := newSession(tx, &user)
+		if err != nil {
+			return err
+		}
+		accessToken, newRefreshTok = access, refresh
+
+		now := time.Now()
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"replaced_by_id": replacement.ID,
+		}).Error
+	})
+
+	if reused {
+		log.Printf("Refresh token reuse detected for user %d, revoking all sessions", reusedUserID)
+		if err := RevokeAllSessions(reusedUserID); err != nil {
+			log.Printf("Error revoking sessions for user %d: %v", reusedUserID, err)
+		}
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	return &user, accessToken, newRefreshTok, nil
+}
+
+// RevokeSession revokes a single session, invalidating its access and refresh tokens
+func RevokeSession(sessionID string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return fmt.Errorf("invalid session ID: %w", err)
+	}
+	return initializers.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllSessions revokes every active session of the user
+func RevokeAllSessions(userID uint) error {
+	return initializers.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOtherSessions revokes every active session of the user except the given one
+func RevokeOtherSessions(userID uint, keepSessionID string) error {
+	return initializers.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND id::text <> ?", userID, keepSessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// sessionActive reports whether the session behind an access token may still be used
+func sessionActive(sessionID string) bool {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return false
+	}
+	var count int64
+	if err := initializers.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", id, time.Now()).
+		Count(&count).Error; err != nil {
+		log.Printf("Error checking session %s: %v", sessionID, err)
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm"
+)
+
+const sessionID = "6f1c2a52-3b1e-4c55-9f0c-0d5a8c1e7b10"
+
+var sessionColumns = []string{"id", "user_id", "refresh_token_hash", "expires_at", "revoked_at", "replaced_by_id"}
+
+func TestNewSessionStoresOnlyTheRefreshTokenHash(t *testing.T) {
+	db := openDB(t)
+
+	access, refresh, err := NewSession(&models.User{Model: gorm.Model{ID: 7}, Role: "SALES_EXECUTIVE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inserts := db.Statements(`INSERT INTO "sessions"`)
+	if len(inserts) != 1 {
+		t.Fatalf("got %d inserts, want 1", len(inserts))
+	}
+	if containsValue(inserts[0].Args, refresh) || !containsValue(inserts[0].Args, hashToken(refresh)) {
+		t.Fatalf("the session must store the hash of the refresh token only: %v", inserts[0].Args)
+	}
+
+	claims, err := parseToken(access, tokenTypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["user_id"] != "7" || claims["sid"] == "" {
+		t.Fatalf("the access token is not bound to the session: %v", claims)
+	}
+}
+
+func TestValidateJWTChecksTheSession(t *testing.T) {
+	openDB(t)
+	token, err := GenerateJWT(&models.User{Model: gorm.Model{ID: 7}}, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, active := range []int64{0, 1} {
+		db := openDB(t)
+		db.On(testdb.Rule{Contains: []string{`FROM "sessions"`, "count(*)"}, Columns: []string{"count"}, Rows: [][]driver.Value{{active}}})
+		_, err := ValidateJWT(token)
+		if (err == nil) != (active == 1) {
+			t.Errorf("ValidateJWT with %d active sessions: %v", active, err)
+		}
+	}
+
+	if _, err := ValidateJWT(token + "x"); err == nil {
+		t.Error("a token with an invalid signature was accepted")
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	now := time.Now()
+	replacement := "0d9f8e7c-6b5a-4c3d-8e2f-1a0b9c8d7e6f"
+	tests := []struct {
+		name string
+		// session is the stored row of the presented refresh token, nil when it is unknown
+		session   []driver.Value
+		rotated   bool
+		revokeAll bool
+	}{
+		{"active session", []driver.Value{sessionID, int64(7), "hash", now.Add(time.Hour), nil, nil}, true, false},
+		{"unknown token", nil, false, false},
+		{"expired session", []driver.Value{sessionID, int64(7), "hash", now.Add(-time.Hour), nil, nil}, false, false},
+		{"logged out session", []driver.Value{sessionID, int64(7), "hash", now.Add(time.Hour), now, nil}, false, false},
+		{"rotated token reused", []driver.Value{sessionID, int64(7), "hash", now.Add(time.Hour), now, replacement}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			if tt.session != nil {
+				db.On(testdb.Rule{Contains: []string{`FROM "sessions"`, "refresh_token_hash = $1", "FOR UPDATE"}, Columns: sessionColumns, Rows: [][]driver.Value{tt.session}})
+			}
+			db.On(testdb.Rule{Contains: []string{`FROM "users"`}, Columns: []string{"id", "role"}, Rows: [][]driver.Value{{int64(7), "SALES_EXECUTIVE"}}})
+
+			user, access, refresh, err := RefreshSession("presented")
+			if tt.rotated {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if user.ID != 7 || access == "" || refresh == "" || refresh == "presented" {
+					t.Fatalf("unexpected result %v, %q, %q", user, access, refresh)
+				}
+				if len(db.Statements(`UPDATE "sessions"`, "replaced_by_id")) != 1 {
+					t.Fatal("the presented session was not replaced")
+				}
+			} else if !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Fatalf("expected ErrInvalidRefreshToken, got %v", err)
+			}
+
+			if got := db.Statements(`FROM "sessions"`, "refresh_token_hash = $1"); len(got) != 1 || !containsValue(got[0].Args, hashToken("presented")) {
+				t.Fatalf("the session was not looked up by the hash of the refresh token: %v", got)
+			}
+			if revoked := len(db.Statements(`UPDATE "sessions"`, "user_id = $")) > 0; revoked != tt.revokeAll {
+				t.Fatalf("all sessions revoked = %v, want %v", revoked, tt.revokeAll)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	db := openDB(t)
+	if err := RevokeSession("not-a-session"); err == nil {
+		t.Fatal("an invalid session ID was accepted")
+	}
+	if err := RevokeSession(sessionID); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Statements(`UPDATE "sessions" SET "revoked_at"`, "revoked_at IS NULL")) != 1 {
+		t.Fatal("the session was not revoked")
+	}
+}
+
+func TestRevokeOtherSessionsKeepsTheCurrentOne(t *testing.T) {
+	db := openDB(t)
+	if err := RevokeOtherSessions(7, sessionID); err != nil {
+		t.Fatal(err)
+	}
+	statements := db.Statements(`UPDATE "sessions" SET "revoked_at"`, "id::text <> $")
+	if len(statements) != 1 || !containsValue(statements[0].Args, sessionID) {
+		t.Fatalf("the current session was not kept: %v", statements)
+	}
+}
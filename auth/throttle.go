@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lockout policy. Once the number of consecutive failures for an account or an IP
+// reaches its threshold every further failure locks it again, each time twice as long,
+// up to maxLockout. Failures older than failureWindow are forgotten.
+const (
+	accountFailureThreshold = 5
+	ipFailureThreshold      = 20
+	baseLockout             = time.Minute
+	maxLockout              = time.Hour
+	failureWindow           = 24 * time.Hour
+)
+
+const (
+	LockoutScopeAccount = "ACCOUNT"
+	LockoutScopeIP      = "IP"
+
+	LockoutEventLocked   = "LOCKED"
+	LockoutEventUnlocked = "UNLOCKED"
+)
+
+// ErrInvalidCredentials is returned for every failed login so accounts cannot be enumerated
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// SimulatePasswordCheck spends the time of a bcrypt comparison when no account matched,
+// so response times do not reveal which emails are registered.
+func SimulatePasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func tooManyAttemptsError(until time.Time) error {
+	return &gqlerror.Error{
+		Message: "too many failed login attempts, try again later",
+		Extensions: map[string]interface{}{
+			"code":       "TOO_MANY_ATTEMPTS",
+			"retryAfter": int(math.Ceil(time.Until(until).Seconds())),
+		},
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CheckLoginAllowed rejects the attempt while the account or the client IP is locked
+func CheckLoginAllowed(email, ip string) error {
+	keys := []string{accountKey(email)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+
+	var throttles []models.LoginThrottle
+	if err := initializers.DB.Where("key IN ? AND locked_until > ?", keys, time.Now()).Find(&throttles).Error; err != nil {
+		log.Printf("Error checking login throttle: %v", err)
+		return fmt.Errorf("internal error: failed to login")
+	}
+
+	var until time.Time
+	for _, t := range throttles {
+		if t.LockedUntil != nil && t.LockedUntil.After(until) {
+			until = *t.LockedUntil
+		}
+	}
+	if !until.IsZero() {
+		return tooManyAttemptsError(until)
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed attempt against the account and the client IP and
+// locks them once their threshold is reached. userID is nil when no account matched.
+func RecordLoginFailure(email, ip string, userID *uint) {
+	if err := recordFailure(accountKey(email), accountFailureThreshold, LockoutScopeAccount, strings.ToLower(strings.TrimSpace(email)), ip, userID); err != nil {
+		log.Printf("Error recording failed login for account: %v", err)
+	}
+	if ip == "" {
+		return
+	}
+	if err := recordFailure(ipKey(ip), ipFailureThreshold, LockoutScopeIP, ip, ip, nil); err != nil {
+		log.Printf("Error recording failed login for IP: %v", err)
+	}
+}
+
+func recordFailure(key string, threshold int, scope, identifier, ip string, userID *uint) error {
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+
+		var throttle models.LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&throttle, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if throttle.LastFailureAt != nil && now.Sub(*throttle.LastFailureAt) > failureWindow {
+			throttle.Failures = 0
+		}
+		throttle.Failures++
+		throttle.LastFailureAt = &now
+
+		if throttle.Failures >= threshold {
+			lockout := baseLockout << uint(min(throttle.Failures-threshold, 10))
+			if lockout > maxLockout {
+				lockout = maxLockout
+			}
+			until := now.Add(lockout)
+			throttle.LockedUntil = &until
+
+			if err := tx.Create(&models.LockoutEvent{
+				Kind:        LockoutEventLocked,
+				Scope:       scope,
+				Identifier:  identifier,
+				UserID:      userID,
+				IPAddress:   ip,
+				Failures:    throttle.Failures,
+				LockedUntil: &until,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(&throttle).Error
+	})
+}
+
+// RecordLoginSuccess resets the failure count of the account. The IP counter is kept so a
+// valid login cannot be used to reset the budget of an attacker guessing other accounts.
+func RecordLoginSuccess(email string) {
+	if err := initializers.DB.Where("key = ?", accountKey(email)).Delete(&models.LoginThrottle{}).Error; err != nil {
+		log.Printf("Error resetting login throttle: %v", err)
+	}
+}
+
+// UnlockAccount lifts a lockout of the user's account and records who lifted it
+func UnlockAccount(user *models.User, actorID uint) error {
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key = ?", accountKey(user.Email)).Delete(&models.LoginThrottle{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LockoutEvent{
+			Kind:       LockoutEventUnlocked,
+			Scope:      LockoutScopeAccount,
+			Identifier: strings.ToLower(user.Email),
+			UserID:     &user.ID,
+			ActorID:    &actorID,
+		}).Error
+	})
+}
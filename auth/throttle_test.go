@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var throttleColumns = []string{"key", "failures", "last_failure_at", "locked_until"}
+
+func TestCheckLoginAllowed(t *testing.T) {
+	db := openDB(t)
+	if err := CheckLoginAllowed(" Jane@Example.com ", "10.0.0.1"); err != nil {
+		t.Fatalf("an attempt without lockout was rejected: %v", err)
+	}
+	lookups := db.Statements(`FROM "login_throttles"`, "locked_until > $")
+	if len(lookups) != 1 || !containsValue(lookups[0].Args, "account:jane@example.com") || !containsValue(lookups[0].Args, "ip:10.0.0.1") {
+		t.Fatalf("the account and the IP were not both checked: %v", lookups)
+	}
+
+	until := time.Now().Add(90 * time.Second)
+	db.On(testdb.Rule{Contains: []string{`FROM "login_throttles"`}, Columns: throttleColumns, Rows: [][]driver.Value{{"ip:10.0.0.1", int64(20), time.Now(), until}}})
+	err := CheckLoginAllowed("jane@example.com", "10.0.0.1")
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) || gqlErr.Extensions["code"] != "TOO_MANY_ATTEMPTS" {
+		t.Fatalf("expected TOO_MANY_ATTEMPTS, got %v", err)
+	}
+	if retryAfter, _ := gqlErr.Extensions["retryAfter"].(int); retryAfter < 89 || retryAfter > 90 {
+		t.Fatalf("retryAfter is %v, want 90", gqlErr.Extensions["retryAfter"])
+	}
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		// failures and lastFailure are stored for the account before the attempt
+		failures    int64
+		lastFailure time.Time
+		want        int
+		lockout     time.Duration
+	}{
+		{"first failure", 0, time.Time{}, 1, 0},
+		{"below the threshold", 3, now.Add(-time.Minute), 4, 0},
+		{"reaching the threshold", 4, now.Add(-time.Minute), 5, baseLockout},
+		{"failing again while locked out", 6, now.Add(-time.Minute), 7, 4 * baseLockout},
+		{"long after the threshold", 40, now.Add(-time.Minute), 41, maxLockout},
+		{"failures outside the window", 4, now.Add(-failureWindow - time.Minute), 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			var lastFailure driver.Value
+			if !tt.lastFailure.IsZero() {
+				lastFailure = tt.lastFailure
+			}
+			db.On(testdb.Rule{
+				Contains: []string{`FROM "login_throttles"`, "FOR UPDATE"},
+				Columns:  throttleColumns,
+				Rows:     [][]driver.Value{{"account:jane@example.com", tt.failures, lastFailure, nil}},
+			})
+
+			RecordLoginFailure("jane@example.com", "", nil)
+
+			saves := db.Statements(`UPDATE "login_throttles"`)
+			if len(saves) != 1 {
+				t.Fatalf("got %d updates, want 1", len(saves))
+			}
+			if !containsValue(saves[0].Args, tt.want) {
+				t.Fatalf("stored %v, want %d failures", saves[0].Args, tt.want)
+			}
+			events := db.Statements(`INSERT INTO "lockout_events"`)
+			if locked := len(events) > 0; locked != (tt.lockout > 0) {
+				t.Fatalf("locked = %v, want %v", locked, tt.lockout > 0)
+			}
+			if tt.lockout == 0 {
+				return
+			}
+			until := lockedUntil(t, saves[0].Args)
+			if lockout := time.Until(until); lockout < tt.lockout-time.Second || lockout > tt.lockout {
+				t.Fatalf("locked out for %v, want %v", lockout, tt.lockout)
+			}
+		})
+	}
+}
+
+func TestRecordLoginFailureCountsTheIP(t *testing.T) {
+	db := openDB(t)
+	RecordLoginFailure("jane@example.com", "10.0.0.1", nil)
+
+	for _, key := range []string{"account:jane@example.com", "ip:10.0.0.1"} {
+		found := false
+		for _, insert := range db.Statements(`INSERT INTO "login_throttles"`) {
+			found = found || containsValue(insert.Args, key)
+		}
+		if !found {
+			t.Errorf("no failure was counted for %s", key)
+		}
+	}
+}
+
+func TestRecordLoginSuccessKeepsTheIPCount(t *testing.T) {
+	db := openDB(t)
+	RecordLoginSuccess(" Jane@Example.com")
+
+	deletes := db.Statements(`DELETE FROM "login_throttles"`)
+	if len(deletes) != 1 || len(deletes[0].Args) != 1 || deletes[0].Args[0] != "account:jane@example.com" {
+		t.Fatalf("only the account throttle must be reset: %v", deletes)
+	}
+}
+
+// lockedUntil returns the lockout end among the arguments of the statement that saved a throttle
+func lockedUntil(t *testing.T, args []driver.Value) time.Time {
+	t.Helper()
+	var until time.Time
+	for _, arg := range args {
+		var value time.Time
+		switch v := arg.(type) {
+		case time.Time:
+			value = v
+		case *time.Time:
+			if v != nil {
+				value = *v
+			}
+		}
+		if value.After(until) {
+			until = value
+		}
+	}
+	if !until.After(time.Now()) {
+		t.Fatalf("no lockout was stored: %v", args)
+	}
+	return until
+}
@@ -5,9 +5,11 @@ go 1.23.4
 require (
 	github.com/99designs/gqlgen v0.17.64
 	github.com/go-chi/cors v1.2.1
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
+	github.com/shopspring/decimal v1.4.0
 	github.com/vektah/gqlparser/v2 v2.5.22
 	golang.org/x/crypto v0.31.0
 	gorm.io/driver/postgres v1.5.11
@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	gqlgen "github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const maxBatchSize = 20
+
+// batchPOST accepts a JSON array of operations in a single POST request and
+// responds with an array of results in the same order. It must be registered
+// before transport.POST, which only understands a single operation per request.
+type batchPOST struct{}
+
+var _ gqlgen.Transport = batchPOST{}
+
+func (batchPOST) Supports(r *http.Request) bool {
+	if r.Method != http.MethodPost || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	// Restore the body for whichever transport ends up handling the request
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func (batchPOST) Do(w http.ResponseWriter, r *http.Request, exec gqlgen.GraphExecutor) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+
+	var batch []*gqlgen.RawParams
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeResponse(w, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("json request body could not be decoded: %v", err)}))
+		return
+	}
+	if len(batch) == 0 || len(batch) > maxBatchSize {
+		w.WriteHeader(http.StatusBadRequest)
+		writeResponse(w, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("batch must contain between 1 and %d operations", maxBatchSize)}))
+		return
+	}
+
+	// Operations run one after another so mutations keep the order the client sent them in
+	responses := make([]*gqlgen.Response, len(batch))
+	for i, params := range batch {
+		params.Headers = r.Header
+		start := gqlgen.Now()
+		params.ReadTime = gqlgen.TraceTiming{Start: start, End: gqlgen.Now()}
+
+		rc, opErr := exec.CreateOperationContext(ctx, params)
+		if opErr != nil {
+			responses[i] = exec.DispatchError(gqlgen.WithOperationContext(ctx, rc), opErr)
+			continue
+		}
+		handler, opCtx := exec.DispatchOperation(ctx, rc)
+		responses[i] = handler(opCtx)
+	}
+
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		writeResponse(w, &gqlgen.Response{Errors: gqlerror.List{gqlerror.Errorf("unable to encode batch response: %v", err)}})
+	}
+}
+
+func writeResponse(w io.Writer, response *gqlgen.Response) {
+	_ = json.NewEncoder(w).Encode(response)
+}
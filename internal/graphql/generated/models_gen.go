@@ -6,8 +6,17 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/shopspring/decimal"
 )
 
+type LoginResult interface {
+	IsLoginResult()
+}
+
 type Activity struct {
 	ActivityID           string `json:"activity_id"`
 	ActivityType         string `json:"activityType"`
@@ -19,24 +28,97 @@ type Activity struct {
 	LeadID               string `json:"leadId"`
 }
 
+type APIKey struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedBy  *User    `json:"createdBy,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+	LastUsedAt *string  `json:"lastUsedAt,omitempty"`
+	RevokedAt  *string  `json:"revokedAt,omitempty"`
+}
+
+type AssignmentRule struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Type         AssignmentRuleType `json:"type"`
+	Priority     int32              `json:"priority"`
+	Enabled      bool               `json:"enabled"`
+	CampaignID   *string            `json:"campaignID,omitempty"`
+	Countries    []string           `json:"countries"`
+	Regions      []string           `json:"regions"`
+	UserIDs      []string           `json:"userIDs"`
+	MaxOpenLeads *int32             `json:"maxOpenLeads,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt"`
+}
+
+type AssignmentRuleInput struct {
+	Name         string             `json:"name"`
+	Type         AssignmentRuleType `json:"type"`
+	Priority     int32              `json:"priority"`
+	Enabled      bool               `json:"enabled"`
+	CampaignID   *string            `json:"campaignID,omitempty"`
+	Countries    []string           `json:"countries,omitempty"`
+	Regions      []string           `json:"regions,omitempty"`
+	UserIDs      []string           `json:"userIDs,omitempty"`
+	MaxOpenLeads *int32             `json:"maxOpenLeads,omitempty"`
+}
+
 type AuthPayload struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	User         *User  `json:"user"`
 }
 
+func (AuthPayload) IsLoginResult() {}
+
 type Campaign struct {
-	CampaignID       string  `json:"campaignID"`
-	CampaignName     string  `json:"campaignName"`
-	CampaignCountry  string  `json:"campaignCountry"`
-	CampaignRegion   string  `json:"campaignRegion"`
-	IndustryTargeted string  `json:"industryTargeted"`
-	Users            []*User `json:"users"`
-	Leads            []*Lead `json:"leads"`
+	CampaignID       string            `json:"campaignID"`
+	CampaignName     string            `json:"campaignName"`
+	CampaignCountry  string            `json:"campaignCountry"`
+	CampaignRegion   string            `json:"campaignRegion"`
+	IndustryTargeted string            `json:"industryTargeted"`
+	Status           CampaignStatus    `json:"status"`
+	StartDate        *time.Time        `json:"startDate,omitempty"`
+	EndDate          *time.Time        `json:"endDate,omitempty"`
+	Budget           *scalars.Money    `json:"budget,omitempty"`
+	ActualSpend      scalars.Money     `json:"actualSpend"`
+	Users            []*User           `json:"users"`
+	Members          []*CampaignMember `json:"members"`
+	Leads            []*Lead           `json:"leads"`
+	Metrics          *CampaignMetrics  `json:"metrics"`
 }
 
 type CampaignFilter struct {
-	CampaignName    *string `json:"campaignName,omitempty"`
-	CampaignCountry *string `json:"campaignCountry,omitempty"`
+	CampaignName    *string         `json:"campaignName,omitempty"`
+	CampaignCountry *string         `json:"campaignCountry,omitempty"`
+	Status          *CampaignStatus `json:"status,omitempty"`
+}
+
+type CampaignMember struct {
+	User     *User        `json:"user"`
+	Role     CampaignRole `json:"role"`
+	JoinedAt time.Time    `json:"joinedAt"`
+}
+
+type CampaignMemberInput struct {
+	UserID string       `json:"userID"`
+	Role   CampaignRole `json:"role"`
+}
+
+type CampaignMetrics struct {
+	TotalLeads          int32                   `json:"totalLeads"`
+	LeadsByStage        []*LeadStageCount       `json:"leadsByStage"`
+	WonLeads            int32                   `json:"wonLeads"`
+	ConversionRate      float64                 `json:"conversionRate"`
+	DealCount           int32                   `json:"dealCount"`
+	DealValue           []*scalars.Money        `json:"dealValue"`
+	ActivitiesByChannel []*ChannelActivityCount `json:"activitiesByChannel"`
+	CostPerLead         *scalars.Money          `json:"costPerLead,omitempty"`
+	CostPerWonDeal      *scalars.Money          `json:"costPerWonDeal,omitempty"`
+	RemainingBudget     *scalars.Money          `json:"remainingBudget,omitempty"`
 }
 
 type CampaignPage struct {
@@ -49,6 +131,11 @@ type CampaignSortInput struct {
 	Order SortOrder         `json:"order"`
 }
 
+type ChannelActivityCount struct {
+	Channel    string `json:"channel"`
+	Activities int32  `json:"activities"`
+}
+
 type Contact struct {
 	ID          string  `json:"id"`
 	CreatedAt   string  `json:"createdAt"`
@@ -69,11 +156,19 @@ type CreateActivityInput struct {
 	LeadID               string `json:"leadId"`
 }
 
+type CreateAPIKeyInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
 type CreateCampaignInput struct {
-	CampaignName     string `json:"campaignName"`
-	CampaignCountry  string `json:"campaignCountry"`
-	CampaignRegion   string `json:"campaignRegion"`
-	IndustryTargeted string `json:"industryTargeted"`
+	CampaignName     string         `json:"campaignName"`
+	CampaignCountry  string         `json:"campaignCountry"`
+	CampaignRegion   string         `json:"campaignRegion"`
+	IndustryTargeted string         `json:"industryTargeted"`
+	StartDate        *time.Time     `json:"startDate,omitempty"`
+	EndDate          *time.Time     `json:"endDate,omitempty"`
+	Budget           *scalars.Money `json:"budget,omitempty"`
 }
 
 type CreateCaseStudyInput struct {
@@ -88,13 +183,14 @@ type CreateCaseStudyInput struct {
 }
 
 type CreateDealInput struct {
-	DealName            string     `json:"dealName"`
-	LeadID              string     `json:"leadID"`
-	DealStartDate       string     `json:"dealStartDate"`
-	DealEndDate         string     `json:"dealEndDate"`
-	ProjectRequirements string     `json:"ProjectRequirements"`
-	DealAmount          string     `json:"dealAmount"`
-	DealStatus          DealStatus `json:"dealStatus"`
+	DealName            string        `json:"dealName"`
+	LeadID              string        `json:"leadID"`
+	DealStartDate       time.Time     `json:"dealStartDate"`
+	DealEndDate         time.Time     `json:"dealEndDate"`
+	ProjectRequirements string        `json:"ProjectRequirements"`
+	DealAmount          scalars.Money `json:"dealAmount"`
+	DealStatus          DealStatus    `json:"dealStatus"`
+	ExpectedCloseDate   *time.Time    `json:"expectedCloseDate,omitempty"`
 }
 
 type CreateLeadInput struct {
@@ -106,7 +202,7 @@ type CreateLeadInput struct {
 	Phone              string       `json:"phone"`
 	LeadSource         string       `json:"leadSource"`
 	InitialContactDate string       `json:"initialContactDate"`
-	LeadAssignedTo     string       `json:"leadAssignedTo"`
+	LeadAssignedTo     *string      `json:"leadAssignedTo,omitempty"`
 	LeadStage          LeadStage    `json:"leadStage"`
 	LeadNotes          string       `json:"leadNotes"`
 	LeadPriority       LeadPriority `json:"leadPriority"`
@@ -123,7 +219,7 @@ type CreateLeadWithActivityInput struct {
 	Phone                string       `json:"phone"`
 	LeadSource           string       `json:"leadSource"`
 	InitialContactDate   string       `json:"initialContactDate"`
-	LeadAssignedTo       string       `json:"leadAssignedTo"`
+	LeadAssignedTo       *string      `json:"leadAssignedTo,omitempty"`
 	LeadStage            LeadStage    `json:"leadStage"`
 	LeadNotes            string       `json:"leadNotes"`
 	LeadPriority         LeadPriority `json:"leadPriority"`
@@ -138,13 +234,14 @@ type CreateLeadWithActivityInput struct {
 }
 
 type CreateOrganizationInput struct {
-	OrganizationName    string  `json:"organizationName"`
-	OrganizationEmail   string  `json:"organizationEmail"`
-	OrganizationWebsite *string `json:"organizationWebsite,omitempty"`
-	City                string  `json:"city"`
-	Country             string  `json:"country"`
-	NoOfEmployees       string  `json:"noOfEmployees"`
-	AnnualRevenue       string  `json:"annualRevenue"`
+	OrganizationName    string         `json:"organizationName"`
+	OrganizationEmail   string         `json:"organizationEmail"`
+	OrganizationWebsite *string        `json:"organizationWebsite,omitempty"`
+	City                string         `json:"city"`
+	Country             string         `json:"country"`
+	NoOfEmployees       string         `json:"noOfEmployees"`
+	AnnualRevenue       *scalars.Money `json:"annualRevenue,omitempty"`
+	ParentID            *string        `json:"parentID,omitempty"`
 }
 
 type CreateResourceProfileInput struct {
@@ -179,35 +276,156 @@ type CreateVendorInput struct {
 	SkillIds        []string     `json:"skillIds,omitempty"`
 }
 
+type CreatedAPIKey struct {
+	APIKey       *APIKey `json:"apiKey"`
+	PlaintextKey string  `json:"plaintextKey"`
+}
+
+type DateRangeInput struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
 type Deal struct {
-	DealID              string `json:"dealID"`
-	DealName            string `json:"dealName"`
-	LeadID              string `json:"leadID"`
-	DealStartDate       string `json:"dealStartDate"`
-	DealEndDate         string `json:"dealEndDate"`
-	ProjectRequirements string `json:"ProjectRequirements"`
-	DealAmount          string `json:"dealAmount"`
-	DealStatus          string `json:"dealStatus"`
+	DealID              string          `json:"dealID"`
+	DealName            string          `json:"dealName"`
+	LeadID              string          `json:"leadID"`
+	DealStartDate       *time.Time      `json:"dealStartDate,omitempty"`
+	DealEndDate         *time.Time      `json:"dealEndDate,omitempty"`
+	ProjectRequirements string          `json:"ProjectRequirements"`
+	DealAmount          scalars.Money   `json:"dealAmount"`
+	DealStatus          DealStatus      `json:"dealStatus"`
+	ExpectedCloseDate   *time.Time      `json:"expectedCloseDate,omitempty"`
+	ClosedAt            *time.Time      `json:"closedAt,omitempty"`
+	CreatedAt           time.Time       `json:"createdAt"`
+	UpdatedAt           time.Time       `json:"updatedAt"`
+	LineItems           []*DealLineItem `json:"lineItems"`
+}
+
+type DealAutomationConfig struct {
+	Enabled        bool                `json:"enabled"`
+	DefaultAmount  scalars.Money       `json:"defaultAmount"`
+	DurationMonths int32               `json:"durationMonths"`
+	DefaultStatus  DealStatus          `json:"defaultStatus"`
+	FieldMappings  []*DealFieldMapping `json:"fieldMappings"`
+	UpdatedBy      *User               `json:"updatedBy,omitempty"`
+	UpdatedAt      *time.Time          `json:"updatedAt,omitempty"`
+}
+
+type DealAutomationConfigInput struct {
+	Enabled        bool                     `json:"enabled"`
+	DefaultAmount  scalars.Money            `json:"defaultAmount"`
+	DurationMonths int32                    `json:"durationMonths"`
+	DefaultStatus  DealStatus               `json:"defaultStatus"`
+	FieldMappings  []*DealFieldMappingInput `json:"fieldMappings"`
+}
+
+type DealFieldMapping struct {
+	DealField DealMappingTarget `json:"dealField"`
+	LeadField LeadMappingSource `json:"leadField"`
+}
+
+type DealFieldMappingInput struct {
+	DealField DealMappingTarget `json:"dealField"`
+	LeadField LeadMappingSource `json:"leadField"`
+}
+
+type DealFilter struct {
+	DealName   *string     `json:"dealName,omitempty"`
+	LeadID     *string     `json:"leadID,omitempty"`
+	DealStatus *DealStatus `json:"dealStatus,omitempty"`
+}
+
+type DealLineItem struct {
+	ID              string           `json:"id"`
+	DealID          string           `json:"dealID"`
+	ResourceProfile *ResourceProfile `json:"resourceProfile,omitempty"`
+	Role            *string          `json:"role,omitempty"`
+	Skills          []*Skill         `json:"skills"`
+	Rate            scalars.Money    `json:"rate"`
+	Unit            LineItemUnit     `json:"unit"`
+	Quantity        decimal.Decimal  `json:"quantity"`
+	Total           scalars.Money    `json:"total"`
+	StartDate       *time.Time       `json:"startDate,omitempty"`
+	EndDate         *time.Time       `json:"endDate,omitempty"`
+	Description     *string          `json:"description,omitempty"`
+}
+
+type DealLineItemInput struct {
+	ResourceProfileID *string         `json:"resourceProfileID,omitempty"`
+	Role              *string         `json:"role,omitempty"`
+	SkillIDs          []string        `json:"skillIDs,omitempty"`
+	Rate              scalars.Money   `json:"rate"`
+	Unit              LineItemUnit    `json:"unit"`
+	Quantity          decimal.Decimal `json:"quantity"`
+	StartDate         *time.Time      `json:"startDate,omitempty"`
+	EndDate           *time.Time      `json:"endDate,omitempty"`
+	Description       *string         `json:"description,omitempty"`
+}
+
+type DealPage struct {
+	Items      []*Deal `json:"items"`
+	TotalCount int32   `json:"totalCount"`
+}
+
+type DealSortInput struct {
+	Field DealSortField `json:"field"`
+	Order SortOrder     `json:"order"`
+}
+
+type DealStageProbability struct {
+	DealStatus     DealStatus `json:"dealStatus"`
+	WinProbability int32      `json:"winProbability"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+type DealValueGroup struct {
+	Key       *string       `json:"key,omitempty"`
+	Label     string        `json:"label"`
+	Total     scalars.Money `json:"total"`
+	DealCount int32         `json:"dealCount"`
+}
+
+type DealValueReport struct {
+	BaseCurrency string                  `json:"baseCurrency"`
+	From         time.Time               `json:"from"`
+	To           time.Time               `json:"to"`
+	Total        scalars.Money           `json:"total"`
+	DealCount    int32                   `json:"dealCount"`
+	Groups       []*DealValueGroup       `json:"groups"`
+	Unconverted  []*UnconvertedDealValue `json:"unconverted"`
+}
+
+type ExchangeRate struct {
+	ID            string          `json:"id"`
+	FromCurrency  string          `json:"fromCurrency"`
+	ToCurrency    string          `json:"toCurrency"`
+	Rate          decimal.Decimal `json:"rate"`
+	EffectiveDate time.Time       `json:"effectiveDate"`
+	UpdatedBy     *User           `json:"updatedBy,omitempty"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
 }
 
 type Lead struct {
-	LeadID             string        `json:"leadID"`
-	FirstName          string        `json:"firstName"`
-	LastName           string        `json:"lastName"`
-	Email              string        `json:"email"`
-	LinkedIn           string        `json:"linkedIn"`
-	Country            string        `json:"country"`
-	Phone              string        `json:"phone"`
-	LeadSource         string        `json:"leadSource"`
-	InitialContactDate string        `json:"initialContactDate"`
-	LeadCreatedBy      *User         `json:"leadCreatedBy"`
-	LeadAssignedTo     *User         `json:"leadAssignedTo"`
-	LeadStage          string        `json:"leadStage"`
-	LeadNotes          string        `json:"leadNotes"`
-	LeadPriority       string        `json:"leadPriority"`
-	Organization       *Organization `json:"organization"`
-	Campaign           *Campaign     `json:"campaign"`
-	Activities         []*Activity   `json:"activities"`
+	LeadID             string          `json:"leadID"`
+	FirstName          string          `json:"firstName"`
+	LastName           string          `json:"lastName"`
+	Email              string          `json:"email"`
+	LinkedIn           string          `json:"linkedIn"`
+	Country            string          `json:"country"`
+	Phone              string          `json:"phone"`
+	LeadSource         string          `json:"leadSource"`
+	InitialContactDate string          `json:"initialContactDate"`
+	LeadCreatedBy      *User           `json:"leadCreatedBy"`
+	LeadAssignedTo     *User           `json:"leadAssignedTo,omitempty"`
+	AssignmentRule     *AssignmentRule `json:"assignmentRule,omitempty"`
+	LeadStage          LeadStage       `json:"leadStage"`
+	LeadNotes          string          `json:"leadNotes"`
+	LeadPriority       string          `json:"leadPriority"`
+	Organization       *Organization   `json:"organization,omitempty"`
+	Campaign           *Campaign       `json:"campaign"`
+	Activities         []*Activity     `json:"activities"`
+	Deals              []*Deal         `json:"deals"`
 }
 
 type LeadFilter struct {
@@ -215,6 +433,21 @@ type LeadFilter struct {
 	Email *string `json:"email,omitempty"`
 }
 
+type LeadFunnelReport struct {
+	From         time.Time              `json:"from"`
+	To           time.Time              `json:"to"`
+	LeadsCreated int32                  `json:"leadsCreated"`
+	Stages       []*LeadFunnelStage     `json:"stages"`
+	Conversions  []*LeadStageConversion `json:"conversions"`
+}
+
+type LeadFunnelStage struct {
+	Stage                LeadStage `json:"stage"`
+	Entered              int32     `json:"entered"`
+	Current              int32     `json:"current"`
+	MedianSecondsInStage *float64  `json:"medianSecondsInStage,omitempty"`
+}
+
 type LeadPage struct {
 	Items      []*Lead `json:"items"`
 	TotalCount int32   `json:"totalCount"`
@@ -225,19 +458,115 @@ type LeadSortInput struct {
 	Order SortOrder     `json:"order"`
 }
 
+type LeadStageChange struct {
+	ID             string     `json:"id"`
+	FromStage      *LeadStage `json:"fromStage,omitempty"`
+	ToStage        LeadStage  `json:"toStage"`
+	Reason         *string    `json:"reason,omitempty"`
+	ChangedBy      *User      `json:"changedBy,omitempty"`
+	ChangedAt      string     `json:"changedAt"`
+	LeftAt         *string    `json:"leftAt,omitempty"`
+	SecondsInStage int32      `json:"secondsInStage"`
+}
+
+type LeadStageConversion struct {
+	FromStage LeadStage `json:"fromStage"`
+	ToStage   LeadStage `json:"toStage"`
+	Leads     int32     `json:"leads"`
+	Rate      float64   `json:"rate"`
+}
+
+type LeadStageCount struct {
+	Stage LeadStage `json:"stage"`
+	Leads int32     `json:"leads"`
+}
+
+type LeadStageRule struct {
+	Stage       LeadStage   `json:"stage"`
+	Initial     bool        `json:"initial"`
+	Transitions []LeadStage `json:"transitions"`
+	Requires    []string    `json:"requires"`
+}
+
+type LockoutEvent struct {
+	ID          string           `json:"id"`
+	Kind        LockoutEventKind `json:"kind"`
+	Scope       LockoutScope     `json:"scope"`
+	Identifier  string           `json:"identifier"`
+	User        *User            `json:"user,omitempty"`
+	IPAddress   *string          `json:"ipAddress,omitempty"`
+	Failures    int32            `json:"failures"`
+	LockedUntil *string          `json:"lockedUntil,omitempty"`
+	Actor       *User            `json:"actor,omitempty"`
+	CreatedAt   string           `json:"createdAt"`
+}
+
+type LockoutEventPage struct {
+	Items      []*LockoutEvent `json:"items"`
+	TotalCount int32           `json:"totalCount"`
+}
+
+type MfaChallenge struct {
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresAt      string `json:"expiresAt"`
+}
+
+func (MfaChallenge) IsLoginResult() {}
+
+type MfaPolicy struct {
+	Role      UserRole `json:"role"`
+	Required  bool     `json:"required"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
 type Mutation struct {
 }
 
 type Organization struct {
-	ID                  string  `json:"ID"`
-	OrganizationName    string  `json:"organizationName"`
-	OrganizationEmail   string  `json:"organizationEmail"`
-	OrganizationWebsite *string `json:"organizationWebsite,omitempty"`
-	City                string  `json:"city"`
-	Country             string  `json:"country"`
-	NoOfEmployees       string  `json:"noOfEmployees"`
-	AnnualRevenue       string  `json:"annualRevenue"`
-	Leads               []*Lead `json:"leads"`
+	ID                  string              `json:"ID"`
+	OrganizationName    string              `json:"organizationName"`
+	OrganizationEmail   string              `json:"organizationEmail"`
+	OrganizationWebsite *string             `json:"organizationWebsite,omitempty"`
+	City                string              `json:"city"`
+	Country             string              `json:"country"`
+	NoOfEmployees       string              `json:"noOfEmployees"`
+	AnnualRevenue       *scalars.Money      `json:"annualRevenue,omitempty"`
+	ParentID            *string             `json:"parentID,omitempty"`
+	Parent              *Organization       `json:"parent,omitempty"`
+	Subsidiaries        []*Organization     `json:"subsidiaries"`
+	Leads               []*Lead             `json:"leads"`
+	Rollup              *OrganizationRollup `json:"rollup"`
+	CreatedAt           time.Time           `json:"createdAt"`
+	UpdatedAt           time.Time           `json:"updatedAt"`
+}
+
+type OrganizationFilter struct {
+	OrganizationName *string `json:"organizationName,omitempty"`
+	Country          *string `json:"country,omitempty"`
+	City             *string `json:"city,omitempty"`
+	ParentID         *string `json:"parentID,omitempty"`
+	TopLevel         *bool   `json:"topLevel,omitempty"`
+	Search           *string `json:"search,omitempty"`
+}
+
+type OrganizationPage struct {
+	Items      []*Organization `json:"items"`
+	TotalCount int32           `json:"totalCount"`
+}
+
+type OrganizationRollup struct {
+	Organizations int32            `json:"organizations"`
+	Leads         int32            `json:"leads"`
+	OpenLeads     int32            `json:"openLeads"`
+	WonLeads      int32            `json:"wonLeads"`
+	Deals         int32            `json:"deals"`
+	DealValue     []*scalars.Money `json:"dealValue"`
+	AnnualRevenue []*scalars.Money `json:"annualRevenue"`
+}
+
+type OrganizationSortInput struct {
+	Field OrganizationSortField `json:"field"`
+	Order SortOrder             `json:"order"`
 }
 
 type PaginationInput struct {
@@ -266,6 +595,43 @@ type PerformanceRating struct {
 type Query struct {
 }
 
+type Quote struct {
+	ID        string         `json:"id"`
+	DealID    string         `json:"dealID"`
+	Version   int32          `json:"version"`
+	Number    string         `json:"number"`
+	Status    QuoteStatus    `json:"status"`
+	Template  *QuoteTemplate `json:"template,omitempty"`
+	Total     scalars.Money  `json:"total"`
+	HTMLURL   string         `json:"htmlUrl"`
+	PDFURL    string         `json:"pdfUrl"`
+	CreatedBy *User          `json:"createdBy,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	SentAt    *time.Time     `json:"sentAt,omitempty"`
+	DecidedAt *time.Time     `json:"decidedAt,omitempty"`
+}
+
+type QuoteTemplate struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type QuoteTemplateInput struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	Body        string  `json:"body"`
+}
+
+type ResourceCommitment struct {
+	ResourceProfile *ResourceProfile `json:"resourceProfile"`
+	Deal            *Deal            `json:"deal"`
+	LineItem        *DealLineItem    `json:"lineItem"`
+}
+
 type ResourceProfile struct {
 	ID                 string         `json:"id"`
 	CreatedAt          string         `json:"createdAt"`
@@ -305,6 +671,32 @@ type ResourceProfileSortInput struct {
 	Order SortOrder                `json:"order"`
 }
 
+type SalesForecast struct {
+	BaseCurrency  string                  `json:"baseCurrency"`
+	From          time.Time               `json:"from"`
+	To            time.Time               `json:"to"`
+	WeightedTotal scalars.Money           `json:"weightedTotal"`
+	BestCaseTotal scalars.Money           `json:"bestCaseTotal"`
+	DealCount     int32                   `json:"dealCount"`
+	Groups        []*SalesForecastGroup   `json:"groups"`
+	Unconverted   []*UnconvertedDealValue `json:"unconverted"`
+}
+
+type SalesForecastGroup struct {
+	Key           *string       `json:"key,omitempty"`
+	Label         string        `json:"label"`
+	WeightedTotal scalars.Money `json:"weightedTotal"`
+	BestCaseTotal scalars.Money `json:"bestCaseTotal"`
+	DealCount     int32         `json:"dealCount"`
+}
+
+type SetExchangeRateInput struct {
+	FromCurrency  string          `json:"fromCurrency"`
+	ToCurrency    string          `json:"toCurrency"`
+	Rate          decimal.Decimal `json:"rate"`
+	EffectiveDate time.Time       `json:"effectiveDate"`
+}
+
 type Skill struct {
 	ID          string  `json:"id"`
 	CreatedAt   string  `json:"createdAt"`
@@ -313,6 +705,17 @@ type Skill struct {
 	Description *string `json:"description,omitempty"`
 }
 
+type TotpEnrollment struct {
+	OtpauthURL     string `json:"otpauthURL"`
+	ManualEntryKey string `json:"manualEntryKey"`
+}
+
+type UnconvertedDealValue struct {
+	Currency  string        `json:"currency"`
+	Total     scalars.Money `json:"total"`
+	DealCount int32         `json:"dealCount"`
+}
+
 type UpdateActivityInput struct {
 	ActivityType         *string `json:"activityType,omitempty"`
 	DateTime             *string `json:"dateTime,omitempty"`
@@ -322,6 +725,17 @@ type UpdateActivityInput struct {
 	FollowUpActions      *string `json:"followUpActions,omitempty"`
 }
 
+type UpdateCampaignInput struct {
+	CampaignName     graphql.Omittable[*string]        `json:"campaignName,omitempty"`
+	CampaignCountry  graphql.Omittable[*string]        `json:"campaignCountry,omitempty"`
+	CampaignRegion   graphql.Omittable[*string]        `json:"campaignRegion,omitempty"`
+	IndustryTargeted graphql.Omittable[*string]        `json:"industryTargeted,omitempty"`
+	StartDate        graphql.Omittable[*time.Time]     `json:"startDate,omitempty"`
+	EndDate          graphql.Omittable[*time.Time]     `json:"endDate,omitempty"`
+	Budget           graphql.Omittable[*scalars.Money] `json:"budget,omitempty"`
+	ActualSpend      graphql.Omittable[*scalars.Money] `json:"actualSpend,omitempty"`
+}
+
 type UpdateCaseStudyInput struct {
 	ProjectName     string `json:"projectName"`
 	ClientName      string `json:"clientName"`
@@ -333,21 +747,44 @@ type UpdateCaseStudyInput struct {
 	Document        string `json:"document"`
 }
 
+type UpdateDealInput struct {
+	DealName            *string        `json:"dealName,omitempty"`
+	LeadID              *string        `json:"leadID,omitempty"`
+	DealStartDate       *time.Time     `json:"dealStartDate,omitempty"`
+	DealEndDate         *time.Time     `json:"dealEndDate,omitempty"`
+	ProjectRequirements *string        `json:"ProjectRequirements,omitempty"`
+	DealAmount          *scalars.Money `json:"dealAmount,omitempty"`
+	DealStatus          *DealStatus    `json:"dealStatus,omitempty"`
+	ExpectedCloseDate   *time.Time     `json:"expectedCloseDate,omitempty"`
+}
+
 type UpdateLeadInput struct {
-	FirstName          *string      `json:"firstName,omitempty"`
-	LastName           *string      `json:"lastName,omitempty"`
-	Email              string       `json:"email"`
-	LinkedIn           *string      `json:"linkedIn,omitempty"`
-	Country            *string      `json:"country,omitempty"`
-	Phone              *string      `json:"phone,omitempty"`
-	LeadSource         string       `json:"leadSource"`
-	InitialContactDate string       `json:"initialContactDate"`
-	LeadAssignedTo     string       `json:"leadAssignedTo"`
-	LeadStage          LeadStage    `json:"leadStage"`
-	LeadNotes          string       `json:"leadNotes"`
-	LeadPriority       LeadPriority `json:"leadPriority"`
-	OrganizationID     string       `json:"organizationID"`
-	CampaignID         string       `json:"campaignID"`
+	FirstName          graphql.Omittable[*string]       `json:"firstName,omitempty"`
+	LastName           graphql.Omittable[*string]       `json:"lastName,omitempty"`
+	Email              graphql.Omittable[*string]       `json:"email,omitempty"`
+	LinkedIn           graphql.Omittable[*string]       `json:"linkedIn,omitempty"`
+	Country            graphql.Omittable[*string]       `json:"country,omitempty"`
+	Phone              graphql.Omittable[*string]       `json:"phone,omitempty"`
+	LeadSource         graphql.Omittable[*string]       `json:"leadSource,omitempty"`
+	InitialContactDate graphql.Omittable[*string]       `json:"initialContactDate,omitempty"`
+	LeadAssignedTo     graphql.Omittable[*string]       `json:"leadAssignedTo,omitempty"`
+	LeadStage          graphql.Omittable[*LeadStage]    `json:"leadStage,omitempty"`
+	StageReason        *string                          `json:"stageReason,omitempty"`
+	LeadNotes          graphql.Omittable[*string]       `json:"leadNotes,omitempty"`
+	LeadPriority       graphql.Omittable[*LeadPriority] `json:"leadPriority,omitempty"`
+	OrganizationID     graphql.Omittable[*string]       `json:"organizationID,omitempty"`
+	CampaignID         graphql.Omittable[*string]       `json:"campaignID,omitempty"`
+}
+
+type UpdateOrganizationInput struct {
+	OrganizationName    graphql.Omittable[*string]        `json:"organizationName,omitempty"`
+	OrganizationEmail   graphql.Omittable[*string]        `json:"organizationEmail,omitempty"`
+	OrganizationWebsite graphql.Omittable[*string]        `json:"organizationWebsite,omitempty"`
+	City                graphql.Omittable[*string]        `json:"city,omitempty"`
+	Country             graphql.Omittable[*string]        `json:"country,omitempty"`
+	NoOfEmployees       graphql.Omittable[*string]        `json:"noOfEmployees,omitempty"`
+	AnnualRevenue       graphql.Omittable[*scalars.Money] `json:"annualRevenue,omitempty"`
+	ParentID            graphql.Omittable[*string]        `json:"parentID,omitempty"`
 }
 
 type UpdateResourceProfileInput struct {
@@ -387,7 +824,6 @@ type User struct {
 	Email     string      `json:"email"`
 	Phone     string      `json:"phone"`
 	Role      string      `json:"role"`
-	Password  string      `json:"password"`
 	Campaigns []*Campaign `json:"campaigns"`
 }
 
@@ -454,6 +890,90 @@ type CaseStudy struct {
 	Document        string `json:"document"`
 }
 
+type AssignmentRuleType string
+
+const (
+	AssignmentRuleTypeRoundRobin AssignmentRuleType = "ROUND_ROBIN"
+	AssignmentRuleTypeTerritory  AssignmentRuleType = "TERRITORY"
+)
+
+var AllAssignmentRuleType = []AssignmentRuleType{
+	AssignmentRuleTypeRoundRobin,
+	AssignmentRuleTypeTerritory,
+}
+
+func (e AssignmentRuleType) IsValid() bool {
+	switch e {
+	case AssignmentRuleTypeRoundRobin, AssignmentRuleTypeTerritory:
+		return true
+	}
+	return false
+}
+
+func (e AssignmentRuleType) String() string {
+	return string(e)
+}
+
+func (e *AssignmentRuleType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AssignmentRuleType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AssignmentRuleType", str)
+	}
+	return nil
+}
+
+func (e AssignmentRuleType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type CampaignRole string
+
+const (
+	CampaignRoleOwner  CampaignRole = "OWNER"
+	CampaignRoleMember CampaignRole = "MEMBER"
+	CampaignRoleViewer CampaignRole = "VIEWER"
+)
+
+var AllCampaignRole = []CampaignRole{
+	CampaignRoleOwner,
+	CampaignRoleMember,
+	CampaignRoleViewer,
+}
+
+func (e CampaignRole) IsValid() bool {
+	switch e {
+	case CampaignRoleOwner, CampaignRoleMember, CampaignRoleViewer:
+		return true
+	}
+	return false
+}
+
+func (e CampaignRole) String() string {
+	return string(e)
+}
+
+func (e *CampaignRole) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CampaignRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CampaignRole", str)
+	}
+	return nil
+}
+
+func (e CampaignRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type CampaignSortField string
 
 const (
@@ -495,6 +1015,278 @@ func (e CampaignSortField) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type CampaignStatus string
+
+const (
+	CampaignStatusDraft     CampaignStatus = "DRAFT"
+	CampaignStatusActive    CampaignStatus = "ACTIVE"
+	CampaignStatusPaused    CampaignStatus = "PAUSED"
+	CampaignStatusCompleted CampaignStatus = "COMPLETED"
+	CampaignStatusArchived  CampaignStatus = "ARCHIVED"
+)
+
+var AllCampaignStatus = []CampaignStatus{
+	CampaignStatusDraft,
+	CampaignStatusActive,
+	CampaignStatusPaused,
+	CampaignStatusCompleted,
+	CampaignStatusArchived,
+}
+
+func (e CampaignStatus) IsValid() bool {
+	switch e {
+	case CampaignStatusDraft, CampaignStatusActive, CampaignStatusPaused, CampaignStatusCompleted, CampaignStatusArchived:
+		return true
+	}
+	return false
+}
+
+func (e CampaignStatus) String() string {
+	return string(e)
+}
+
+func (e *CampaignStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CampaignStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CampaignStatus", str)
+	}
+	return nil
+}
+
+func (e CampaignStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DealMappingTarget string
+
+const (
+	DealMappingTargetDealName            DealMappingTarget = "DEAL_NAME"
+	DealMappingTargetProjectRequirements DealMappingTarget = "PROJECT_REQUIREMENTS"
+)
+
+var AllDealMappingTarget = []DealMappingTarget{
+	DealMappingTargetDealName,
+	DealMappingTargetProjectRequirements,
+}
+
+func (e DealMappingTarget) IsValid() bool {
+	switch e {
+	case DealMappingTargetDealName, DealMappingTargetProjectRequirements:
+		return true
+	}
+	return false
+}
+
+func (e DealMappingTarget) String() string {
+	return string(e)
+}
+
+func (e *DealMappingTarget) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DealMappingTarget(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DealMappingTarget", str)
+	}
+	return nil
+}
+
+func (e DealMappingTarget) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DealSortField string
+
+const (
+	DealSortFieldDealName      DealSortField = "DEAL_NAME"
+	DealSortFieldDealStartDate DealSortField = "DEAL_START_DATE"
+	DealSortFieldDealEndDate   DealSortField = "DEAL_END_DATE"
+	DealSortFieldCreatedAt     DealSortField = "CREATED_AT"
+)
+
+var AllDealSortField = []DealSortField{
+	DealSortFieldDealName,
+	DealSortFieldDealStartDate,
+	DealSortFieldDealEndDate,
+	DealSortFieldCreatedAt,
+}
+
+func (e DealSortField) IsValid() bool {
+	switch e {
+	case DealSortFieldDealName, DealSortFieldDealStartDate, DealSortFieldDealEndDate, DealSortFieldCreatedAt:
+		return true
+	}
+	return false
+}
+
+func (e DealSortField) String() string {
+	return string(e)
+}
+
+func (e *DealSortField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DealSortField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DealSortField", str)
+	}
+	return nil
+}
+
+func (e DealSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DealValueGroupBy string
+
+const (
+	DealValueGroupByCurrency DealValueGroupBy = "CURRENCY"
+	DealValueGroupByStatus   DealValueGroupBy = "STATUS"
+	DealValueGroupByCampaign DealValueGroupBy = "CAMPAIGN"
+	DealValueGroupByCountry  DealValueGroupBy = "COUNTRY"
+)
+
+var AllDealValueGroupBy = []DealValueGroupBy{
+	DealValueGroupByCurrency,
+	DealValueGroupByStatus,
+	DealValueGroupByCampaign,
+	DealValueGroupByCountry,
+}
+
+func (e DealValueGroupBy) IsValid() bool {
+	switch e {
+	case DealValueGroupByCurrency, DealValueGroupByStatus, DealValueGroupByCampaign, DealValueGroupByCountry:
+		return true
+	}
+	return false
+}
+
+func (e DealValueGroupBy) String() string {
+	return string(e)
+}
+
+func (e *DealValueGroupBy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DealValueGroupBy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DealValueGroupBy", str)
+	}
+	return nil
+}
+
+func (e DealValueGroupBy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ForecastGroupBy string
+
+const (
+	ForecastGroupByAssignee ForecastGroupBy = "ASSIGNEE"
+	ForecastGroupByCampaign ForecastGroupBy = "CAMPAIGN"
+	ForecastGroupByMonth    ForecastGroupBy = "MONTH"
+)
+
+var AllForecastGroupBy = []ForecastGroupBy{
+	ForecastGroupByAssignee,
+	ForecastGroupByCampaign,
+	ForecastGroupByMonth,
+}
+
+func (e ForecastGroupBy) IsValid() bool {
+	switch e {
+	case ForecastGroupByAssignee, ForecastGroupByCampaign, ForecastGroupByMonth:
+		return true
+	}
+	return false
+}
+
+func (e ForecastGroupBy) String() string {
+	return string(e)
+}
+
+func (e *ForecastGroupBy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ForecastGroupBy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ForecastGroupBy", str)
+	}
+	return nil
+}
+
+func (e ForecastGroupBy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LeadMappingSource string
+
+const (
+	LeadMappingSourceFullName         LeadMappingSource = "FULL_NAME"
+	LeadMappingSourceEmail            LeadMappingSource = "EMAIL"
+	LeadMappingSourceLeadNotes        LeadMappingSource = "LEAD_NOTES"
+	LeadMappingSourceLeadSource       LeadMappingSource = "LEAD_SOURCE"
+	LeadMappingSourceLeadPriority     LeadMappingSource = "LEAD_PRIORITY"
+	LeadMappingSourceOrganizationName LeadMappingSource = "ORGANIZATION_NAME"
+	LeadMappingSourceCampaignName     LeadMappingSource = "CAMPAIGN_NAME"
+)
+
+var AllLeadMappingSource = []LeadMappingSource{
+	LeadMappingSourceFullName,
+	LeadMappingSourceEmail,
+	LeadMappingSourceLeadNotes,
+	LeadMappingSourceLeadSource,
+	LeadMappingSourceLeadPriority,
+	LeadMappingSourceOrganizationName,
+	LeadMappingSourceCampaignName,
+}
+
+func (e LeadMappingSource) IsValid() bool {
+	switch e {
+	case LeadMappingSourceFullName, LeadMappingSourceEmail, LeadMappingSourceLeadNotes, LeadMappingSourceLeadSource, LeadMappingSourceLeadPriority, LeadMappingSourceOrganizationName, LeadMappingSourceCampaignName:
+		return true
+	}
+	return false
+}
+
+func (e LeadMappingSource) String() string {
+	return string(e)
+}
+
+func (e *LeadMappingSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LeadMappingSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LeadMappingSource", str)
+	}
+	return nil
+}
+
+func (e LeadMappingSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type LeadPriority string
 
 const (
@@ -626,6 +1418,219 @@ func (e LeadStage) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type LineItemUnit string
+
+const (
+	LineItemUnitHour  LineItemUnit = "HOUR"
+	LineItemUnitDay   LineItemUnit = "DAY"
+	LineItemUnitMonth LineItemUnit = "MONTH"
+)
+
+var AllLineItemUnit = []LineItemUnit{
+	LineItemUnitHour,
+	LineItemUnitDay,
+	LineItemUnitMonth,
+}
+
+func (e LineItemUnit) IsValid() bool {
+	switch e {
+	case LineItemUnitHour, LineItemUnitDay, LineItemUnitMonth:
+		return true
+	}
+	return false
+}
+
+func (e LineItemUnit) String() string {
+	return string(e)
+}
+
+func (e *LineItemUnit) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LineItemUnit(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LineItemUnit", str)
+	}
+	return nil
+}
+
+func (e LineItemUnit) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LockoutEventKind string
+
+const (
+	LockoutEventKindLocked   LockoutEventKind = "LOCKED"
+	LockoutEventKindUnlocked LockoutEventKind = "UNLOCKED"
+)
+
+var AllLockoutEventKind = []LockoutEventKind{
+	LockoutEventKindLocked,
+	LockoutEventKindUnlocked,
+}
+
+func (e LockoutEventKind) IsValid() bool {
+	switch e {
+	case LockoutEventKindLocked, LockoutEventKindUnlocked:
+		return true
+	}
+	return false
+}
+
+func (e LockoutEventKind) String() string {
+	return string(e)
+}
+
+func (e *LockoutEventKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LockoutEventKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LockoutEventKind", str)
+	}
+	return nil
+}
+
+func (e LockoutEventKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LockoutScope string
+
+const (
+	LockoutScopeAccount LockoutScope = "ACCOUNT"
+	LockoutScopeIP      LockoutScope = "IP"
+)
+
+var AllLockoutScope = []LockoutScope{
+	LockoutScopeAccount,
+	LockoutScopeIP,
+}
+
+func (e LockoutScope) IsValid() bool {
+	switch e {
+	case LockoutScopeAccount, LockoutScopeIP:
+		return true
+	}
+	return false
+}
+
+func (e LockoutScope) String() string {
+	return string(e)
+}
+
+func (e *LockoutScope) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LockoutScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LockoutScope", str)
+	}
+	return nil
+}
+
+func (e LockoutScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrganizationLeadPolicy string
+
+const (
+	OrganizationLeadPolicyReject   OrganizationLeadPolicy = "REJECT"
+	OrganizationLeadPolicyDetach   OrganizationLeadPolicy = "DETACH"
+	OrganizationLeadPolicyReassign OrganizationLeadPolicy = "REASSIGN"
+)
+
+var AllOrganizationLeadPolicy = []OrganizationLeadPolicy{
+	OrganizationLeadPolicyReject,
+	OrganizationLeadPolicyDetach,
+	OrganizationLeadPolicyReassign,
+}
+
+func (e OrganizationLeadPolicy) IsValid() bool {
+	switch e {
+	case OrganizationLeadPolicyReject, OrganizationLeadPolicyDetach, OrganizationLeadPolicyReassign:
+		return true
+	}
+	return false
+}
+
+func (e OrganizationLeadPolicy) String() string {
+	return string(e)
+}
+
+func (e *OrganizationLeadPolicy) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrganizationLeadPolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrganizationLeadPolicy", str)
+	}
+	return nil
+}
+
+func (e OrganizationLeadPolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrganizationSortField string
+
+const (
+	OrganizationSortFieldCreatedAt        OrganizationSortField = "createdAt"
+	OrganizationSortFieldUpdatedAt        OrganizationSortField = "updatedAt"
+	OrganizationSortFieldOrganizationName OrganizationSortField = "organizationName"
+	OrganizationSortFieldCountry          OrganizationSortField = "country"
+)
+
+var AllOrganizationSortField = []OrganizationSortField{
+	OrganizationSortFieldCreatedAt,
+	OrganizationSortFieldUpdatedAt,
+	OrganizationSortFieldOrganizationName,
+	OrganizationSortFieldCountry,
+}
+
+func (e OrganizationSortField) IsValid() bool {
+	switch e {
+	case OrganizationSortFieldCreatedAt, OrganizationSortFieldUpdatedAt, OrganizationSortFieldOrganizationName, OrganizationSortFieldCountry:
+		return true
+	}
+	return false
+}
+
+func (e OrganizationSortField) String() string {
+	return string(e)
+}
+
+func (e *OrganizationSortField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrganizationSortField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrganizationSortField", str)
+	}
+	return nil
+}
+
+func (e OrganizationSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type PaymentTerms string
 
 const (
@@ -669,6 +1674,51 @@ func (e PaymentTerms) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type QuoteStatus string
+
+const (
+	QuoteStatusDraft    QuoteStatus = "DRAFT"
+	QuoteStatusSent     QuoteStatus = "SENT"
+	QuoteStatusAccepted QuoteStatus = "ACCEPTED"
+	QuoteStatusRejected QuoteStatus = "REJECTED"
+)
+
+var AllQuoteStatus = []QuoteStatus{
+	QuoteStatusDraft,
+	QuoteStatusSent,
+	QuoteStatusAccepted,
+	QuoteStatusRejected,
+}
+
+func (e QuoteStatus) IsValid() bool {
+	switch e {
+	case QuoteStatusDraft, QuoteStatusSent, QuoteStatusAccepted, QuoteStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e QuoteStatus) String() string {
+	return string(e)
+}
+
+func (e *QuoteStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = QuoteStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid QuoteStatus", str)
+	}
+	return nil
+}
+
+func (e QuoteStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type ResourceProfileSortField string
 
 const (
@@ -1029,17 +2079,19 @@ const (
 	DealStatusStarted   DealStatus = "STARTED"
 	DealStatusPending   DealStatus = "PENDING"
 	DealStatusCompleted DealStatus = "COMPLETED"
+	DealStatusCancelled DealStatus = "CANCELLED"
 )
 
 var AllDealStatus = []DealStatus{
 	DealStatusStarted,
 	DealStatusPending,
 	DealStatusCompleted,
+	DealStatusCancelled,
 }
 
 func (e DealStatus) IsValid() bool {
 	switch e {
-	case DealStatusStarted, DealStatusPending, DealStatusCompleted:
+	case DealStatusStarted, DealStatusPending, DealStatusCompleted, DealStatusCancelled:
 		return true
 	}
 	return false
@@ -14,6 +14,8 @@ import (
 	"github.com/Zenithive/it-crm-backend/auth"
 	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
 	"github.com/Zenithive/it-crm-backend/internal/graphql/schema"
+	"github.com/Zenithive/it-crm-backend/mailer"
+	"github.com/Zenithive/it-crm-backend/utils"
 	"github.com/go-chi/cors"
 	"github.com/vektah/gqlparser/v2/ast"
 )
@@ -24,7 +26,6 @@ func init() {
 	initializers.ConnectToDatabase()
 }
 
-// Must contain 6 characters, one uppercase, one lowercase, one number, and one special character
 func Handler() {
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},                      // Allow all origins
@@ -36,21 +37,37 @@ func Handler() {
 
 	// handler := c.Handler(http.DefaultServeMux)
 
+	// Fail on startup rather than on the first stage change when the pipeline config is broken
+	if _, err := utils.LoadLeadPipeline(); err != nil {
+		log.Fatalf("Failed to load lead pipeline: %v", err)
+	}
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure the mailer: %v", err)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = defaultPort
 	}
-	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: &schema.Resolver{}}))
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers: &schema.Resolver{Mailer: mail},
+		Directives: auth.Directives(),
+	}))
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
+	srv.AddTransport(batchPOST{})
 	srv.AddTransport(transport.POST{})
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
 	srv.Use(extension.Introspection{})
+	srv.Use(auth.PublicFields{})
+	srv.Use(auth.SecretGuard{})
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
 	http.Handle("/", auth.Middleware(playground.Handler("GraphQL playground", "/")))
 	http.Handle("/graphql", auth.Middleware(c.Handler(srv)))
+	http.Handle("/quotes/", auth.Middleware(c.Handler(auth.RequireUser(http.HandlerFunc(quoteDocuments)))))
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
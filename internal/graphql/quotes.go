@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Zenithive/it-crm-backend/utils"
+)
+
+// quoteDocuments serves the stored documents of a quote at /quotes/{id}/pdf and
+// /quotes/{id}/html to callers who can see its deal
+func quoteDocuments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, format, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/quotes/"), "/")
+	if !ok || (format != "pdf" && format != "html") {
+		http.NotFound(w, r)
+		return
+	}
+
+	q, err := utils.FindScopedQuote(r.Context(), id)
+	if err != nil {
+		if !errors.Is(err, utils.ErrQuoteNotFound) {
+			log.Printf("Error loading quote %s: %v", id, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	// Quotes are rendered once and stored, they can be cached privately
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+q.Number+`.pdf"`)
+		w.Write(q.PDF)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Templates are written by admins, keep scripts in them from running on this origin
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; img-src data: https:")
+	w.Write([]byte(q.HTML))
+}
@@ -0,0 +1,48 @@
+package scalars
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/shopspring/decimal"
+)
+
+// MarshalDecimal serializes a decimal as a string so no precision is lost
+func MarshalDecimal(d decimal.Decimal) graphql.Marshaler {
+	return graphql.MarshalString(d.String())
+}
+
+// UnmarshalDecimal accepts a decimal as a string or a number
+func UnmarshalDecimal(v interface{}) (decimal.Decimal, error) {
+	return decimalFromValue(v)
+}
+
+func decimalFromValue(v interface{}) (decimal.Decimal, error) {
+	switch value := v.(type) {
+	case string:
+		parsed, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil {
+			return decimal.Decimal{}, fmt.Errorf("invalid decimal %q", value)
+		}
+		return parsed, nil
+	case json.Number:
+		parsed, err := decimal.NewFromString(value.String())
+		if err != nil {
+			return decimal.Decimal{}, fmt.Errorf("invalid decimal %q", value)
+		}
+		return parsed, nil
+	case int:
+		return decimal.NewFromInt(int64(value)), nil
+	case int64:
+		return decimal.NewFromInt(value), nil
+	case float64:
+		return decimal.NewFromFloat(value), nil
+	case nil:
+		return decimal.Decimal{}, errors.New("value is required")
+	default:
+		return decimal.Decimal{}, fmt.Errorf("invalid decimal of type %T", v)
+	}
+}
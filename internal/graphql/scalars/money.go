@@ -0,0 +1,235 @@
+package scalars
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Money is a decimal amount in an ISO 4217 currency. It is serialized as
+// {"amount": "1200.5", "currency": "USD"} with the amount as a string, at the full precision
+// it is stored with, so no precision is lost.
+type Money struct {
+	Amount   decimal.Decimal
+	Currency string
+}
+
+const defaultCurrency = "USD"
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencySymbols maps the symbols found in legacy free text amounts to their currency
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"₹": "INR",
+	"¥": "JPY",
+}
+
+// DefaultCurrency is assumed for amounts entered without a currency, configured with DEFAULT_CURRENCY
+func DefaultCurrency() string {
+	if currency := strings.ToUpper(strings.TrimSpace(os.Getenv("DEFAULT_CURRENCY"))); currencyPattern.MatchString(currency) {
+		return currency
+	}
+	return defaultCurrency
+}
+
+// ValidateCurrency checks that the code looks like an ISO 4217 currency code
+func ValidateCurrency(currency string) error {
+	if !currencyPattern.MatchString(currency) {
+		return fmt.Errorf("invalid currency %q, expected an ISO 4217 code such as USD", currency)
+	}
+	return nil
+}
+
+func (m Money) MarshalGQL(w io.Writer) {
+	out, _ := json.Marshal(map[string]string{
+		"amount":   m.Amount.String(),
+		"currency": m.Currency,
+	})
+	w.Write(out)
+}
+
+// UnmarshalGQL accepts {"amount": "12.50", "currency": "EUR"}, where the amount may also be a
+// number and the currency defaults to DefaultCurrency, or a string such as "12.50 EUR".
+func (m *Money) UnmarshalGQL(v interface{}) error {
+	var parsed Money
+	var err error
+	switch value := v.(type) {
+	case map[string]interface{}:
+		parsed, err = moneyFromObject(value)
+	case string:
+		parsed, err = ParseMoney(value)
+	default:
+		err = fmt.Errorf("Money must be an object with amount and currency, got %T", v)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func moneyFromObject(value map[string]interface{}) (Money, error) {
+	if value["amount"] == nil {
+		return Money{}, errors.New("amount is required")
+	}
+	amount, err := decimalFromValue(value["amount"])
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount: %w", err)
+	}
+	money := Money{Amount: amount}
+
+	money.Currency = DefaultCurrency()
+	if currency, ok := value["currency"].(string); ok && currency != "" {
+		money.Currency = strings.ToUpper(strings.TrimSpace(currency))
+	}
+	if err := ValidateCurrency(money.Currency); err != nil {
+		return Money{}, err
+	}
+	return money, nil
+}
+
+// ParseMoney parses a free text amount such as "1200", "$1,200.50", "1.200,50 EUR" or
+// "INR 1,00,000". Amounts without a currency get DefaultCurrency. See normalizeAmount for how
+// decimal and thousands separators are told apart.
+func ParseMoney(text string) (Money, error) {
+	original := text
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Money{}, errors.New("amount is empty")
+	}
+
+	currency := ""
+	for symbol, code := range currencySymbols {
+		if strings.Contains(text, symbol) {
+			if currency != "" {
+				return Money{}, fmt.Errorf("conflicting currencies in %q", original)
+			}
+			currency = code
+			text = strings.ReplaceAll(text, symbol, "")
+		}
+	}
+	fields := strings.Fields(text)
+	var amountParts []string
+	for _, field := range fields {
+		if upper := strings.ToUpper(field); currencyPattern.MatchString(upper) {
+			if currency != "" && currency != upper {
+				return Money{}, fmt.Errorf("conflicting currencies in %q", original)
+			}
+			currency = upper
+			continue
+		}
+		amountParts = append(amountParts, field)
+	}
+	if len(amountParts) != 1 {
+		return Money{}, fmt.Errorf("cannot parse amount %q", original)
+	}
+
+	amount, err := normalizeAmount(amountParts[0])
+	if err != nil {
+		return Money{}, fmt.Errorf("cannot parse amount %q: %w", original, err)
+	}
+	// Reject things like "1e9" or "0x10" that the decimal parser would accept
+	if _, err := strconv.ParseFloat(amount, 64); err != nil || strings.ContainsAny(amount, "eExX") {
+		return Money{}, fmt.Errorf("cannot parse amount %q", original)
+	}
+	parsed, err := decimal.NewFromString(amount)
+	if err != nil {
+		return Money{}, fmt.Errorf("cannot parse amount %q", original)
+	}
+
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+	return Money{Amount: parsed, Currency: currency}, nil
+}
+
+// normalizeAmount rewrites an amount with "," or "." as decimal or thousands separator to the
+// plain form the decimal parser reads. When both occur the last one is the decimal separator
+// ("1,200.50", "1.200,50"), a separator that occurs more than once groups thousands
+// ("1.200.000"). A single separator is a decimal separator unless three digits follow it: a
+// comma is then read as thousands separator like in "$1,200", while "1.200" means 1.2 in
+// English and 1200 in most of Europe and is rejected as ambiguous.
+func normalizeAmount(amount string) (string, error) {
+	sign := ""
+	if strings.HasPrefix(amount, "-") {
+		sign, amount = "-", amount[1:]
+	}
+	lastComma, lastDot := strings.LastIndex(amount, ","), strings.LastIndex(amount, ".")
+
+	if lastComma >= 0 && lastDot >= 0 {
+		decimalSep, groupSep := ".", ","
+		if lastComma > lastDot {
+			decimalSep, groupSep = ",", "."
+		}
+		split := strings.LastIndex(amount, decimalSep)
+		integer, fraction := amount[:split], amount[split+1:]
+		if strings.Count(amount, decimalSep) > 1 || !groupedDigits(integer, groupSep) {
+			return "", errors.New("misplaced separators")
+		}
+		return sign + strings.ReplaceAll(integer, groupSep, "") + "." + fraction, nil
+	}
+
+	sep := ","
+	if lastDot >= 0 {
+		sep = "."
+	}
+	switch strings.Count(amount, sep) {
+	case 0:
+		return sign + amount, nil
+	case 1:
+		integer, fraction, _ := strings.Cut(amount, sep)
+		if len(fraction) == 3 && groupedDigits(amount, sep) {
+			if sep == "." {
+				return "", errors.New("ambiguous separator, write the amount without thousands separators")
+			}
+			return sign + integer + fraction, nil
+		}
+		return sign + integer + "." + fraction, nil
+	default:
+		if !groupedDigits(amount, sep) {
+			return "", errors.New("misplaced separators")
+		}
+		return sign + strings.ReplaceAll(amount, sep, ""), nil
+	}
+}
+
+// groupedDigits reports whether the digits are grouped in thousands by sep ("1,200,000"),
+// or with a comma in the Indian lakh and crore layout ("1,00,00,000")
+func groupedDigits(digits, sep string) bool {
+	groups := strings.Split(digits, sep)
+	if len(groups) < 2 || !isDigits(groups[0]) || groups[0][0] == '0' {
+		return false
+	}
+	last := groups[len(groups)-1]
+	if len(groups[0]) > 3 || len(last) != 3 || !isDigits(last) {
+		return false
+	}
+	thousands, indian := true, sep == "," && len(groups[0]) <= 2
+	for _, group := range groups[1 : len(groups)-1] {
+		thousands = thousands && len(group) == 3 && isDigits(group)
+		indian = indian && len(group) == 2 && isDigits(group)
+	}
+	return thousands || indian
+}
+
+func isDigits(text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
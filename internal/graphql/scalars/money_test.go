@@ -0,0 +1,103 @@
+package scalars
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseMoney(t *testing.T) {
+	t.Setenv("DEFAULT_CURRENCY", "USD")
+	tests := []struct {
+		text     string
+		amount   string
+		currency string
+	}{
+		{"1200", "1200", "USD"},
+		{"-75.5", "-75.5", "USD"},
+		{"$1,200.50", "1200.5", "USD"},
+		{"$1,200", "1200", "USD"},
+		{"1,200,000 GBP", "1200000", "GBP"},
+		{"1.200,50 EUR", "1200.5", "EUR"},
+		{"€1.200.000", "1200000", "EUR"},
+		{"1.200.000,75 €", "1200000.75", "EUR"},
+		{"12,50 EUR", "12.5", "EUR"},
+		{"0,125 EUR", "0.125", "EUR"},
+		{"0.125", "0.125", "USD"},
+		{"1.5", "1.5", "USD"},
+		{"1234.5678", "1234.5678", "USD"},
+		{"INR 50000", "50000", "INR"},
+		{"₹1,00,000", "100000", "INR"},
+		{"₹1,00,00,000.50", "10000000.5", "INR"},
+		{"eur 99", "99", "EUR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			money, err := ParseMoney(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if money.Amount.String() != tt.amount || money.Currency != tt.currency {
+				t.Fatalf("got %s %s, want %s %s", money.Amount, money.Currency, tt.amount, tt.currency)
+			}
+		})
+	}
+}
+
+func TestParseMoneyRejects(t *testing.T) {
+	tests := []struct {
+		text    string
+		problem string
+	}{
+		{"", "empty"},
+		{"1.200 EUR", "ambiguous"},
+		{"€12.000", "ambiguous"},
+		{"1,20,0", "misplaced"},
+		{"12,00.000", "misplaced"},
+		{"1.200.50", "misplaced"},
+		{"1,200.000.50", "misplaced"},
+		{"1,2,3", "misplaced"},
+		{"12 34", "cannot parse"},
+		{"1e9", "cannot parse"},
+		{"0x10", "cannot parse"},
+		{"ten", "cannot parse"},
+		{"$10 EUR", "conflicting currencies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			money, err := ParseMoney(tt.text)
+			if err == nil {
+				t.Fatalf("parsed as %s %s", money.Amount, money.Currency)
+			}
+			if !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("error %q does not mention %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestMoneyMarshalKeepsPrecision(t *testing.T) {
+	tests := map[string]string{
+		"1200.5":    `{"amount":"1200.5","currency":"EUR"}`,
+		"0.0125":    `{"amount":"0.0125","currency":"EUR"}`,
+		"1234.5678": `{"amount":"1234.5678","currency":"EUR"}`,
+		"-3":        `{"amount":"-3","currency":"EUR"}`,
+	}
+	for amount, want := range tests {
+		money := Money{Amount: decimal.RequireFromString(amount), Currency: "EUR"}
+		var out strings.Builder
+		money.MarshalGQL(&out)
+		if out.String() != want {
+			t.Errorf("got %s, want %s", out.String(), want)
+		}
+
+		var parsed Money
+		if err := parsed.UnmarshalGQL(map[string]interface{}{"amount": amount, "currency": "EUR"}); err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Amount.Equal(money.Amount) {
+			t.Errorf("%s did not survive a round trip, got %s", amount, parsed.Amount)
+		}
+	}
+}
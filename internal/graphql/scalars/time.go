@@ -0,0 +1,77 @@
+package scalars
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalDate serializes a calendar date as YYYY-MM-DD
+func MarshalDate(t time.Time) graphql.Marshaler {
+	return graphql.MarshalString(t.Format(time.DateOnly))
+}
+
+// UnmarshalDate parses a YYYY-MM-DD date
+func UnmarshalDate(v interface{}) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Date must be a string, got %T", v)
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid Date %q, expected YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// MarshalDateTime serializes a point in time as an RFC 3339 timestamp in UTC
+func MarshalDateTime(t time.Time) graphql.Marshaler {
+	return graphql.MarshalString(t.UTC().Format(time.RFC3339))
+}
+
+// UnmarshalDateTime parses an RFC 3339 timestamp
+func UnmarshalDateTime(v interface{}) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("DateTime must be a string, got %T", v)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid DateTime %q, expected an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// legacyTimeLayouts are the formats found in the free text date columns. The last one is
+// what time.Time.String() produces, which older code stored verbatim.
+var legacyTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.DateOnly,
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+}
+
+// ParseLegacyTime parses the free text dates stored before date columns were typed
+func ParseLegacyTime(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, errors.New("date is empty")
+	}
+	// time.Time.String() appends the monotonic clock reading, e.g. " m=+0.001"
+	if i := strings.Index(text, " m="); i >= 0 {
+		text = text[:i]
+	}
+	for _, layout := range legacyTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse date %q", text)
+}
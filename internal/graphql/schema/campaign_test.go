@@ -0,0 +1,130 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+// campaignWithMember makes the scripted database know campaign 2 with user 7 as its OWNER
+func campaignWithMember(db *testdb.DB) {
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "campaigns"`},
+		Columns:  []string{"id", "campaign_name", "campaign_country", "status", "actual_spend", "currency"},
+		Rows:     [][]driver.Value{{int64(2), "Spring", "DE", "ACTIVE", "0", "EUR"}},
+	})
+	db.On(testdb.Rule{Contains: []string{"count(*)", `FROM "campaigns"`}, Columns: []string{"count"}, Rows: [][]driver.Value{{int64(1)}}})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "campaign_users"`},
+		Columns:  []string{"campaign_id", "user_id", "role", "joined_at"},
+		Rows:     [][]driver.Value{{int64(2), int64(7), "OWNER", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "users" WHERE "users"."id"`},
+		Columns:  []string{"id", "name", "email", "role"},
+		Rows:     [][]driver.Value{{int64(7), "Owner", "owner@example.com", "SALES_EXECUTIVE"}},
+	})
+}
+
+func TestGetCampaignsReturnsMembers(t *testing.T) {
+	srv, db := newServer(t)
+	headers := bearer(t, db, &models.User{Model: gormModel(4), Role: string(generated.UserRoleAdmin)})
+	campaignWithMember(db)
+
+	resp := execute(t, srv, headers, `{ getCampaigns { items { campaignID users { userID } members { role user { userID } } } } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("getCampaigns failed: %s", resp.raw)
+	}
+	want := `{"items":[{"campaignID":"2","users":[{"userID":"7"}],"members":[{"role":"OWNER","user":{"userID":"7"}}]}]}`
+	if got := string(resp.Data["getCampaigns"]); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+// campaignScope is the condition CampaignScope adds for users who do not see every campaign
+const campaignScope = "campaign_users.user_id = $"
+
+func TestGetCampaignScope(t *testing.T) {
+	tests := []struct {
+		name string
+		user *models.User
+		// inScope is whether the database finds the campaign under the scope condition
+		inScope bool
+		scoped  bool
+		visible bool
+	}{
+		{"admin", &models.User{Model: gormModel(4), Role: string(generated.UserRoleAdmin)}, false, false, true},
+		{"member or lead owner", &models.User{Model: gormModel(7), Role: string(generated.UserRoleSalesExecutive)}, true, true, true},
+		{"outsider", &models.User{Model: gormModel(8), Role: string(generated.UserRoleSalesExecutive)}, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			headers := bearer(t, db, tt.user)
+			campaignWithMember(db)
+			if !tt.inScope {
+				db.On(testdb.Rule{Contains: []string{`FROM "campaigns"`, campaignScope}, Columns: []string{"id"}})
+			}
+
+			resp := execute(t, srv, headers, `{ getCampaign(campaignID: "2") { campaignID } }`, nil)
+			if tt.visible {
+				if string(resp.Data["getCampaign"]) != `{"campaignID":"2"}` {
+					t.Fatalf("the campaign is hidden: %s", resp.raw)
+				}
+			} else if len(resp.Errors) == 0 || resp.Errors[0].Message != "campaign not found" {
+				t.Fatalf("the campaign is visible: %s", resp.raw)
+			}
+
+			scoped := db.Statements(`FROM "campaigns"`, campaignScope)
+			if (len(scoped) > 0) != tt.scoped {
+				t.Fatalf("scoped %v, want %v: %v", len(scoped) > 0, tt.scoped, db.Statements(`FROM "campaigns"`))
+			}
+			if tt.scoped && !slices.Contains(scoped[0].Args, driver.Value(fmt.Sprintf("%d", tt.user.ID))) {
+				t.Fatalf("the campaigns are not scoped to user %d: %v", tt.user.ID, scoped[0])
+			}
+		})
+	}
+}
+
+func TestGetCampaignsScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   generated.UserRole
+		scoped bool
+	}{
+		{"admin", generated.UserRoleAdmin, false},
+		{"manager", generated.UserRoleManager, false},
+		{"sales executive", generated.UserRoleSalesExecutive, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			headers := bearer(t, db, &models.User{Model: gormModel(8), Role: string(tt.role)})
+			campaignWithMember(db)
+
+			resp := execute(t, srv, headers, `{ getCampaigns { totalCount items { campaignID } } }`, nil)
+			if len(resp.Errors) > 0 {
+				t.Fatalf("getCampaigns failed: %s", resp.raw)
+			}
+
+			// Both the page and its total count only cover the visible campaigns
+			for _, fragment := range []string{"count(*)", "SELECT *"} {
+				statements := db.Statements(`FROM "campaigns"`, fragment)
+				if len(statements) == 0 {
+					t.Fatalf("no %s statement ran", fragment)
+				}
+				scoped := strings.Contains(statements[0].SQL, campaignScope)
+				if scoped != tt.scoped {
+					t.Fatalf("%s scoped %v, want %v: %s", fragment, scoped, tt.scoped, statements[0].SQL)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+const viewerError = "campaign viewers cannot change the deals of its leads"
+
+// dealMutations change a deal, its line items or its quotes
+var dealMutations = []struct {
+	name  string
+	query string
+}{
+	{"updateDeal", `mutation { updateDeal(dealID: "3", input: {dealName: "Renamed"}) { dealID } }`},
+	{"closeDeal", `mutation { closeDeal(dealID: "3", status: COMPLETED) { dealID } }`},
+	{"generateQuote", `mutation { generateQuote(dealID: "3") { id } }`},
+	{"updateQuoteStatus", `mutation { updateQuoteStatus(id: "5d0c7a3e-2f4b-4c1d-9e8a-6b7c8d9e0f12", status: SENT) { id } }`},
+	{"addDealLineItem", `mutation { addDealLineItem(dealID: "3", input: {role: "Developer", rate: "80 EUR", unit: HOUR, quantity: "10"}) { id } }`},
+	{"updateDealLineItem", `mutation { updateDealLineItem(id: "8a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", input: {role: "Developer", rate: "80 EUR", unit: HOUR, quantity: "10"}) { id } }`},
+	{"removeDealLineItem", `mutation { removeDealLineItem(id: "8a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d") { id } }`},
+}
+
+// visibleDeal makes the scripted database know a deal of a lead the caller sees, with a line
+// item and a quote. editable tells whether the caller may change the lead.
+func visibleDeal(db *testdb.DB, editable bool) {
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "deals"`},
+		Columns:  []string{"id", "lead_id", "deal_name", "deal_amount", "deal_currency", "deal_status"},
+		Rows:     [][]driver.Value{{int64(3), "lead-1", "Deal", "1000", "EUR", string(models.DealStatusStarted)}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "deal_line_items"`},
+		Columns:  []string{"id", "deal_id", "role"},
+		Rows:     [][]driver.Value{{"8a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", int64(3), "Developer"}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "quotes"`},
+		Columns:  []string{"id", "deal_id", "status"},
+		Rows:     [][]driver.Value{{"5d0c7a3e-2f4b-4c1d-9e8a-6b7c8d9e0f12", int64(3), "DRAFT"}},
+	})
+	count := int64(0)
+	if editable {
+		count = 1
+	}
+	db.On(testdb.Rule{Contains: []string{"count(*)", `FROM "leads"`}, Columns: []string{"count"}, Rows: [][]driver.Value{{count}}})
+}
+
+func TestCampaignViewerCannotChangeDeals(t *testing.T) {
+	for _, tt := range dealMutations {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			headers := bearer(t, db, &models.User{Model: gormModel(7), Role: string(generated.UserRoleSalesExecutive)})
+			visibleDeal(db, false)
+
+			resp := execute(t, srv, headers, tt.query, nil)
+			if len(resp.Errors) == 0 || resp.Errors[0].Message != viewerError {
+				t.Fatalf("expected %q, got %s", viewerError, resp.raw)
+			}
+			for _, write := range []string{"INSERT", "UPDATE", "DELETE"} {
+				if statements := db.Statements(write); len(statements) > 0 {
+					t.Fatalf("a viewer wrote to the database: %s", statements[0].SQL)
+				}
+			}
+		})
+	}
+}
+
+func TestCampaignMemberCanChangeDeals(t *testing.T) {
+	for _, tt := range dealMutations {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			headers := bearer(t, db, &models.User{Model: gormModel(7), Role: string(generated.UserRoleSalesExecutive)})
+			visibleDeal(db, true)
+
+			resp := execute(t, srv, headers, tt.query, nil)
+			if strings.Contains(string(resp.raw), viewerError) {
+				t.Fatalf("a member was treated as viewer: %s", resp.raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/google/uuid"
+)
+
+func TestGetAllLeadsReturnsFullLeads(t *testing.T) {
+	srv, db := newServer(t)
+	headers := bearer(t, db, &models.User{Model: gormModel(4), Role: string(generated.UserRoleAdmin)})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "leads"`},
+		Columns:  []string{"lead_id", "first_name", "last_name", "email", "lead_stage", "lead_created_by", "campaign_id"},
+		Rows:     [][]driver.Value{{"lead-1", "Jane", "Doe", "jane@example.com", "NEW", "4", "2"}},
+	})
+	db.On(testdb.Rule{Contains: []string{"count(*)", `FROM "leads"`}, Columns: []string{"count"}, Rows: [][]driver.Value{{int64(1)}}})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "deals"`},
+		Columns:  []string{"id", "lead_id", "deal_name", "deal_amount", "deal_currency", "deal_status"},
+		Rows:     [][]driver.Value{{int64(3), "lead-1", "Deal", "1000", "EUR", string(models.DealStatusStarted)}},
+	})
+
+	resp := execute(t, srv, headers, `{ getAllLeads { totalCount items { leadID lastName leadStage deals { dealID } activities { activity_id } } } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("getAllLeads failed: %s", resp.raw)
+	}
+	want := `{"totalCount":1,"items":[{"leadID":"lead-1","lastName":"Doe","leadStage":"NEW","deals":[{"dealID":"3"}],"activities":[]}]}`
+	if got := string(resp.Data["getAllLeads"]); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestGetOneLeadReturnsFullLead(t *testing.T) {
+	srv, db := newServer(t)
+	headers := bearer(t, db, &models.User{Model: gormModel(4), Role: string(generated.UserRoleAdmin)})
+	ruleID := uuid.New()
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "leads"`},
+		Columns:  []string{"lead_id", "first_name", "last_name", "email", "lead_stage", "lead_created_by", "lead_assigned_to", "assignment_rule_id", "campaign_id"},
+		Rows:     [][]driver.Value{{"lead-1", "Jane", "Doe", "jane@example.com", "NEW", "4", "4", ruleID.String(), ""}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "assignment_rules"`},
+		Columns:  []string{"id", "name", "type", "enabled", "countries", "regions", "user_ids"},
+		Rows:     [][]driver.Value{{ruleID.String(), "DACH", "TERRITORY", true, `["DE"]`, `[]`, `[4]`}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "activities"`},
+		Columns:  []string{"activity_id", "lead_id", "activity_type", "follow_up_actions"},
+		Rows:     [][]driver.Value{{"activity-1", "lead-1", "CALL", "Send the quote"}},
+	})
+
+	resp := execute(t, srv, headers, `{ getOneLead(lead_id: "lead-1") {
+		leadID email leadCreatedBy { userID } leadAssignedTo { userID } assignmentRule { name }
+		campaign { campaignID } activities { activity_id followUpActions }
+	} }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("getOneLead failed: %s", resp.raw)
+	}
+	want := `{"leadID":"lead-1","email":"jane@example.com","leadCreatedBy":{"userID":"4"},"leadAssignedTo":{"userID":"4"},"assignmentRule":{"name":"DACH"},` +
+		`"campaign":{"campaignID":""},"activities":[{"activity_id":"activity-1","followUpActions":"Send the quote"}]}`
+	if got := string(resp.Data["getOneLead"]); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	loginMutation     = `mutation($email: String!, $password: String!) { login(email: $email, password: $password) { __typename } }`
+	verifyMfaMutation = `mutation($challenge: String!, $code: String!) { verifyMfa(challengeToken: $challenge, code: $code) { token } }`
+)
+
+// loginUser makes the scripted database know the user with the password
+func loginUser(t *testing.T, db *testdb.DB, password string, totpEnabled bool) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Model: gormModel(9), Email: "mfa@example.com", Role: "SALES_EXECUTIVE", Password: string(hash), TotpEnabled: totpEnabled}
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "users"`},
+		Columns:  []string{"id", "name", "email", "role", "password", "totp_enabled"},
+		Rows:     [][]driver.Value{{int64(user.ID), "MFA", user.Email, user.Role, user.Password, totpEnabled}},
+	})
+	return user
+}
+
+// accountThrottleResets returns how often the failure count of the account was reset
+func accountThrottleResets(db *testdb.DB) int {
+	return len(db.Statements(`DELETE FROM "login_throttles"`))
+}
+
+func TestLoginWithoutMFAResetsAccountThrottle(t *testing.T) {
+	srv, db := newServer(t)
+	loginUser(t, db, "secret-password", false)
+
+	resp := execute(t, srv, nil, loginMutation, map[string]interface{}{"email": "mfa@example.com", "password": "secret-password"})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("login failed: %s", resp.raw)
+	}
+	if accountThrottleResets(db) != 1 {
+		t.Fatal("a successful login did not reset the account throttle")
+	}
+}
+
+func TestLoginIgnoresEmailCase(t *testing.T) {
+	srv, db := newServer(t)
+	loginUser(t, db, "secret-password", false)
+
+	resp := execute(t, srv, nil, loginMutation, map[string]interface{}{"email": "MFA@Example.com", "password": "secret-password"})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("login failed: %s", resp.raw)
+	}
+	// Matches the throttle key, the password reset and Google sign-in lookups
+	if len(db.Statements(`FROM "users" WHERE LOWER(email) = LOWER(`)) != 1 {
+		t.Fatalf("the user was not looked up ignoring case: %v", db.Statements(`FROM "users"`))
+	}
+}
+
+func TestLoginWithMFAKeepsAccountThrottle(t *testing.T) {
+	srv, db := newServer(t)
+	loginUser(t, db, "secret-password", true)
+
+	resp := execute(t, srv, nil, loginMutation, map[string]interface{}{"email": "mfa@example.com", "password": "secret-password"})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("login failed: %s", resp.raw)
+	}
+	if string(resp.Data["login"]) != `{"__typename":"MfaChallenge"}` {
+		t.Fatalf("expected an MFA challenge, got %s", resp.raw)
+	}
+	if accountThrottleResets(db) != 0 {
+		t.Fatal("the password alone reset the account throttle that protects the MFA code")
+	}
+}
+
+func TestVerifyMfa(t *testing.T) {
+	tests := []struct {
+		name     string
+		accepted bool
+	}{
+		{"valid code", true},
+		{"wrong code", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			user := loginUser(t, db, "secret-password", true)
+			challenge, _, err := auth.NewMFAChallenge(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Recovery codes are consumed by a conditional update, only a valid one affects a row
+			affected := int64(0)
+			if tt.accepted {
+				affected = 1
+			}
+			db.On(testdb.Rule{Contains: []string{`UPDATE "recovery_codes"`}, RowsAffected: affected})
+
+			resp := execute(t, srv, nil, verifyMfaMutation, map[string]interface{}{"challenge": challenge, "code": "abcde-fghjk"})
+			if accepted := len(resp.Errors) == 0; accepted != tt.accepted {
+				t.Fatalf("accepted = %v, want %v: %s", accepted, tt.accepted, resp.raw)
+			}
+			resets := accountThrottleResets(db)
+			failures := len(db.Statements(`INSERT INTO "login_throttles"`))
+			if tt.accepted && (resets != 1 || failures != 0) {
+				t.Fatalf("a valid code reset the account throttle %d times and counted %d failures", resets, failures)
+			}
+			if !tt.accepted && (resets != 0 || failures == 0) {
+				t.Fatalf("a wrong code reset the account throttle %d times and counted %d failures", resets, failures)
+			}
+		})
+	}
+}
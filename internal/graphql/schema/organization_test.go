@@ -0,0 +1,57 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+const renameOrganization = `mutation { updateOrganization(id: "3", input: { organizationName: "Renamed" }) { ID organizationName } }`
+
+func TestUpdateOrganizationRequiresManager(t *testing.T) {
+	tests := []struct {
+		role    generated.UserRole
+		allowed bool
+	}{
+		{generated.UserRoleAdmin, true},
+		{generated.UserRoleManager, true},
+		{generated.UserRoleSalesExecutive, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			srv, db := newServer(t)
+			headers := bearer(t, db, &models.User{Model: gormModel(8), Role: string(tt.role)})
+			db.On(testdb.Rule{
+				Contains: []string{`FROM "organizations"`},
+				Columns:  []string{"id", "organization_name"},
+				Rows:     [][]driver.Value{{int64(3), "Acme"}},
+			})
+
+			resp := execute(t, srv, headers, renameOrganization, nil)
+			if !tt.allowed {
+				if code := errorCode(resp); code != auth.ErrCodeForbidden {
+					t.Fatalf("expected %s, got %s", auth.ErrCodeForbidden, resp.raw)
+				}
+				if updates := db.Statements(`UPDATE "organizations"`); len(updates) > 0 {
+					t.Fatalf("the organization was changed: %v", updates)
+				}
+				return
+			}
+			if len(resp.Errors) > 0 {
+				t.Fatalf("updateOrganization failed: %s", resp.raw)
+			}
+		})
+	}
+}
+
+func TestUpdateOrganizationClosedToAPIKeys(t *testing.T) {
+	srv, db := newServer(t)
+	resp := execute(t, srv, apiKey(db, "updateOrganization"), renameOrganization, nil)
+	if len(resp.Errors) == 0 {
+		t.Fatalf("an API key changed an organization: %s", resp.raw)
+	}
+}
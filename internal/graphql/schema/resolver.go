@@ -4,4 +4,8 @@ package schema
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-type Resolver struct{}
+import "github.com/Zenithive/it-crm-backend/mailer"
+
+type Resolver struct {
+	Mailer mailer.Mailer
+}
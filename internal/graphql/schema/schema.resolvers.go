@@ -9,38 +9,98 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	initializers "github.com/Zenithive/it-crm-backend/Initializers"
 	"github.com/Zenithive/it-crm-backend/auth"
 	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/mailer"
 	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/Zenithive/it-crm-backend/quote"
 	"github.com/Zenithive/it-crm-backend/utils"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Metrics is the resolver for the metrics field.
+func (r *campaignResolver) Metrics(ctx context.Context, obj *generated.Campaign) (*generated.CampaignMetrics, error) {
+	metrics, err := utils.CampaignMetrics(ctx, obj.CampaignID)
+	if err != nil {
+		log.Printf("Error computing metrics of campaign %s: %v", obj.CampaignID, err)
+		return nil, fmt.Errorf("internal error: failed to compute campaign metrics")
+	}
+	return metrics, nil
+}
+
+// LineItems is the resolver for the lineItems field.
+func (r *dealResolver) LineItems(ctx context.Context, obj *generated.Deal) ([]*generated.DealLineItem, error) {
+	var items []models.DealLineItem
+	if err := initializers.DB.Preload("ResourceProfile.Skills").Preload("Skills").
+		Where("deal_id = ?", obj.DealID).Order("created_at").Find(&items).Error; err != nil {
+		log.Printf("Error fetching deal line items: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch deal line items")
+	}
+	result := make([]*generated.DealLineItem, 0, len(items))
+	for i := range items {
+		result = append(result, utils.ConvertDealLineItem(&items[i], obj.DealAmount.Currency))
+	}
+	return result, nil
+}
+
 // Login is the resolver for the login field.
-func (r *mutationResolver) Login(ctx context.Context, email string, password string) (*generated.AuthPayload, error) {
+func (r *mutationResolver) Login(ctx context.Context, email string, password string) (generated.LoginResult, error) {
+	ip := auth.GetClientIP(ctx)
+	if err := auth.CheckLoginAllowed(email, ip); err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	if err := initializers.DB.Where("email = ?", email).First(&user).Error; err != nil {
-		return nil, errors.New("user not found")
+	if err := initializers.DB.Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		auth.SimulatePasswordCheck(password)
+		auth.RecordLoginFailure(email, ip, nil)
+		return nil, auth.ErrInvalidCredentials
 	}
 	// Validate password
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return nil, errors.New("invalid password")
+		auth.RecordLoginFailure(email, ip, &user.ID)
+		return nil, auth.ErrInvalidCredentials
+	}
+	// With MFA enabled the password only earns a challenge, the session starts in verifyMfa.
+	// The account throttle is only reset there, otherwise logging in again between wrong
+	// codes would reset the lockout that protects the code.
+	if user.TotpEnabled {
+		challengeToken, expiresAt, err := auth.NewMFAChallenge(&user)
+		if err != nil {
+			log.Printf("Error creating MFA challenge: %v", err)
+			return nil, errors.New("failed to generate token")
+		}
+		return &generated.MfaChallenge{
+			ChallengeToken: challengeToken,
+			ExpiresAt:      expiresAt.Format(time.RFC3339),
+		}, nil
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateJWT(&user)
+	auth.RecordLoginSuccess(email)
+
+	// Start a session and issue the token pair
+	token, refreshToken, err := auth.NewSession(&user)
 	if err != nil {
+		log.Printf("Error creating session: %v", err)
 		return nil, errors.New("failed to generate token")
 	}
 
 	return &generated.AuthPayload{
-		Token: token,
+		Token:        token,
+		RefreshToken: refreshToken,
 		User: &generated.User{
 			UserID:   fmt.Sprintf("%d", user.ID),
 			GoogleID: &user.GoogleId,
@@ -48,356 +108,1277 @@ func (r *mutationResolver) Login(ctx context.Context, email string, password str
 			Email:    user.Email,
 			Phone:    user.Phone,
 			Role:     user.Role,
-			Password: user.Password,
 		},
 	}, nil
 }
 
-// CreateUser is the resolver for the createUser field.
-func (r *mutationResolver) CreateUser(ctx context.Context, input generated.CreateUserInput) (*generated.User, error) {
-	if initializers.DB == nil {
-		return nil, fmt.Errorf("database connection is nil")
-	}
-
-	role, err := auth.GetUserRoleFromJWT(ctx)
-	fmt.Println("Role: ", role)
+// LoginWithGoogle is the resolver for the loginWithGoogle field.
+func (r *mutationResolver) LoginWithGoogle(ctx context.Context, idToken string) (generated.LoginResult, error) {
+	identity, err := auth.VerifyGoogleIDToken(ctx, idToken)
 	if err != nil {
-		return nil, fmt.Errorf("unauthorized")
-	}
-	if role != "ADMIN" && role != "MANAGER" {
-		return nil, fmt.Errorf("unauthorized to create user")
+		if !errors.Is(err, auth.ErrInvalidGoogleToken) {
+			log.Printf("Error verifying Google ID token: %v", err)
+		}
+		return nil, auth.ErrInvalidGoogleToken
 	}
 
-	if input.Name == "" {
-		return nil, fmt.Errorf("name is required")
-	}
-	if input.Email == "" {
-		return nil, fmt.Errorf("email is required")
-	}
-	if input.Password == "" {
-		return nil, fmt.Errorf("password is required")
-	}
-	if input.Role == "" {
-		return nil, fmt.Errorf("role is required")
+	// Prefer an account already linked to the Google subject, otherwise link by verified email
+	var user models.User
+	err = initializers.DB.Where("google_id = ?", identity.Subject).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if identity.Email == "" || !identity.EmailVerified {
+			return nil, errors.New("Google account email is not verified")
+		}
+		if err := initializers.DB.Where("LOWER(email) = LOWER(?)", identity.Email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("no account is linked to this Google identity")
+			}
+			return nil, err
+		}
+		if user.GoogleId != "" && user.GoogleId != identity.Subject {
+			return nil, errors.New("account is linked to a different Google identity")
+		}
+		if err := initializers.DB.Model(&user).Update("google_id", identity.Subject).Error; err != nil {
+			log.Printf("Error linking Google identity to user %d: %v", user.ID, err)
+			return nil, fmt.Errorf("internal error: failed to link Google account")
+		}
+	} else if err != nil {
+		return nil, err
 	}
-	if input.Role != "ADMIN" && input.Role != "SALES_EXECUTIVE" && input.Role != "MANAGER" {
-		return nil, fmt.Errorf("invalid role")
+
+	// A lockout after wrong passwords or MFA codes also holds for Google sign-in
+	if err := auth.CheckLoginAllowed(user.Email, auth.GetClientIP(ctx)); err != nil {
+		return nil, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if initializers.DB != nil {
-		user := models.User{
-			// ID:       uuid.NewString(),//gorm.Model has id field already set and is autoincremented
-			GoogleId: *input.GoogleID,
-			Name:     input.Name,
-			Email:    input.Email,
-			Phone:    *input.Phone,
-			Password: string(hashedPassword),
-			Role:     string(input.Role),
-		}
+	if user.TotpEnabled {
+		challengeToken, expiresAt, err := auth.NewMFAChallenge(&user)
 		if err != nil {
-			return nil, fmt.Errorf("failed to hash password: %v", err)
-		}
-		result := initializers.DB.Create(&user)
-		if result.Error != nil {
-			return nil, result.Error
+			log.Printf("Error creating MFA challenge: %v", err)
+			return nil, errors.New("failed to generate token")
 		}
-		return &generated.User{
+		return &generated.MfaChallenge{
+			ChallengeToken: challengeToken,
+			ExpiresAt:      expiresAt.Format(time.RFC3339),
+		}, nil
+	}
+
+	token, refreshToken, err := auth.NewSession(&user)
+	if err != nil {
+		log.Printf("Error creating session: %v", err)
+		return nil, errors.New("failed to generate token")
+	}
+
+	return &generated.AuthPayload{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User: &generated.User{
 			UserID:   fmt.Sprintf("%d", user.ID),
 			GoogleID: &user.GoogleId,
 			Name:     user.Name,
 			Email:    user.Email,
 			Phone:    user.Phone,
-			Password: user.Password,
 			Role:     user.Role,
-		}, nil
-	}
-	return nil, fmt.Errorf("database connection is nil")
+		},
+	}, nil
 }
 
-// UpdateUser is the resolver for the updateUser field.
-func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input generated.UpdateUserInput) (*generated.User, error) {
-	if initializers.DB == nil {
-		return nil, fmt.Errorf("database connection is nil")
-	}
-	role, err := auth.GetUserRoleFromJWT(ctx)
-
-	fmt.Println("Role: ", role)
-
+// VerifyMfa is the resolver for the verifyMfa field.
+func (r *mutationResolver) VerifyMfa(ctx context.Context, challengeToken string, code string) (*generated.AuthPayload, error) {
+	userID, err := auth.ParseMFAChallenge(challengeToken)
 	if err != nil {
-		return nil, fmt.Errorf("unauthorized")
-	}
-	if role != "ADMIN" && role != "MANAGER" {
-		return nil, fmt.Errorf("unauthorized to update user")
+		return nil, err
 	}
 
-	// Find the user by ID
 	var user models.User
 	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
-		return nil, fmt.Errorf("user not found: %v", err)
+		return nil, auth.ErrInvalidMFAChallenge
 	}
 
-	// Update the user's fields with the input
-	if input.Name != nil {
-		user.Name = *input.Name
-	}
-	if input.Email != nil {
-		user.Email = *input.Email
+	// Wrong codes count against the same lockout as wrong passwords
+	ip := auth.GetClientIP(ctx)
+	if err := auth.CheckLoginAllowed(user.Email, ip); err != nil {
+		return nil, err
 	}
-	if input.Phone != nil {
-		user.Phone = *input.Phone
+	ok, err := auth.VerifyMFACode(&user, code)
+	if err != nil {
+		log.Printf("Error verifying MFA code: %v", err)
+		return nil, fmt.Errorf("internal error: failed to verify code")
 	}
-	if input.Role != nil {
-		user.Role = string(*input.Role)
+	if !ok {
+		auth.RecordLoginFailure(user.Email, ip, &user.ID)
+		return nil, auth.ErrInvalidMFACode
 	}
+	auth.RecordLoginSuccess(user.Email)
 
-	// Save the updated user record in the database
-	if err := initializers.DB.Save(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to update user: %v", err)
+	token, refreshToken, err := auth.NewSession(&user)
+	if err != nil {
+		log.Printf("Error creating session: %v", err)
+		return nil, errors.New("failed to generate token")
 	}
 
-	// Return the updated user
-	return &generated.User{
-		UserID:   fmt.Sprintf("%d", user.ID),
-		GoogleID: &user.GoogleId,
-		Name:     user.Name,
-		Email:    user.Email,
-		Phone:    user.Phone,
-		Password: user.Password,
-		Role:     user.Role,
+	return &generated.AuthPayload{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         utils.ConvertUserSummary(&user),
 	}, nil
 }
 
-// DeleteUser is the resolver for the deleteUser field.
-func (r *mutationResolver) DeleteUser(ctx context.Context, userID string) (*generated.User, error) {
-	// panic(fmt.Errorf("not implemented: DeleteUser - deleteUser"))
-	if initializers.DB == nil {
-		return nil, fmt.Errorf("database connection is nil")
+// RefreshToken is the resolver for the refreshToken field.
+func (r *mutationResolver) RefreshToken(ctx context.Context, refreshToken string) (*generated.AuthPayload, error) {
+	user, token, newRefreshToken, err := auth.RefreshSession(refreshToken)
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidRefreshToken) {
+			log.Printf("Error refreshing session: %v", err)
+		}
+		return nil, auth.ErrInvalidRefreshToken
 	}
-	role, err := auth.GetUserRoleFromJWT(ctx)
-	fmt.Println("Role: ", role)
+
+	return &generated.AuthPayload{
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		User: &generated.User{
+			UserID:   fmt.Sprintf("%d", user.ID),
+			GoogleID: &user.GoogleId,
+			Name:     user.Name,
+			Email:    user.Email,
+			Phone:    user.Phone,
+			Role:     user.Role,
+		},
+	}, nil
+}
+
+// Logout is the resolver for the logout field.
+func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
+	sessionID, err := auth.GetSessionIDFromJWT(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("unauthorized")
+		return false, fmt.Errorf("unauthorized")
 	}
-	if role != "ADMIN" && role != "MANAGER" {
-		return nil, fmt.Errorf("unauthorized to delete user")
+	if err := auth.RevokeSession(sessionID); err != nil {
+		log.Printf("Error revoking session: %v", err)
+		return false, fmt.Errorf("internal error: failed to logout")
+	}
+	return true, nil
+}
+
+// RevokeAllSessions is the resolver for the revokeAllSessions field.
+func (r *mutationResolver) RevokeAllSessions(ctx context.Context, userID string) (bool, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return false, fmt.Errorf("unauthorized")
+	}
+	// Users may always sign themselves out everywhere. ADMIN may revoke anybody's sessions,
+	// MANAGER everybody's but an ADMIN's.
+	role, _ := claims["role"].(string)
+	self := claims["user_id"] == userID
+	if !self && role != "ADMIN" && role != "MANAGER" {
+		return false, fmt.Errorf("unauthorized to revoke sessions")
 	}
 
-	// Find the user by ID
 	var user models.User
 	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
-		return nil, fmt.Errorf("user not found: %v", err)
+		return false, fmt.Errorf("user not found: %v", err)
 	}
-	fmt.Println("User found: ", user)
-	// Delete the user record from the database
-	if err := initializers.DB.Delete(&user).Error; err != nil {
-		return nil, fmt.Errorf("failed to delete user: %v", err)
+	if !self && role == "MANAGER" && user.Role == "ADMIN" {
+		return false, fmt.Errorf("unauthorized to revoke sessions")
 	}
-	fmt.Println("User deleted: ", user)
-	// Return the deleted user
-	return &generated.User{
-		UserID:   fmt.Sprintf("%d", user.ID),
-		GoogleID: &user.GoogleId,
-		Name:     user.Name,
-		Email:    user.Email,
-		Phone:    user.Phone,
-		Password: user.Password,
-		Role:     user.Role,
-	}, nil
+	if err := auth.RevokeAllSessions(user.ID); err != nil {
+		log.Printf("Error revoking sessions: %v", err)
+		return false, fmt.Errorf("internal error: failed to revoke sessions")
+	}
+	return true, nil
 }
 
-// CreateOrganization is the resolver for the createOrganization field.
-func (r *mutationResolver) CreateOrganization(ctx context.Context, input generated.CreateOrganizationInput) (*generated.Organization, error) {
-	// Create new organization model instance
-	newOrganization := models.Organization{
-		OrganizationName:    input.OrganizationName,
-		OrganizationEmail:   input.OrganizationEmail,
-		OrganizationWebsite: *input.OrganizationWebsite,
-		City:                input.City,
-		Country:             input.Country,
-		NoOfEmployees:       input.NoOfEmployees,
-		AnnualRevenue:       input.AnnualRevenue,
+// ChangePassword is the resolver for the changePassword field.
+func (r *mutationResolver) ChangePassword(ctx context.Context, oldPassword string, newPassword string) (bool, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return false, fmt.Errorf("unauthorized")
 	}
 
-	// Save to database
-	if err := initializers.DB.Create(&newOrganization).Error; err != nil {
-		log.Printf("Error creating organization: %v", err)
-		return nil, fmt.Errorf("internal error: failed to create organization")
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", claims["user_id"]).Error; err != nil {
+		return false, errors.New("user not found")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
+		return false, errors.New("invalid password")
+	}
+	if err := utils.ValidatePassword(newPassword); err != nil {
+		return false, err
 	}
 
-	// Return the created organization
-	return &generated.Organization{
-		ID:                  fmt.Sprintf("%d", newOrganization.ID),
-		OrganizationName:    newOrganization.OrganizationName,
-		OrganizationEmail:   newOrganization.OrganizationEmail,
-		OrganizationWebsite: &newOrganization.OrganizationWebsite,
-		City:                newOrganization.City,
-		Country:             newOrganization.Country,
-		NoOfEmployees:       newOrganization.NoOfEmployees,
-		AnnualRevenue:       newOrganization.AnnualRevenue,
-	}, nil
-}
-
-// CreateCampaign is the resolver for the createCampaign field.
-func (r *mutationResolver) CreateCampaign(ctx context.Context, input generated.CreateCampaignInput) (*generated.Campaign, error) {
-	role, err := auth.GetUserRoleFromJWT(ctx)
-	fmt.Println("Role: ", role)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, fmt.Errorf("unauthorized")
-	}
-	if role != "ADMIN" && role != "MANAGER" {
-		return nil, fmt.Errorf("unauthorized to create campaign")
+		return false, fmt.Errorf("failed to hash password: %v", err)
 	}
-	// Create new campaign
-	newCampaign := models.Campaign{
-		CampaignName:     input.CampaignName,
-		CampaignCountry:  input.CampaignCountry,
-		CampaignRegion:   input.CampaignRegion,
-		IndustryTargeted: input.IndustryTargeted,
+	if err := initializers.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+		log.Printf("Error updating password: %v", err)
+		return false, fmt.Errorf("internal error: failed to change password")
 	}
-	if err := initializers.DB.Create(&newCampaign).Error; err != nil {
-		log.Printf("Error creating campaign: %v", err)
-		return nil, fmt.Errorf("internal error: failed to create campaign")
+
+	// Sign the user out everywhere else, the current session stays valid
+	sessionID, _ := auth.GetSessionIDFromJWT(ctx)
+	if err := auth.RevokeOtherSessions(user.ID, sessionID); err != nil {
+		log.Printf("Error revoking sessions for user %d: %v", user.ID, err)
 	}
-	return &generated.Campaign{
-		CampaignID:       fmt.Sprintf("%d", newCampaign.ID),
-		CampaignName:     newCampaign.CampaignName,
-		CampaignCountry:  newCampaign.CampaignCountry,
-		CampaignRegion:   newCampaign.CampaignRegion,
-		IndustryTargeted: newCampaign.IndustryTargeted,
-	}, nil
+	return true, nil
 }
 
-// AddUserToCampaign is the resolver for the addUserToCampaign field.
-func (r *mutationResolver) AddUserToCampaign(ctx context.Context, userID string, campaignID string) (*generated.Campaign, error) {
-	// panic(fmt.Errorf("not implemented: AddUserToCampaign - addUserToCampaign"))
+// RequestPasswordReset is the resolver for the requestPasswordReset field.
+func (r *mutationResolver) RequestPasswordReset(ctx context.Context, email string) (bool, error) {
+	// Always report success so the response does not reveal which emails have an account
+	var user models.User
+	if err := initializers.DB.Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error looking up user for password reset: %v", err)
+		}
+		return true, nil
+	}
 
-	role, err := auth.GetUserRoleFromJWT(ctx)
-	fmt.Println("Role: ", role)
+	token, err := auth.CreatePasswordResetToken(user.ID)
 	if err != nil {
-		return nil, fmt.Errorf("unauthorized")
-	}
-	if role != "ADMIN" && role != "MANAGER" {
-		return nil, fmt.Errorf("unauthorized to add user to campaign")
-	}
-	// Find the user by ID
-	var user models.User
-	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
-		log.Printf("Error finding user: %v", err)
-		return nil, fmt.Errorf("internal error: failed to find user")
+		log.Printf("Error creating password reset token: %v", err)
+		return false, fmt.Errorf("internal error: failed to request password reset")
 	}
 
-	// Find the campaign by ID
-	var campaign models.Campaign
-	if err := initializers.DB.First(&campaign, "id = ?", campaignID).Error; err != nil {
-		log.Printf("Error finding campaign: %v", err)
-		return nil, fmt.Errorf("internal error: failed to find campaign")
+	body := fmt.Sprintf("Hi %s,\n\nUse the following token to reset your password: %s\n", user.Name, token)
+	if resetURL := os.Getenv("PASSWORD_RESET_URL"); resetURL != "" {
+		body = fmt.Sprintf("Hi %s,\n\nOpen the following link to reset your password:\n%s?token=%s\n", user.Name, resetURL, url.QueryEscape(token))
 	}
-	// Add user to campaign
-	if err := initializers.DB.Model(&campaign).Association("Users").Append(&user); err != nil {
-		log.Printf("Error adding user to campaign: %v", err)
-		return nil, fmt.Errorf("internal error: failed to add user to campaign")
+	body += "\nIt can only be used once and expires shortly. If you did not request a password reset you can ignore this email.\n"
+
+	if err := r.Mailer.Send(ctx, mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Body:    body,
+	}); err != nil {
+		log.Printf("Error sending password reset mail to user %d: %v", user.ID, err)
 	}
-	return &generated.Campaign{
-		CampaignID:       fmt.Sprintf("%d", campaign.ID),
-		CampaignName:     campaign.CampaignName,
-		CampaignCountry:  campaign.CampaignCountry,
-		CampaignRegion:   campaign.CampaignRegion,
-		IndustryTargeted: campaign.IndustryTargeted,
-		Users: []*generated.User{
-			{
-				UserID: fmt.Sprintf("%d", user.ID),
-				Name:   user.Name,
-				Email:  user.Email,
-				Phone:  user.Phone,
-			},
-		},
-	}, nil
+	return true, nil
 }
 
-// RemoveUserFromCampaign is the resolver for the removeUserFromCampaign field.
-func (r *mutationResolver) RemoveUserFromCampaign(ctx context.Context, userID string, campaignID string) (*generated.Campaign, error) {
-	// panic(fmt.Errorf("not implemented: RemoveUserFromCampaign - removeUserFromCampaign"))
-	role, err := auth.GetUserRoleFromJWT(ctx)
-	fmt.Println("Role: ", role)
-	if err != nil {
-		return nil, fmt.Errorf("unauthorized")
+// ResetPassword is the resolver for the resetPassword field.
+func (r *mutationResolver) ResetPassword(ctx context.Context, token string, newPassword string) (bool, error) {
+	// Validate before consuming so a weak password does not burn the token
+	if err := utils.ValidatePassword(newPassword); err != nil {
+		return false, err
 	}
-	if role != "ADMIN" && role != "MANAGER" {
-		return nil, fmt.Errorf("unauthorized to remove user from campaign")
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password: %v", err)
 	}
 
-	// Check if user is part of the campaign
-	var exists bool
-	err = initializers.DB.Raw(
-		"SELECT EXISTS(SELECT 1 FROM campaign_users WHERE user_id = ? AND campaign_id = ?)", userID, campaignID,
-	).Scan(&exists).Error
-
+	userID, err := auth.ConsumePasswordResetToken(token)
 	if err != nil {
-		log.Printf("Error checking user in campaign: %v", err)
-		return nil, fmt.Errorf("internal error: failed to check user in campaign")
+		if !errors.Is(err, auth.ErrInvalidResetToken) {
+			log.Printf("Error consuming password reset token: %v", err)
+		}
+		return false, auth.ErrInvalidResetToken
 	}
 
-	if !exists {
-		return nil, fmt.Errorf("user is not part of this campaign")
+	if err := initializers.DB.Model(&models.User{}).Where("id = ?", userID).Update("password", string(hashedPassword)).Error; err != nil {
+		log.Printf("Error resetting password: %v", err)
+		return false, fmt.Errorf("internal error: failed to reset password")
 	}
-	// Find the user by ID
+	if err := auth.RevokeAllSessions(userID); err != nil {
+		log.Printf("Error revoking sessions for user %d: %v", userID, err)
+	}
+	return true, nil
+}
+
+// UnlockUser is the resolver for the unlockUser field.
+func (r *mutationResolver) UnlockUser(ctx context.Context, userID string) (bool, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return false, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return false, fmt.Errorf("unauthorized")
+	}
+
 	var user models.User
 	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
-		log.Printf("Error finding user: %v", err)
-		return nil, fmt.Errorf("internal error: failed to find user")
+		return false, fmt.Errorf("user not found: %v", err)
 	}
+	if err := auth.UnlockAccount(&user, actor.ID); err != nil {
+		log.Printf("Error unlocking user %d: %v", user.ID, err)
+		return false, fmt.Errorf("internal error: failed to unlock user")
+	}
+	return true, nil
+}
 
-	// Find the campaign by ID
-	var campaign models.Campaign
-	if err := initializers.DB.First(&campaign, "id = ?", campaignID).Error; err != nil {
-		log.Printf("Error finding campaign: %v", err)
-		return nil, fmt.Errorf("internal error: failed to find campaign")
+// EnrollTotp is the resolver for the enrollTotp field.
+func (r *mutationResolver) EnrollTotp(ctx context.Context) (*generated.TotpEnrollment, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
 	}
 
-	// Remove user from campaign
-	if err := initializers.DB.Model(&campaign).Association("Users").Delete(&user); err != nil {
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TotpEnabled {
+		return nil, errors.New("TOTP is already enabled")
+	}
+
+	// The secret stays inactive until confirmTotp proves the authenticator app was set up
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("internal error: failed to generate TOTP secret")
+	}
+	if err := initializers.DB.Model(&user).Updates(map[string]interface{}{
+		"totp_secret":         secret,
+		"totp_last_used_step": 0,
+	}).Error; err != nil {
+		log.Printf("Error storing TOTP secret: %v", err)
+		return nil, fmt.Errorf("internal error: failed to enroll TOTP")
+	}
+
+	return &generated.TotpEnrollment{
+		OtpauthURL:     auth.TOTPProvisioningURL(auth.TOTPIssuer(), user.Email, secret),
+		ManualEntryKey: secret,
+	}, nil
+}
+
+// ConfirmTotp is the resolver for the confirmTotp field.
+func (r *mutationResolver) ConfirmTotp(ctx context.Context, code string) ([]string, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TotpEnabled {
+		return nil, errors.New("TOTP is already enabled")
+	}
+	if user.TotpSecret == "" {
+		return nil, errors.New("TOTP enrollment has not been started")
+	}
+	step, valid := auth.ValidateTOTP(user.TotpSecret, code, user.TotpLastUsedStep, time.Now())
+	if !valid {
+		return nil, auth.ErrInvalidMFACode
+	}
+
+	var codes []string
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":        true,
+			"totp_last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = auth.ReplaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error enabling TOTP: %v", err)
+		return nil, fmt.Errorf("internal error: failed to confirm TOTP")
+	}
+
+	// Sessions started before enrollment are signed out. A token that was restricted
+	// until enrollment loses the restriction with the next refreshToken call.
+	sessionID, _ := auth.GetSessionIDFromJWT(ctx)
+	if err := auth.RevokeOtherSessions(user.ID, sessionID); err != nil {
+		log.Printf("Error revoking sessions for user %d: %v", user.ID, err)
+	}
+	return codes, nil
+}
+
+// DisableTotp is the resolver for the disableTotp field.
+func (r *mutationResolver) DisableTotp(ctx context.Context, code string) (bool, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return false, fmt.Errorf("unauthorized")
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", claims["user_id"]).Error; err != nil {
+		return false, errors.New("user not found")
+	}
+	if !user.TotpEnabled {
+		return false, errors.New("TOTP is not enabled")
+	}
+	if auth.MFARequiredForRole(user.Role) {
+		return false, errors.New("multi-factor authentication is required for your role")
+	}
+	valid, err := auth.VerifyMFACode(&user, code)
+	if err != nil {
+		log.Printf("Error verifying MFA code: %v", err)
+		return false, fmt.Errorf("internal error: failed to verify code")
+	}
+	if !valid {
+		return false, auth.ErrInvalidMFACode
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":        false,
+			"totp_secret":         "",
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		log.Printf("Error disabling TOTP: %v", err)
+		return false, fmt.Errorf("internal error: failed to disable TOTP")
+	}
+	return true, nil
+}
+
+// RegenerateRecoveryCodes is the resolver for the regenerateRecoveryCodes field.
+func (r *mutationResolver) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.TotpEnabled {
+		return nil, errors.New("TOTP is not enabled")
+	}
+	valid, err := auth.VerifyMFACode(&user, code)
+	if err != nil {
+		log.Printf("Error verifying MFA code: %v", err)
+		return nil, fmt.Errorf("internal error: failed to verify code")
+	}
+	if !valid {
+		return nil, auth.ErrInvalidMFACode
+	}
+
+	codes, err := auth.ReplaceRecoveryCodes(initializers.DB, user.ID)
+	if err != nil {
+		log.Printf("Error generating recovery codes: %v", err)
+		return nil, fmt.Errorf("internal error: failed to generate recovery codes")
+	}
+	return codes, nil
+}
+
+// SetMfaRequirement is the resolver for the setMfaRequirement field.
+func (r *mutationResolver) SetMfaRequirement(ctx context.Context, role generated.UserRole, required bool) (*generated.MfaPolicy, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	policy := models.MfaPolicy{
+		Role:        role.String(),
+		Required:    required,
+		UpdatedByID: &actor.ID,
+	}
+	if err := initializers.DB.Save(&policy).Error; err != nil {
+		log.Printf("Error saving MFA policy: %v", err)
+		return nil, fmt.Errorf("internal error: failed to update MFA policy")
+	}
+
+	return &generated.MfaPolicy{
+		Role:      role,
+		Required:  policy.Required,
+		UpdatedAt: policy.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// CreateAPIKey is the resolver for the createApiKey field.
+func (r *mutationResolver) CreateAPIKey(ctx context.Context, input generated.CreateAPIKeyInput) (*generated.CreatedAPIKey, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	scopes, err := auth.NormalizeAPIKeyScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, plaintext, err := auth.CreateAPIKey(name, scopes, actor.ID)
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		return nil, fmt.Errorf("internal error: failed to create API key")
+	}
+	key.CreatedBy = actor
+
+	return &generated.CreatedAPIKey{
+		APIKey:       utils.ConvertAPIKey(key),
+		PlaintextKey: plaintext,
+	}, nil
+}
+
+// RevokeAPIKey is the resolver for the revokeApiKey field.
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (*generated.APIKey, error) {
+	var key models.APIKey
+	if err := initializers.DB.Preload("CreatedBy").First(&key, "id = ?", id).Error; err != nil {
+		return nil, errors.New("API key not found")
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		if err := initializers.DB.Model(&key).Update("revoked_at", now).Error; err != nil {
+			log.Printf("Error revoking API key: %v", err)
+			return nil, fmt.Errorf("internal error: failed to revoke API key")
+		}
+		key.RevokedAt = &now
+	}
+	return utils.ConvertAPIKey(&key), nil
+}
+
+// UpdateDealAutomationConfig is the resolver for the updateDealAutomationConfig field.
+func (r *mutationResolver) UpdateDealAutomationConfig(ctx context.Context, input generated.DealAutomationConfigInput) (*generated.DealAutomationConfig, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	config, err := utils.NewDealAutomationConfig(input, &actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := initializers.DB.Save(&config).Error; err != nil {
+		log.Printf("Error saving deal automation config: %v", err)
+		return nil, fmt.Errorf("internal error: failed to update deal automation config")
+	}
+	config.UpdatedBy = &actor
+	return utils.ConvertDealAutomationConfig(&config), nil
+}
+
+// SetExchangeRate is the resolver for the setExchangeRate field.
+func (r *mutationResolver) SetExchangeRate(ctx context.Context, input generated.SetExchangeRateInput) (*generated.ExchangeRate, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	from := strings.ToUpper(strings.TrimSpace(input.FromCurrency))
+	to := strings.ToUpper(strings.TrimSpace(input.ToCurrency))
+	if err := scalars.ValidateCurrency(from); err != nil {
+		return nil, err
+	}
+	if err := scalars.ValidateCurrency(to); err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, errors.New("fromCurrency and toCurrency must differ")
+	}
+	if !input.Rate.IsPositive() {
+		return nil, errors.New("rate must be positive")
+	}
+
+	rate := models.ExchangeRate{
+		FromCurrency:  from,
+		ToCurrency:    to,
+		EffectiveDate: input.EffectiveDate,
+		Rate:          input.Rate,
+		UpdatedByID:   &actor.ID,
+	}
+	if err := initializers.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}, {Name: "effective_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_by_id", "updated_at"}),
+	}).Create(&rate).Error; err != nil {
+		log.Printf("Error saving exchange rate: %v", err)
+		return nil, fmt.Errorf("internal error: failed to save exchange rate")
+	}
+
+	// On conflict the existing row keeps its ID, so reload it by its key
+	if err := initializers.DB.Preload("UpdatedBy").
+		Where("from_currency = ? AND to_currency = ? AND effective_date = ?", from, to, input.EffectiveDate).
+		First(&rate).Error; err != nil {
+		log.Printf("Error loading exchange rate: %v", err)
+		return nil, fmt.Errorf("internal error: failed to save exchange rate")
+	}
+	return utils.ConvertExchangeRate(&rate), nil
+}
+
+// DeleteExchangeRate is the resolver for the deleteExchangeRate field.
+func (r *mutationResolver) DeleteExchangeRate(ctx context.Context, id string) (*generated.ExchangeRate, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("exchange rate not found")
+	}
+	var rate models.ExchangeRate
+	if err := initializers.DB.Preload("UpdatedBy").First(&rate, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("exchange rate not found")
+		}
+		log.Printf("Error loading exchange rate: %v", err)
+		return nil, fmt.Errorf("internal error: failed to delete exchange rate")
+	}
+	if err := initializers.DB.Delete(&rate).Error; err != nil {
+		log.Printf("Error deleting exchange rate: %v", err)
+		return nil, fmt.Errorf("internal error: failed to delete exchange rate")
+	}
+	return utils.ConvertExchangeRate(&rate), nil
+}
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input generated.CreateUserInput) (*generated.User, error) {
+	if initializers.DB == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	if input.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if input.Email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+	if input.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	if err := utils.ValidatePassword(input.Password); err != nil {
+		return nil, err
+	}
+	if input.Role == "" {
+		return nil, fmt.Errorf("role is required")
+	}
+	if input.Role != "ADMIN" && input.Role != "SALES_EXECUTIVE" && input.Role != "MANAGER" {
+		return nil, fmt.Errorf("invalid role")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if initializers.DB != nil {
+		user := models.User{
+			// ID:       uuid.NewString(),//gorm.Model has id field already set and is autoincremented
+			GoogleId: *input.GoogleID,
+			Name:     input.Name,
+			Email:    input.Email,
+			Phone:    *input.Phone,
+			Password: string(hashedPassword),
+			Role:     string(input.Role),
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %v", err)
+		}
+		result := initializers.DB.Create(&user)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		return &generated.User{
+			UserID:   fmt.Sprintf("%d", user.ID),
+			GoogleID: &user.GoogleId,
+			Name:     user.Name,
+			Email:    user.Email,
+			Phone:    user.Phone,
+			Role:     user.Role,
+		}, nil
+	}
+	return nil, fmt.Errorf("database connection is nil")
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input generated.UpdateUserInput) (*generated.User, error) {
+	if initializers.DB == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	// Find the user by ID
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+
+	// Update the user's fields with the input
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+	if input.Email != nil {
+		user.Email = *input.Email
+	}
+	if input.Phone != nil {
+		user.Phone = *input.Phone
+	}
+	roleChanged := false
+	if input.Role != nil {
+		roleChanged = user.Role != string(*input.Role)
+		user.Role = string(*input.Role)
+	}
+
+	// Save the updated user record in the database
+	if err := initializers.DB.Save(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+
+	// Existing tokens still carry the old role, force the user to sign in again
+	if roleChanged {
+		if err := auth.RevokeAllSessions(user.ID); err != nil {
+			log.Printf("Error revoking sessions for user %d: %v", user.ID, err)
+		}
+	}
+
+	// Return the updated user
+	return &generated.User{
+		UserID:   fmt.Sprintf("%d", user.ID),
+		GoogleID: &user.GoogleId,
+		Name:     user.Name,
+		Email:    user.Email,
+		Phone:    user.Phone,
+		Role:     user.Role,
+	}, nil
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, userID string) (*generated.User, error) {
+	// panic(fmt.Errorf("not implemented: DeleteUser - deleteUser"))
+	if initializers.DB == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	// Find the user by ID
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+	fmt.Println("User found: ", user)
+	// Delete the user record from the database
+	if err := initializers.DB.Delete(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete user: %v", err)
+	}
+	fmt.Println("User deleted: ", user)
+	if err := auth.RevokeAllSessions(user.ID); err != nil {
+		log.Printf("Error revoking sessions for user %d: %v", user.ID, err)
+	}
+	// Return the deleted user
+	return &generated.User{
+		UserID:   fmt.Sprintf("%d", user.ID),
+		GoogleID: &user.GoogleId,
+		Name:     user.Name,
+		Email:    user.Email,
+		Phone:    user.Phone,
+		Role:     user.Role,
+	}, nil
+}
+
+// CreateOrganization is the resolver for the createOrganization field.
+func (r *mutationResolver) CreateOrganization(ctx context.Context, input generated.CreateOrganizationInput) (*generated.Organization, error) {
+	// Create new organization model instance
+	newOrganization := models.Organization{
+		OrganizationName:  input.OrganizationName,
+		OrganizationEmail: input.OrganizationEmail,
+		City:              input.City,
+		Country:           input.Country,
+		NoOfEmployees:     input.NoOfEmployees,
+	}
+	if input.OrganizationWebsite != nil {
+		newOrganization.OrganizationWebsite = *input.OrganizationWebsite
+	}
+	utils.SetOrganizationRevenue(&newOrganization, input.AnnualRevenue)
+	if input.ParentID != nil && *input.ParentID != "" {
+		parent, err := utils.FindParentOrganization(initializers.DB, &newOrganization, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		newOrganization.ParentID = &parent.ID
+	}
+
+	// Save to database
+	if err := initializers.DB.Create(&newOrganization).Error; err != nil {
+		log.Printf("Error creating organization: %v", err)
+		return nil, fmt.Errorf("internal error: failed to create organization")
+	}
+
+	// Return the created organization
+	return utils.ConvertOrganization(&newOrganization), nil
+}
+
+// UpdateOrganization is the resolver for the updateOrganization field.
+func (r *mutationResolver) UpdateOrganization(ctx context.Context, id string, input generated.UpdateOrganizationInput) (*generated.Organization, error) {
+	organization, err := utils.FindOrganization(initializers.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := utils.ApplyOrganizationPatch(initializers.DB, organization, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) > 0 {
+		if err := initializers.DB.Model(organization).Select(columns).Updates(organization).Error; err != nil {
+			log.Printf("Error updating organization %s: %v", id, err)
+			return nil, fmt.Errorf("internal error: failed to update organization")
+		}
+	}
+	return utils.ConvertOrganization(organization), nil
+}
+
+// DeleteOrganization is the resolver for the deleteOrganization field.
+func (r *mutationResolver) DeleteOrganization(ctx context.Context, id string, leadPolicy generated.OrganizationLeadPolicy, reassignTo *string) (*generated.Organization, error) {
+	organization, err := utils.FindOrganization(initializers.DB, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *models.Organization
+	switch leadPolicy {
+	case generated.OrganizationLeadPolicyReassign:
+		if reassignTo == nil || *reassignTo == "" {
+			return nil, errors.New("reassignTo is required to reassign the leads")
+		}
+		if target, err = utils.FindOrganization(initializers.DB, *reassignTo); err != nil {
+			return nil, err
+		}
+		if target.ID == organization.ID {
+			return nil, errors.New("leads cannot be reassigned to the organization that is deleted")
+		}
+	case generated.OrganizationLeadPolicyReject:
+		var leads int64
+		if err := initializers.DB.Model(&models.Lead{}).Where("organization_id = ?", fmt.Sprintf("%d", organization.ID)).Count(&leads).Error; err != nil {
+			log.Printf("Error counting leads of organization %s: %v", id, err)
+			return nil, fmt.Errorf("internal error: failed to delete organization")
+		}
+		if leads > 0 {
+			return nil, errors.New("organization has leads, detach or reassign them")
+		}
+	}
+	if reassignTo != nil && leadPolicy != generated.OrganizationLeadPolicyReassign {
+		return nil, errors.New("reassignTo is only used to reassign the leads")
+	}
+
+	// Leads added since they were counted lose the organization instead of pointing to a
+	// deleted one
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return utils.DeleteOrganization(tx, organization, target)
+	})
+	if err != nil {
+		log.Printf("Error deleting organization %s: %v", id, err)
+		return nil, fmt.Errorf("internal error: failed to delete organization")
+	}
+	return utils.ConvertOrganization(organization), nil
+}
+
+// CreateCampaign is the resolver for the createCampaign field.
+func (r *mutationResolver) CreateCampaign(ctx context.Context, input generated.CreateCampaignInput) (*generated.Campaign, error) {
+	// Create new campaign
+	newCampaign, err := utils.NewCampaign(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := initializers.DB.Create(newCampaign).Error; err != nil {
+		log.Printf("Error creating campaign: %v", err)
+		return nil, fmt.Errorf("internal error: failed to create campaign")
+	}
+	return utils.ConvertCampaign(newCampaign), nil
+}
+
+// AddUserToCampaign is the resolver for the addUserToCampaign field.
+func (r *mutationResolver) AddUserToCampaign(ctx context.Context, userID string, campaignID string) (*generated.Campaign, error) {
+	// panic(fmt.Errorf("not implemented: AddUserToCampaign - addUserToCampaign"))
+
+	// Find the user by ID
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
+		log.Printf("Error finding user: %v", err)
+		return nil, fmt.Errorf("internal error: failed to find user")
+	}
+
+	// Find the campaign by ID
+	var campaign models.Campaign
+	if err := initializers.DB.First(&campaign, "id = ?", campaignID).Error; err != nil {
+		log.Printf("Error finding campaign: %v", err)
+		return nil, fmt.Errorf("internal error: failed to find campaign")
+	}
+	// Add user to campaign
+	if err := initializers.DB.Model(&campaign).Association("Users").Append(&user); err != nil {
+		log.Printf("Error adding user to campaign: %v", err)
+		return nil, fmt.Errorf("internal error: failed to add user to campaign")
+	}
+	result := utils.ConvertCampaign(&campaign)
+	result.Users = []*generated.User{
+		{
+			UserID: fmt.Sprintf("%d", user.ID),
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+		},
+	}
+	return result, nil
+}
+
+// RemoveUserFromCampaign is the resolver for the removeUserFromCampaign field.
+func (r *mutationResolver) RemoveUserFromCampaign(ctx context.Context, userID string, campaignID string) (*generated.Campaign, error) {
+	// panic(fmt.Errorf("not implemented: RemoveUserFromCampaign - removeUserFromCampaign"))
+	// Check if user is part of the campaign
+	var exists bool
+	err := initializers.DB.Raw(
+		"SELECT EXISTS(SELECT 1 FROM campaign_users WHERE user_id = ? AND campaign_id = ?)", userID, campaignID,
+	).Scan(&exists).Error
+
+	if err != nil {
+		log.Printf("Error checking user in campaign: %v", err)
+		return nil, fmt.Errorf("internal error: failed to check user in campaign")
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("user is not part of this campaign")
+	}
+	// Find the user by ID
+	var user models.User
+	if err := initializers.DB.First(&user, "id = ?", userID).Error; err != nil {
+		log.Printf("Error finding user: %v", err)
+		return nil, fmt.Errorf("internal error: failed to find user")
+	}
+
+	// Find the campaign by ID
+	var campaign models.Campaign
+	if err := initializers.DB.First(&campaign, "id = ?", campaignID).Error; err != nil {
+		log.Printf("Error finding campaign: %v", err)
+		return nil, fmt.Errorf("internal error: failed to find campaign")
+	}
+
+	// Remove user from campaign
+	if err := initializers.DB.Model(&campaign).Association("Users").Delete(&user); err != nil {
 		log.Printf("Error removing user from campaign: %v", err)
 		return nil, fmt.Errorf("internal error: failed to remove user from campaign")
 
 	}
-	return &generated.Campaign{
-		CampaignID:       fmt.Sprintf("%d", campaign.ID),
-		CampaignName:     campaign.CampaignName,
-		CampaignCountry:  campaign.CampaignCountry,
-		CampaignRegion:   campaign.CampaignRegion,
-		IndustryTargeted: campaign.IndustryTargeted,
-		Users: []*generated.User{
-			{
-				UserID: fmt.Sprintf("%d", user.ID),
-				Name:   user.Name,
-				Email:  user.Email,
-				Phone:  user.Phone,
-			},
-		},
+	result := utils.ConvertCampaign(&campaign)
+	result.Users = []*generated.User{
+		{
+			UserID: fmt.Sprintf("%d", user.ID),
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+		},
+	}
+	return result, nil
+}
+
+// SetCampaignMembers is the resolver for the setCampaignMembers field.
+func (r *mutationResolver) SetCampaignMembers(ctx context.Context, campaignID string, members []*generated.CampaignMemberInput) (*generated.Campaign, error) {
+	campaign, err := utils.FindCampaign(initializers.DB, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.CanManageCampaignMembers(ctx, campaign) {
+		return nil, errors.New("only ADMIN, MANAGER and campaign owners can change its members")
+	}
+
+	newMembers, err := utils.NewCampaignMembers(initializers.DB, campaign, members)
+	if err != nil {
+		return nil, err
+	}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return utils.ReplaceCampaignMembers(tx, campaign, newMembers)
+	})
+	if err != nil {
+		log.Printf("Error setting members of campaign %s: %v", campaignID, err)
+		return nil, fmt.Errorf("internal error: failed to set campaign members")
+	}
+
+	campaign, err = utils.FindCampaign(initializers.DB, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertCampaign(campaign), nil
+}
+
+// UpdateCampaign is the resolver for the updateCampaign field.
+func (r *mutationResolver) UpdateCampaign(ctx context.Context, campaignID string, input generated.UpdateCampaignInput) (*generated.Campaign, error) {
+	campaign, err := utils.FindCampaign(initializers.DB, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := utils.ApplyCampaignPatch(campaign, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) > 0 {
+		if err := initializers.DB.Model(campaign).Select(columns).Updates(campaign).Error; err != nil {
+			log.Printf("Error updating campaign %s: %v", campaignID, err)
+			return nil, fmt.Errorf("internal error: failed to update campaign")
+		}
+	}
+	return utils.ConvertCampaign(campaign), nil
+}
+
+// SetCampaignStatus is the resolver for the setCampaignStatus field.
+func (r *mutationResolver) SetCampaignStatus(ctx context.Context, campaignID string, status generated.CampaignStatus) (*generated.Campaign, error) {
+	campaign, err := utils.FindCampaign(initializers.DB, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := utils.ChangeCampaignStatus(campaign, models.CampaignStatus(status))
+	if err != nil {
+		return nil, err
+	}
+	if err := initializers.DB.Model(campaign).Select(columns).Updates(campaign).Error; err != nil {
+		log.Printf("Error changing status of campaign %s: %v", campaignID, err)
+		return nil, fmt.Errorf("internal error: failed to change campaign status")
+	}
+	return utils.ConvertCampaign(campaign), nil
+}
+
+// DeleteCampaign is the resolver for the deleteCampaign field.
+func (r *mutationResolver) DeleteCampaign(ctx context.Context, campaignID string) (*generated.Campaign, error) {
+	campaign, err := utils.FindCampaign(initializers.DB, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	var leads int64
+	if err := initializers.DB.Model(&models.Lead{}).Where("campaign_id = ?", campaignID).Count(&leads).Error; err != nil {
+		log.Printf("Error counting leads of campaign %s: %v", campaignID, err)
+		return nil, fmt.Errorf("internal error: failed to delete campaign")
+	}
+	if leads > 0 {
+		return nil, errors.New("campaign has leads, archive it instead")
+	}
+	if err := initializers.DB.Delete(campaign).Error; err != nil {
+		log.Printf("Error deleting campaign %s: %v", campaignID, err)
+		return nil, fmt.Errorf("internal error: failed to delete campaign")
+	}
+	return utils.ConvertCampaign(campaign), nil
+}
+
+// CreateLead is the resolver for the createLead field.
+func (r *mutationResolver) CreateLead(ctx context.Context, input generated.CreateLeadInput) (*generated.Lead, error) {
+	// Check if LeadCreatedBy exists
+	// var createdByUser models.User
+
+	jwtClaims, _ := auth.GetUserFromJWT(ctx)
+
+	// Check if LeadAssignedTo exists, leads without one are assigned by the assignment rules
+	var assignedTo string
+	if input.LeadAssignedTo != nil && *input.LeadAssignedTo != "" {
+		var assignedToUser models.User
+		if err := initializers.DB.First(&assignedToUser, "id = ?", *input.LeadAssignedTo).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("assigned user not found")
+			}
+			return nil, err
+		}
+		assignedTo = *input.LeadAssignedTo
+	}
+	// Check if Organization exists
+	var organization models.Organization
+	if err := initializers.DB.First(&organization, "id = ?", input.OrganizationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		return nil, err
+	}
+	// Check if Campaign exists
+	var campaign models.Campaign
+	if err := initializers.DB.First(&campaign, "id = ?", input.CampaignID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("campaign not found")
+		}
+		return nil, err
+	}
+	userID, ok := jwtClaims["user_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract user ID from JWT")
+	}
+	lead := models.Lead{
+		LeadID:             uuid.New().String(),
+		FirstName:          input.FirstName,
+		LastName:           input.LastName,
+		Email:              input.Email,
+		LinkedIn:           input.LinkedIn,
+		Country:            input.Country,
+		Phone:              input.Phone,
+		LeadSource:         input.LeadSource,
+		InitialContactDate: input.InitialContactDate,
+		LeadCreatedBy:      userID,
+		LeadAssignedTo:     assignedTo,
+		LeadStage:          models.LeadStage(input.LeadStage),
+		LeadNotes:          input.LeadNotes,
+		LeadPriority:       input.LeadPriority.String(),
+		OrganizationID:     input.OrganizationID,
+		CampaignID:         input.CampaignID,
+	}
+
+	pipeline, err := utils.LoadLeadPipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	// Save lead to DB together with its initial stage. The stage is validated after the
+	// assignment rules ran since it may require an assignee.
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if lead.LeadAssignedTo == "" {
+			if err := utils.AssignLead(tx, &lead, &campaign); err != nil {
+				log.Printf("Error assigning lead: %v", err)
+				return fmt.Errorf("internal error: failed to assign lead")
+			}
+		}
+		if err := pipeline.ValidateInitialStage(&lead); err != nil {
+			return err
+		}
+		if err := tx.Create(&lead).Error; err != nil {
+			return err
+		}
+		return utils.RecordInitialStage(tx, &lead, utils.ActorID(ctx))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := utils.LoadLead(initializers.DB, lead.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertLead(created), nil
+}
+
+// UpdateLead is the resolver for the updateLead field.
+func (r *mutationResolver) UpdateLead(ctx context.Context, leadID string, input generated.UpdateLeadInput) (*generated.Lead, error) {
+	lead, err := utils.FindEditableLead(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := utils.ApplyLeadPatch(initializers.DB, lead, input)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := utils.LoadLeadPipeline()
+	if err != nil {
+		return nil, err
+	}
+	stage := lead.LeadStage
+	if value, ok := input.LeadStage.ValueOK(); ok {
+		if value == nil {
+			return nil, errors.New("leadStage cannot be cleared")
+		}
+		stage = models.LeadStage(*value)
+	}
+	reason := ""
+	if input.StageReason != nil {
+		reason = *input.StageReason
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Update Lead Details, the stage is changed separately so it is validated and recorded
+		if len(columns) > 0 {
+			if err := tx.Model(lead).Select(columns).Updates(lead).Error; err != nil {
+				log.Printf("Error updating lead %s: %v", leadID, err)
+				return fmt.Errorf("internal error: failed to update lead")
+			}
+		}
+
+		if stage == lead.LeadStage {
+			return nil
+		}
+		// Validated against the updated lead, so e.g. an assignee can be set in the same update
+		if err := pipeline.ValidateStageChange(lead, stage, reason); err != nil {
+			return err
+		}
+		if err := utils.ChangeLeadStage(tx, lead, stage, reason, utils.ActorID(ctx)); err != nil {
+			log.Printf("Error changing stage of lead %s: %v", leadID, err)
+			return fmt.Errorf("internal error: failed to change lead stage")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := utils.LoadLead(initializers.DB, leadID)
+	if err != nil {
+		log.Printf("Error reloading lead %s: %v", leadID, err)
+		return nil, fmt.Errorf("internal error: failed to reload lead")
+	}
+	return utils.ConvertLead(updated), nil
+}
+
+// DeleteLead is the resolver for the deleteLead field.
+func (r *mutationResolver) DeleteLead(ctx context.Context, leadID string) (*generated.Lead, error) {
+	// panic(fmt.Errorf("not implemented: DeleteLead - deleteLead"))
+
+	lead, err := utils.FindEditableLead(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := initializers.DB.Delete(lead).Error; err != nil {
+		return nil, err
+	}
+	return &generated.Lead{
+		LeadID:    lead.LeadID,
+		FirstName: lead.FirstName,
+		LastName:  lead.LastName,
+		Email:     lead.Email,
+		LinkedIn:  lead.LinkedIn,
+		Country:   lead.Country,
 	}, nil
 }
 
-// CreateLead is the resolver for the createLead field.
-func (r *mutationResolver) CreateLead(ctx context.Context, input generated.CreateLeadInput) (*generated.Lead, error) {
-	// Check if LeadCreatedBy exists
-	// var createdByUser models.User
+// MoveLeadStage is the resolver for the moveLeadStage field.
+func (r *mutationResolver) MoveLeadStage(ctx context.Context, leadID string, stage generated.LeadStage, reason *string) (*generated.Lead, error) {
+	lead, err := utils.FindEditableLead(ctx, leadID)
+	if err != nil {
+		return nil, err
+	}
 
-	jwtClaims, _ := auth.GetUserFromJWT(ctx)
-	fmt.Println("User from JWT: ", jwtClaims)
-	if jwtClaims == nil {
-		return nil, errors.New("unauthorized")
+	pipeline, err := utils.LoadLeadPipeline()
+	if err != nil {
+		return nil, err
+	}
+	to := models.LeadStage(stage)
+	stageReason := ""
+	if reason != nil {
+		stageReason = *reason
+	}
+	if err := pipeline.ValidateStageChange(lead, to, stageReason); err != nil {
+		return nil, err
 	}
 
-	// Check if LeadAssignedTo exists
-	var assignedToUser models.User
-	if err := initializers.DB.First(&assignedToUser, "id = ?", input.LeadAssignedTo).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("assigned user not found")
-		}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return utils.ChangeLeadStage(tx, lead, to, stageReason, utils.ActorID(ctx))
+	})
+	if err != nil {
+		log.Printf("Error changing stage of lead %s: %v", leadID, err)
+		return nil, fmt.Errorf("internal error: failed to change lead stage")
+	}
+
+	updated, err := utils.LoadLead(initializers.DB, leadID)
+	if err != nil {
 		return nil, err
 	}
-	// Check if Organization exists
+	return utils.ConvertLead(updated), nil
+}
+
+// CreateLeadWithActivity is the resolver for the createLeadWithActivity field.
+func (r *mutationResolver) CreateLeadWithActivity(ctx context.Context, input generated.CreateLeadWithActivityInput) (*generated.Lead, error) {
+	jwtClaims, _ := auth.GetUserFromJWT(ctx)
+
+	// Validate LeadAssignedTo exists, leads without one are assigned by the assignment rules
+	var assignedTo string
+	if input.LeadAssignedTo != nil && *input.LeadAssignedTo != "" {
+		var assignedToUser models.User
+		if err := initializers.DB.First(&assignedToUser, "id = ?", *input.LeadAssignedTo).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("assigned user not found")
+			}
+			return nil, err
+		}
+		assignedTo = *input.LeadAssignedTo
+	}
+
+	// Validate Organization exists
 	var organization models.Organization
 	if err := initializers.DB.First(&organization, "id = ?", input.OrganizationID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -405,7 +1386,8 @@ func (r *mutationResolver) CreateLead(ctx context.Context, input generated.Creat
 		}
 		return nil, err
 	}
-	// Check if Campaign exists
+
+	// Validate Campaign exists
 	var campaign models.Campaign
 	if err := initializers.DB.First(&campaign, "id = ?", input.CampaignID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -414,14 +1396,14 @@ func (r *mutationResolver) CreateLead(ctx context.Context, input generated.Creat
 		return nil, err
 	}
 	userID, ok := jwtClaims["user_id"].(string)
-	fmt.Println("User ID: ", userID)
 	if !ok {
 		return nil, fmt.Errorf("failed to extract user ID from JWT")
 	}
-	lead := models.Lead{
-		LeadID:             uuid.New().String(),
-		FirstName:          input.FirstName,
-		LastName:           input.LastName,
+	// Create new lead instance
+	newLead := models.Lead{
+		LeadID:             uuid.NewString(),
+		FirstName:          input.Firstname,
+		LastName:           input.Lastname,
 		Email:              input.Email,
 		LinkedIn:           input.LinkedIn,
 		Country:            input.Country,
@@ -429,319 +1411,624 @@ func (r *mutationResolver) CreateLead(ctx context.Context, input generated.Creat
 		LeadSource:         input.LeadSource,
 		InitialContactDate: input.InitialContactDate,
 		LeadCreatedBy:      userID,
-		LeadAssignedTo:     input.LeadAssignedTo,
-		LeadStage:          input.LeadStage.String(),
+		LeadAssignedTo:     assignedTo,
+		LeadStage:          models.LeadStage(input.LeadStage),
 		LeadNotes:          input.LeadNotes,
 		LeadPriority:       input.LeadPriority.String(),
 		OrganizationID:     input.OrganizationID,
 		CampaignID:         input.CampaignID,
 	}
 
-	// Save lead to DB
-	if err := initializers.DB.Create(&lead).Error; err != nil {
+	pipeline, err := utils.LoadLeadPipeline()
+	if err != nil {
 		return nil, err
 	}
 
-	return &generated.Lead{
-		LeadID:             lead.LeadID,
-		FirstName:          lead.FirstName,
-		LastName:           lead.LastName,
-		Email:              lead.Email,
-		LinkedIn:           lead.LinkedIn,
-		Country:            lead.Country,
-		Phone:              lead.Phone,
-		LeadSource:         lead.LeadSource,
-		InitialContactDate: lead.InitialContactDate,
-		LeadCreatedBy: &generated.User{
-			UserID: userID,
-		},
-		LeadAssignedTo: &generated.User{
-			UserID: fmt.Sprintf("%d", assignedToUser.ID),
-			Name:   assignedToUser.Name,
-			Email:  assignedToUser.Email,
-		},
-		LeadStage:    lead.LeadStage,
-		LeadNotes:    lead.LeadNotes,
-		LeadPriority: lead.LeadPriority,
-		Organization: &generated.Organization{
-			ID:               fmt.Sprintf("%d", organization.ID),
-			OrganizationName: organization.OrganizationName,
-		},
+	// Create new activity instance
+	newActivity := models.Activity{
+		ActivityID:           uuid.NewString(),
+		LeadID:               newLead.LeadID, // Associate the activity with the lead
+		ActivityType:         input.ActivityType,
+		DateTime:             input.DateTime,
+		CommunicationChannel: input.CommunicationChannel,
+		ContentNotes:         input.ContentNotes,
+		ParticipantDetails:   input.ParticipantDetails,
+		FollowUpActions:      input.FollowUpActions,
+	}
 
-		Campaign: &generated.Campaign{
-			CampaignID:   fmt.Sprintf("%d", campaign.ID),
-			CampaignName: campaign.CampaignName,
-		},
-	}, nil
+	// Use a transaction to ensure both Lead and Activity are created successfully. The stage is
+	// validated after the assignment rules ran since it may require an assignee.
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if newLead.LeadAssignedTo == "" {
+			if err := utils.AssignLead(tx, &newLead, &campaign); err != nil {
+				log.Printf("Error assigning lead: %v", err)
+				return fmt.Errorf("internal error: failed to assign lead")
+			}
+		}
+		if err := pipeline.ValidateInitialStage(&newLead); err != nil {
+			return err
+		}
+		if err := tx.Create(&newLead).Error; err != nil {
+			log.Printf("Error creating lead: %v", err)
+			return fmt.Errorf("internal error: failed to create lead")
+		}
+		if err := utils.RecordInitialStage(tx, &newLead, utils.ActorID(ctx)); err != nil {
+			log.Printf("Error recording lead stage: %v", err)
+			return fmt.Errorf("internal error: failed to create lead")
+		}
+
+		if err := tx.Create(&newActivity).Error; err != nil {
+			log.Printf("Error creating activity: %v", err)
+			return fmt.Errorf("internal error: failed to create activity")
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the created lead and its associated activity
+	created, err := utils.LoadLead(initializers.DB, newLead.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertLead(created), nil
 }
 
-// UpdateLead is the resolver for the updateLead field.
-func (r *mutationResolver) UpdateLead(ctx context.Context, leadID string, input generated.UpdateLeadInput) (*generated.Lead, error) {
-	lead := models.Lead{}
-	if err := initializers.DB.First(&lead, "lead_id = ?", leadID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("lead not found")
+// CreateDeal is the resolver for the createDeal field.
+func (r *mutationResolver) CreateDeal(ctx context.Context, input generated.CreateDealInput) (*generated.Deal, error) {
+	// panic(fmt.Errorf("not implemented: CreateDeal - createDeal"))
+
+	// The lead must exist and be visible to the caller
+	if _, err := utils.FindEditableLead(ctx, input.LeadID); err != nil {
+		return nil, err
+	}
+	status := models.DealStatus(input.DealStatus)
+	if status.IsClosed() {
+		return nil, errors.New("deals are closed with closeDeal")
+	}
+	if err := utils.ValidateDealDates(&input.DealStartDate, &input.DealEndDate); err != nil {
+		return nil, err
+	}
+
+	// Create new deal
+	newDeal := models.Deals{
+		LeadID:              input.LeadID,
+		DealName:            input.DealName,
+		DealAmount:          input.DealAmount.Amount,
+		DealCurrency:        input.DealAmount.Currency,
+		DealStartDate:       &input.DealStartDate,
+		DealEndDate:         &input.DealEndDate,
+		ProjectRequirements: input.ProjectRequirements,
+		DealStatus:          status,
+		ExpectedCloseDate:   input.ExpectedCloseDate,
+	}
+	if err := initializers.DB.Create(&newDeal).Error; err != nil {
+		log.Printf("Error creating deal: %v", err)
+		return nil, fmt.Errorf("internal error: failed to create deal")
+	}
+	return utils.ConvertDeal(&newDeal), nil
+}
+
+// UpdateDeal is the resolver for the updateDeal field.
+func (r *mutationResolver) UpdateDeal(ctx context.Context, dealID string, input generated.UpdateDealInput) (*generated.Deal, error) {
+	deal, err := utils.FindEditableDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if deal.DealStatus.IsClosed() {
+		return nil, errors.New("closed deals cannot be updated")
+	}
+
+	updates := map[string]interface{}{}
+	if input.DealName != nil {
+		updates["deal_name"] = *input.DealName
+	}
+	if input.LeadID != nil {
+		if _, err := utils.FindEditableLead(ctx, *input.LeadID); err != nil {
+			return nil, err
 		}
+		updates["lead_id"] = *input.LeadID
+	}
+	start, end := deal.DealStartDate, deal.DealEndDate
+	if input.DealStartDate != nil {
+		start = input.DealStartDate
+		updates["deal_start_date"] = *input.DealStartDate
+	}
+	if input.DealEndDate != nil {
+		end = input.DealEndDate
+		updates["deal_end_date"] = *input.DealEndDate
+	}
+	if err := utils.ValidateDealDates(start, end); err != nil {
 		return nil, err
 	}
+	if input.ProjectRequirements != nil {
+		updates["project_requirements"] = *input.ProjectRequirements
+	}
+	if input.DealAmount != nil {
+		var lineItems int64
+		if err := initializers.DB.Model(&models.DealLineItem{}).Where("deal_id = ?", deal.ID).Count(&lineItems).Error; err != nil {
+			log.Printf("Error counting deal line items: %v", err)
+			return nil, fmt.Errorf("internal error: failed to update deal")
+		}
+		if lineItems > 0 {
+			return nil, errors.New("dealAmount is computed from the line items of the deal")
+		}
+		updates["deal_amount"] = input.DealAmount.Amount
+		updates["deal_currency"] = input.DealAmount.Currency
+	}
+	if input.DealStatus != nil {
+		status := models.DealStatus(*input.DealStatus)
+		if status.IsClosed() {
+			return nil, errors.New("deals are closed with closeDeal")
+		}
+		updates["deal_status"] = status
+	}
+	if input.ExpectedCloseDate != nil {
+		updates["expected_close_date"] = *input.ExpectedCloseDate
+	}
 
-	// Check if LeadStage is updated to "WON" from a different value
-	isWon := input.LeadStage.String() == "CLOSED_WON" && lead.LeadStage != "CLOSED_WON"
+	if len(updates) > 0 {
+		if err := initializers.DB.Model(deal).Updates(updates).Error; err != nil {
+			log.Printf("Error updating deal: %v", err)
+			return nil, fmt.Errorf("internal error: failed to update deal")
+		}
+	}
+	if err := initializers.DB.First(deal, "id = ?", deal.ID).Error; err != nil {
+		return nil, err
+	}
+	return utils.ConvertDeal(deal), nil
+}
 
-	// Update Lead Details
-	if err := initializers.DB.Model(&lead).Updates(models.Lead{
-		FirstName:          *input.FirstName,
-		LastName:           *input.LastName,
-		Email:              input.Email,
-		LinkedIn:           *input.LinkedIn,
-		Country:            *input.Country,
-		Phone:              *input.Phone,
-		LeadSource:         input.LeadSource,
-		InitialContactDate: input.InitialContactDate,
-		LeadAssignedTo:     input.LeadAssignedTo,
-		LeadStage:          input.LeadStage.String(),
-		LeadNotes:          input.LeadNotes,
-		LeadPriority:       input.LeadPriority.String(),
-		OrganizationID:     input.OrganizationID,
-		CampaignID:         input.CampaignID,
+// CloseDeal is the resolver for the closeDeal field.
+func (r *mutationResolver) CloseDeal(ctx context.Context, dealID string, status generated.DealStatus) (*generated.Deal, error) {
+	closing := models.DealStatus(status)
+	if !closing.IsClosed() {
+		return nil, errors.New("a deal is closed as COMPLETED or CANCELLED")
+	}
+
+	deal, err := utils.FindEditableDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if deal.DealStatus.IsClosed() {
+		return nil, errors.New("deal is already closed")
+	}
+
+	if err := initializers.DB.Model(deal).Updates(map[string]interface{}{
+		"deal_status": closing,
+		"closed_at":   time.Now(),
 	}).Error; err != nil {
+		log.Printf("Error closing deal: %v", err)
+		return nil, fmt.Errorf("internal error: failed to close deal")
+	}
+	if err := initializers.DB.First(deal, "id = ?", deal.ID).Error; err != nil {
+		return nil, err
+	}
+	return utils.ConvertDeal(deal), nil
+}
+
+// DeleteDeal is the resolver for the deleteDeal field.
+func (r *mutationResolver) DeleteDeal(ctx context.Context, dealID string) (*generated.Deal, error) {
+	deal, err := utils.FindEditableDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if err := initializers.DB.Delete(deal).Error; err != nil {
+		log.Printf("Error deleting deal: %v", err)
+		return nil, fmt.Errorf("internal error: failed to delete deal")
+	}
+	return utils.ConvertDeal(deal), nil
+}
+
+// GenerateQuote is the resolver for the generateQuote field.
+func (r *mutationResolver) GenerateQuote(ctx context.Context, dealID string, templateID *string) (*generated.Quote, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	deal, err := utils.FindEditableDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if deal.DealStatus == models.DealStatusCancelled {
+		return nil, errors.New("cancelled deals cannot be quoted")
+	}
+
+	// Without a template the built-in one is used
+	body := quote.DefaultTemplate
+	var tmpl *models.QuoteTemplate
+	if templateID != nil {
+		tmpl = &models.QuoteTemplate{}
+		if _, err := uuid.Parse(*templateID); err != nil {
+			return nil, errors.New("quote template not found")
+		}
+		if err := initializers.DB.First(tmpl, "id = ?", *templateID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("quote template not found")
+			}
+			log.Printf("Error loading quote template: %v", err)
+			return nil, fmt.Errorf("internal error: failed to generate quote")
+		}
+		body = tmpl.Body
+	}
+
+	var newQuote models.Quote
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the deal so concurrent quotes get consecutive versions
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Deals{}, "id = ?", deal.ID).Error; err != nil {
+			log.Printf("Error locking deal: %v", err)
+			return fmt.Errorf("internal error: failed to generate quote")
+		}
+		var version int
+		if err := tx.Model(&models.Quote{}).Unscoped().Where("deal_id = ?", deal.ID).
+			Select("COALESCE(MAX(version), 0) + 1").Scan(&version).Error; err != nil {
+			log.Printf("Error numbering quote: %v", err)
+			return fmt.Errorf("internal error: failed to generate quote")
+		}
+
+		data, err := utils.BuildQuoteData(initializers.DB, deal, version, time.Now())
+		if err != nil {
+			log.Printf("Error collecting quote data: %v", err)
+			return fmt.Errorf("internal error: failed to generate quote")
+		}
+		html, err := quote.RenderHTML(body, data)
+		if err != nil {
+			return err
+		}
+		pdf, err := quote.RenderPDF(data)
+		if err != nil {
+			log.Printf("Error rendering quote PDF: %v", err)
+			return fmt.Errorf("internal error: failed to generate quote")
+		}
+
+		newQuote = models.Quote{
+			DealID:      deal.ID,
+			Version:     version,
+			Number:      data.Number,
+			Template:    tmpl,
+			Status:      models.QuoteStatusDraft,
+			Total:       deal.DealAmount,
+			Currency:    deal.DealCurrency,
+			HTML:        html,
+			PDF:         pdf,
+			CreatedByID: &actor.ID,
+			CreatedBy:   &actor,
+		}
+		if tmpl != nil {
+			newQuote.TemplateID = &tmpl.ID
+		}
+		if err := tx.Omit("Template", "CreatedBy").Create(&newQuote).Error; err != nil {
+			log.Printf("Error saving quote: %v", err)
+			return fmt.Errorf("internal error: failed to generate quote")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertQuote(&newQuote), nil
+}
+
+// UpdateQuoteStatus is the resolver for the updateQuoteStatus field.
+func (r *mutationResolver) UpdateQuoteStatus(ctx context.Context, id string, status generated.QuoteStatus) (*generated.Quote, error) {
+	q, err := utils.FindEditableQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	next := models.QuoteStatus(status)
+	if q.Status == next {
+		return utils.ConvertQuote(q), nil
+	}
+	if !q.Status.CanMoveTo(next) {
+		return nil, fmt.Errorf("a %s quote cannot be marked %s", q.Status, next)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": next}
+	if next == models.QuoteStatusSent {
+		updates["sent_at"] = now
+		q.SentAt = &now
+	} else {
+		updates["decided_at"] = now
+		q.DecidedAt = &now
+	}
+	// The status is checked again so two concurrent decisions cannot both win
+	result := initializers.DB.Model(&models.Quote{}).Where("id = ? AND status = ?", q.ID, q.Status).Updates(updates)
+	if result.Error != nil {
+		log.Printf("Error updating quote status: %v", result.Error)
+		return nil, fmt.Errorf("internal error: failed to update quote status")
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("quote was changed concurrently, reload it and try again")
+	}
+	q.Status = next
+	return utils.ConvertQuote(q), nil
+}
+
+// CreateQuoteTemplate is the resolver for the createQuoteTemplate field.
+func (r *mutationResolver) CreateQuoteTemplate(ctx context.Context, input generated.QuoteTemplateInput) (*generated.QuoteTemplate, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if _, err := quote.ParseTemplate(input.Body); err != nil {
+		return nil, err
+	}
+	tmpl := models.QuoteTemplate{
+		Name:        name,
+		Body:        input.Body,
+		CreatedByID: &actor.ID,
+	}
+	if input.Description != nil {
+		tmpl.Description = strings.TrimSpace(*input.Description)
+	}
+	if err := initializers.DB.Create(&tmpl).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.New("a quote template with this name already exists")
+		}
+		log.Printf("Error creating quote template: %v", err)
+		return nil, fmt.Errorf("internal error: failed to create quote template")
+	}
+	return utils.ConvertQuoteTemplate(&tmpl), nil
+}
+
+// UpdateQuoteTemplate is the resolver for the updateQuoteTemplate field.
+func (r *mutationResolver) UpdateQuoteTemplate(ctx context.Context, id string, input generated.QuoteTemplateInput) (*generated.QuoteTemplate, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("quote template not found")
+	}
+	var tmpl models.QuoteTemplate
+	if err := initializers.DB.First(&tmpl, "id = ?", id).Error; err != nil {
+		return nil, errors.New("quote template not found")
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if _, err := quote.ParseTemplate(input.Body); err != nil {
 		return nil, err
 	}
+	description := ""
+	if input.Description != nil {
+		description = strings.TrimSpace(*input.Description)
+	}
+
+	// Quotes already generated keep the documents they were rendered with
+	if err := initializers.DB.Model(&tmpl).Updates(map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"body":        input.Body,
+	}).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.New("a quote template with this name already exists")
+		}
+		log.Printf("Error updating quote template: %v", err)
+		return nil, fmt.Errorf("internal error: failed to update quote template")
+	}
+	return utils.ConvertQuoteTemplate(&tmpl), nil
+}
+
+// DeleteQuoteTemplate is the resolver for the deleteQuoteTemplate field.
+func (r *mutationResolver) DeleteQuoteTemplate(ctx context.Context, id string) (*generated.QuoteTemplate, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("quote template not found")
+	}
+	var tmpl models.QuoteTemplate
+	if err := initializers.DB.First(&tmpl, "id = ?", id).Error; err != nil {
+		return nil, errors.New("quote template not found")
+	}
+	// Hard delete so the name can be used again; quotes lose the reference
+	if err := initializers.DB.Unscoped().Delete(&tmpl).Error; err != nil {
+		log.Printf("Error deleting quote template: %v", err)
+		return nil, fmt.Errorf("internal error: failed to delete quote template")
+	}
+	return utils.ConvertQuoteTemplate(&tmpl), nil
+}
+
+// CreateAssignmentRule is the resolver for the createAssignmentRule field.
+func (r *mutationResolver) CreateAssignmentRule(ctx context.Context, input generated.AssignmentRuleInput) (*generated.AssignmentRule, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
 
-	// If LeadStage is updated to "WON" and no existing deal, create a new deal
-	if isWon {
-		existingDeal := models.Deals{}
-		if err := initializers.DB.Where("lead_id = ?", leadID).First(&existingDeal).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				// Create a new Deal only if it does not exist
-				newDeal := models.Deals{
-					LeadID:        leadID,
-					DealName:      fmt.Sprintf("Deal for %s %s", lead.FirstName, lead.LastName),
-					DealAmount:    "0", // Default, can be updated later
-					DealStartDate: time.Now().String(),
-					DealEndDate:   time.Now().AddDate(0, 6, 0).String(), // Example: 6 months duration
-					DealStatus:    "Active",
-				}
-				if err := initializers.DB.Create(&newDeal).Error; err != nil {
-					log.Printf("Error creating deal for lead %s: %v", leadID, err)
-					return nil, fmt.Errorf("internal error: failed to create deal")
-				}
-			}
+	rule := models.AssignmentRule{CreatedByID: &actor.ID}
+	if err := utils.BuildAssignmentRule(initializers.DB, input, &rule); err != nil {
+		return nil, err
+	}
+	if err := initializers.DB.Create(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.New("an assignment rule with this name already exists")
 		}
+		log.Printf("Error creating assignment rule: %v", err)
+		return nil, fmt.Errorf("internal error: failed to create assignment rule")
 	}
-
-	return &generated.Lead{
-		LeadID:             leadID,
-		FirstName:          lead.FirstName,
-		LastName:           lead.LastName,
-		Email:              lead.Email,
-		LinkedIn:           lead.LinkedIn,
-		Country:            lead.Country,
-		Phone:              lead.Phone,
-		LeadSource:         lead.LeadSource,
-		InitialContactDate: lead.InitialContactDate,
-		LeadAssignedTo: &generated.User{
-			UserID: fmt.Sprintf("%d", lead.Assignee.ID),
-			Name:   lead.Assignee.Name,
-			Email:  lead.Assignee.Email,
-			Phone:  lead.Assignee.Phone,
-		},
-		LeadStage:    lead.LeadStage,
-		LeadNotes:    lead.LeadNotes,
-		LeadPriority: lead.LeadPriority,
-		Organization: &generated.Organization{
-			ID:               lead.OrganizationID,
-			OrganizationName: lead.Organization.OrganizationName,
-		},
-		Campaign: &generated.Campaign{
-			CampaignID: lead.CampaignID,
-		},
-	}, nil
+	return utils.ConvertAssignmentRule(&rule), nil
 }
 
-// DeleteLead is the resolver for the deleteLead field.
-func (r *mutationResolver) DeleteLead(ctx context.Context, leadID string) (*generated.Lead, error) {
-	// panic(fmt.Errorf("not implemented: DeleteLead - deleteLead"))
+// UpdateAssignmentRule is the resolver for the updateAssignmentRule field.
+func (r *mutationResolver) UpdateAssignmentRule(ctx context.Context, id string, input generated.AssignmentRuleInput) (*generated.AssignmentRule, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("assignment rule not found")
+	}
+	var rule models.AssignmentRule
+	if err := initializers.DB.First(&rule, "id = ?", id).Error; err != nil {
+		return nil, errors.New("assignment rule not found")
+	}
 
-	lead := models.Lead{}
-	if err := initializers.DB.First(&lead, "lead_id = ?", leadID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("lead not found")
-		}
+	if err := utils.BuildAssignmentRule(initializers.DB, input, &rule); err != nil {
 		return nil, err
 	}
-	if err := initializers.DB.Delete(&lead).Error; err != nil {
-		return nil, err
+	// Leads already assigned keep their assignee and the rule that picked them
+	if err := initializers.DB.Save(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.New("an assignment rule with this name already exists")
+		}
+		log.Printf("Error updating assignment rule: %v", err)
+		return nil, fmt.Errorf("internal error: failed to update assignment rule")
 	}
-	return &generated.Lead{
-		LeadID:    lead.LeadID,
-		FirstName: lead.FirstName,
-		LastName:  lead.LastName,
-		Email:     lead.Email,
-		LinkedIn:  lead.LinkedIn,
-		Country:   lead.Country,
-	}, nil
+	return utils.ConvertAssignmentRule(&rule), nil
 }
 
-// CreateLeadWithActivity is the resolver for the createLeadWithActivity field.
-func (r *mutationResolver) CreateLeadWithActivity(ctx context.Context, input generated.CreateLeadWithActivityInput) (*generated.Lead, error) {
-	jwtClaims, _ := auth.GetUserFromJWT(ctx)
+// DeleteAssignmentRule is the resolver for the deleteAssignmentRule field.
+func (r *mutationResolver) DeleteAssignmentRule(ctx context.Context, id string) (*generated.AssignmentRule, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("assignment rule not found")
+	}
+	var rule models.AssignmentRule
+	if err := initializers.DB.First(&rule, "id = ?", id).Error; err != nil {
+		return nil, errors.New("assignment rule not found")
+	}
+	// Hard delete so the name can be used again; leads lose the reference
+	if err := initializers.DB.Unscoped().Delete(&rule).Error; err != nil {
+		log.Printf("Error deleting assignment rule: %v", err)
+		return nil, fmt.Errorf("internal error: failed to delete assignment rule")
+	}
+	return utils.ConvertAssignmentRule(&rule), nil
+}
 
-	fmt.Println("User from JWT: ", jwtClaims)
-	// Validate LeadAssignedTo exists
-	var assignedToUser models.User
-	if err := initializers.DB.First(&assignedToUser, "id = ?", input.LeadAssignedTo).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("assigned user not found")
-		}
+// AddDealLineItem is the resolver for the addDealLineItem field.
+func (r *mutationResolver) AddDealLineItem(ctx context.Context, dealID string, input generated.DealLineItemInput) (*generated.DealLineItem, error) {
+	deal, err := utils.FindEditableDeal(ctx, dealID)
+	if err != nil {
 		return nil, err
 	}
+	if deal.DealStatus.IsClosed() {
+		return nil, errors.New("closed deals cannot be updated")
+	}
 
-	// Validate Organization exists
-	var organization models.Organization
-	if err := initializers.DB.First(&organization, "id = ?", input.OrganizationID).Error; err != nil {
-
-		fmt.Println("Organization id ", input.OrganizationID)
-
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("organization not found")
+	var item models.DealLineItem
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.BuildDealLineItem(tx, deal, input, &item); err != nil {
+			return err
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			log.Printf("Error creating deal line item: %v", err)
+			return fmt.Errorf("internal error: failed to add deal line item")
 		}
+		if err := utils.RecalculateDealAmount(tx, deal); err != nil {
+			log.Printf("Error updating deal amount: %v", err)
+			return fmt.Errorf("internal error: failed to add deal line item")
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	return utils.ConvertDealLineItem(&item, deal.DealCurrency), nil
+}
 
-	// Validate Campaign exists
-	var campaign models.Campaign
-	if err := initializers.DB.First(&campaign, "id = ?", input.CampaignID).Error; err != nil {
-		fmt.Println("Campaign id ", input.CampaignID)
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("campaign not found")
-		}
+// UpdateDealLineItem is the resolver for the updateDealLineItem field.
+func (r *mutationResolver) UpdateDealLineItem(ctx context.Context, id string, input generated.DealLineItemInput) (*generated.DealLineItem, error) {
+	item, deal, err := utils.FindEditableDealLineItem(ctx, id)
+	if err != nil {
 		return nil, err
 	}
-	userID, ok := jwtClaims["user_id"].(string)
-	fmt.Println("User ID: ", userID)
-	if !ok {
-		return nil, fmt.Errorf("failed to extract user ID from JWT")
+	if deal.DealStatus.IsClosed() {
+		return nil, errors.New("closed deals cannot be updated")
 	}
-	// Create new lead instance
-	newLead := models.Lead{
-		LeadID:             uuid.NewString(),
-		FirstName:          input.Firstname,
-		LastName:           input.Lastname,
-		Email:              input.Email,
-		LinkedIn:           input.LinkedIn,
-		Country:            input.Country,
-		Phone:              input.Phone,
-		LeadSource:         input.LeadSource,
-		InitialContactDate: input.InitialContactDate,
-		LeadCreatedBy:      userID,
-		LeadAssignedTo:     input.LeadAssignedTo,
-		LeadStage:          input.LeadStage.String(),
-		LeadNotes:          input.LeadNotes,
-		LeadPriority:       input.LeadPriority.String(),
-		OrganizationID:     input.OrganizationID,
-		CampaignID:         input.CampaignID,
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.BuildDealLineItem(tx, deal, input, item); err != nil {
+			return err
+		}
+		if err := tx.Omit("Skills", "ResourceProfile").Save(item).Error; err != nil {
+			log.Printf("Error updating deal line item: %v", err)
+			return fmt.Errorf("internal error: failed to update deal line item")
+		}
+		if err := tx.Model(item).Association("Skills").Replace(item.Skills); err != nil {
+			log.Printf("Error updating deal line item skills: %v", err)
+			return fmt.Errorf("internal error: failed to update deal line item")
+		}
+		if err := utils.RecalculateDealAmount(tx, deal); err != nil {
+			log.Printf("Error updating deal amount: %v", err)
+			return fmt.Errorf("internal error: failed to update deal line item")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return utils.ConvertDealLineItem(item, deal.DealCurrency), nil
+}
 
-	// Create new activity instance
-	newActivity := models.Activity{
-		ActivityID:           uuid.NewString(),
-		LeadID:               newLead.LeadID, // Associate the activity with the lead
-		ActivityType:         input.ActivityType,
-		DateTime:             input.DateTime,
-		CommunicationChannel: input.CommunicationChannel,
-		ContentNotes:         input.ContentNotes,
-		ParticipantDetails:   input.ParticipantDetails,
-		FollowUpActions:      input.FollowUpActions,
+// RemoveDealLineItem is the resolver for the removeDealLineItem field.
+func (r *mutationResolver) RemoveDealLineItem(ctx context.Context, id string) (*generated.DealLineItem, error) {
+	item, deal, err := utils.FindEditableDealLineItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if deal.DealStatus.IsClosed() {
+		return nil, errors.New("closed deals cannot be updated")
 	}
 
-	// Use a transaction to ensure both Lead and Activity are created successfully
-	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(&newLead).Error; err != nil {
-			log.Printf("Error creating lead: %v", err)
-			return fmt.Errorf("internal error: failed to create lead")
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(item).Error; err != nil {
+			log.Printf("Error deleting deal line item: %v", err)
+			return fmt.Errorf("internal error: failed to remove deal line item")
 		}
-
-		if err := tx.Create(&newActivity).Error; err != nil {
-			log.Printf("Error creating activity: %v", err)
-			return fmt.Errorf("internal error: failed to create activity")
+		if err := utils.RecalculateDealAmount(tx, deal); err != nil {
+			log.Printf("Error updating deal amount: %v", err)
+			return fmt.Errorf("internal error: failed to remove deal line item")
 		}
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
-
-	// Return the created lead and its associated activity
-	return &generated.Lead{
-		LeadID:             newLead.LeadID,
-		FirstName:          newLead.FirstName,
-		LastName:           newLead.LastName,
-		Email:              newLead.Email,
-		LinkedIn:           newLead.LinkedIn,
-		Country:            newLead.Country,
-		Phone:              newLead.Phone,
-		LeadSource:         newLead.LeadSource,
-		InitialContactDate: newLead.InitialContactDate,
-		LeadCreatedBy: &generated.User{
-			UserID: userID,
-		},
-		LeadAssignedTo: &generated.User{
-			UserID: fmt.Sprintf("%d", assignedToUser.ID),
-			Name:   assignedToUser.Name,
-			Email:  assignedToUser.Email,
-		},
-		LeadStage:    newLead.LeadStage,
-		LeadNotes:    newLead.LeadNotes,
-		LeadPriority: newLead.LeadPriority,
-		Organization: &generated.Organization{
-			ID:               fmt.Sprintf("%d", organization.ID),
-			OrganizationName: organization.OrganizationName,
-		},
-		Campaign: &generated.Campaign{
-			CampaignID:   fmt.Sprintf("%d", campaign.ID),
-			CampaignName: campaign.CampaignName,
-		},
-		Activities: []*generated.Activity{
-			{
-				ActivityID:           newActivity.ActivityID,
-				LeadID:               newActivity.LeadID,
-				ActivityType:         newActivity.ActivityType,
-				DateTime:             newActivity.DateTime,
-				CommunicationChannel: newActivity.CommunicationChannel,
-				ContentNotes:         newActivity.ContentNotes,
-				ParticipantDetails:   newActivity.ParticipantDetails,
-				FollowUpActions:      newActivity.FollowUpActions,
-			},
-		},
-	}, nil
+	return utils.ConvertDealLineItem(item, deal.DealCurrency), nil
 }
 
-// CreateDeal is the resolver for the createDeal field.
-func (r *mutationResolver) CreateDeal(ctx context.Context, input generated.CreateDealInput) (*generated.Deal, error) {
-	// panic(fmt.Errorf("not implemented: CreateDeal - createDeal"))
+// SetDealStageProbability is the resolver for the setDealStageProbability field.
+func (r *mutationResolver) SetDealStageProbability(ctx context.Context, dealStatus generated.DealStatus, winProbability int32) (*generated.DealStageProbability, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	var actor models.User
+	if err := initializers.DB.First(&actor, "id = ?", claims["user_id"]).Error; err != nil {
+		return nil, fmt.Errorf("unauthorized")
+	}
 
-	// Create new deal
-	newDeal := models.Deals{
-		LeadID:        input.LeadID,
-		DealName:      input.DealName,
-		DealAmount:    input.DealAmount,
-		DealStartDate: input.DealStartDate,
-		DealEndDate:   input.DealEndDate,
-		DealStatus:    input.DealStatus.String(),
+	status := models.DealStatus(dealStatus)
+	if status.IsClosed() {
+		return nil, errors.New("closed deals are not forecast")
 	}
-	if err := initializers.DB.Create(&newDeal).Error; err != nil {
-		log.Printf("Error creating deal: %v", err)
-		return nil, fmt.Errorf("internal error: failed to create deal")
+	if winProbability < 0 || winProbability > 100 {
+		return nil, errors.New("winProbability must be between 0 and 100")
 	}
-	return &generated.Deal{
-		DealID:     fmt.Sprintf("%d", newDeal.ID),
-		LeadID:     newDeal.LeadID,
-		DealName:   newDeal.DealName,
-		DealAmount: newDeal.DealAmount,
-		DealStatus: newDeal.DealStatus,
-	}, nil
+
+	probability := models.DealStageProbability{
+		DealStatus:  status,
+		Probability: int(winProbability),
+		UpdatedByID: &actor.ID,
+	}
+	if err := initializers.DB.Save(&probability).Error; err != nil {
+		log.Printf("Error saving win probability: %v", err)
+		return nil, fmt.Errorf("internal error: failed to update win probability")
+	}
+	return utils.ConvertDealStageProbability(&probability), nil
 }
 
 // CreateActivity is the resolver for the createActivity field.
@@ -1270,6 +2557,61 @@ func (r *mutationResolver) DeleteCaseStudy(ctx context.Context, caseStudyID stri
 	}, nil
 }
 
+// Parent is the resolver for the parent field.
+func (r *organizationResolver) Parent(ctx context.Context, obj *generated.Organization) (*generated.Organization, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+	parent, err := utils.FindOrganization(initializers.DB, *obj.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertOrganization(parent), nil
+}
+
+// Subsidiaries is the resolver for the subsidiaries field.
+func (r *organizationResolver) Subsidiaries(ctx context.Context, obj *generated.Organization) ([]*generated.Organization, error) {
+	var subsidiaries []models.Organization
+	if err := initializers.DB.Where("parent_id = ?", obj.ID).Order("organization_name").Find(&subsidiaries).Error; err != nil {
+		log.Printf("Error fetching subsidiaries of organization %s: %v", obj.ID, err)
+		return nil, fmt.Errorf("internal error: failed to fetch subsidiaries")
+	}
+	result := make([]*generated.Organization, 0, len(subsidiaries))
+	for i := range subsidiaries {
+		result = append(result, utils.ConvertOrganization(&subsidiaries[i]))
+	}
+	return result, nil
+}
+
+// Leads is the resolver for the leads field.
+func (r *organizationResolver) Leads(ctx context.Context, obj *generated.Organization) ([]*generated.Lead, error) {
+	query, err := utils.LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var leads []models.Lead
+	if err := utils.PreloadLead(query).Where("leads.organization_id = ?", obj.ID).
+		Order("leads.created_at DESC").Find(&leads).Error; err != nil {
+		log.Printf("Error fetching leads of organization %s: %v", obj.ID, err)
+		return nil, fmt.Errorf("internal error: failed to fetch leads")
+	}
+	result := make([]*generated.Lead, 0, len(leads))
+	for i := range leads {
+		result = append(result, utils.ConvertLead(&leads[i]))
+	}
+	return result, nil
+}
+
+// Rollup is the resolver for the rollup field.
+func (r *organizationResolver) Rollup(ctx context.Context, obj *generated.Organization) (*generated.OrganizationRollup, error) {
+	rollup, err := utils.OrganizationRollup(ctx, obj.ID)
+	if err != nil {
+		log.Printf("Error computing rollup of organization %s: %v", obj.ID, err)
+		return nil, fmt.Errorf("internal error: failed to compute organization rollup")
+	}
+	return rollup, nil
+}
+
 // GetUsers is the resolver for the getUsers field.
 func (r *queryResolver) GetUsers(ctx context.Context, filter *generated.UserFilter, pagination *generated.PaginationInput, sort *generated.UserSortInput) (*generated.UserPage, error) {
 	log.Println("GetUsers called")
@@ -1334,13 +2676,7 @@ func (r *queryResolver) GetUsers(ctx context.Context, filter *generated.UserFilt
 	for _, c := range users {
 		var campaigns []*generated.Campaign
 		for _, u := range c.Campaigns {
-			campaigns = append(campaigns, &generated.Campaign{
-				CampaignID:       fmt.Sprintf("%d", u.ID),
-				CampaignName:     u.CampaignName,
-				CampaignCountry:  u.CampaignCountry,
-				CampaignRegion:   u.CampaignRegion,
-				IndustryTargeted: u.IndustryTargeted,
-			})
+			campaigns = append(campaigns, utils.ConvertCampaign(&u))
 		}
 		result = append(result, &generated.User{
 			UserID:    fmt.Sprintf("%d", c.ID),
@@ -1349,7 +2685,6 @@ func (r *queryResolver) GetUsers(ctx context.Context, filter *generated.UserFilt
 			Email:     c.Email,
 			Phone:     c.Phone,
 			Role:      c.Role,
-			Password:  c.Password,
 			Campaigns: campaigns,
 		})
 	}
@@ -1371,13 +2706,7 @@ func (r *queryResolver) GetUser(ctx context.Context, userID string) (*generated.
 	// Map campaigns
 	var campaigns []*generated.Campaign
 	for _, c := range user.Campaigns {
-		campaigns = append(campaigns, &generated.Campaign{
-			CampaignID:       fmt.Sprintf("%d", c.ID),
-			CampaignName:     c.CampaignName,
-			CampaignCountry:  c.CampaignCountry,
-			CampaignRegion:   c.CampaignRegion,
-			IndustryTargeted: c.IndustryTargeted,
-		})
+		campaigns = append(campaigns, utils.ConvertCampaign(&c))
 	}
 
 	// Map the user to the GraphQL response type
@@ -1388,17 +2717,17 @@ func (r *queryResolver) GetUser(ctx context.Context, userID string) (*generated.
 		Email:     user.Email,
 		Phone:     user.Phone,
 		Role:      user.Role,
-		Password:  user.Password,
 		Campaigns: campaigns, // Include campaigns in response
 	}, nil
 }
 
 // GetCampaigns is the resolver for the getCampaigns field.
 func (r *queryResolver) GetCampaigns(ctx context.Context, filter *generated.CampaignFilter, pagination *generated.PaginationInput, sort *generated.CampaignSortInput) (*generated.CampaignPage, error) {
-	log.Println("GetCampaigns called")
-
 	var campaigns []models.Campaign
-	query := initializers.DB.Model(&models.Campaign{})
+	query, err := utils.CampaignScope(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// --- Apply Filters ---
 	if filter != nil {
@@ -1408,6 +2737,9 @@ func (r *queryResolver) GetCampaigns(ctx context.Context, filter *generated.Camp
 		if filter.CampaignCountry != nil && *filter.CampaignCountry != "" {
 			query = query.Where("campaigns.campaign_country = ?", *filter.CampaignCountry)
 		}
+		if filter.Status != nil {
+			query = query.Where("campaigns.status = ?", *filter.Status)
+		}
 	}
 
 	// --- Apply Sorting ---
@@ -1434,19 +2766,15 @@ func (r *queryResolver) GetCampaigns(ctx context.Context, filter *generated.Camp
 	}
 
 	// Execute the query
-	if err := query.Find(&campaigns).Error; err != nil {
+	if err := utils.PreloadCampaign(query).Find(&campaigns).Error; err != nil {
 		log.Printf("Error fetching campaigns: %v", err)
 		return nil, fmt.Errorf("internal error: failed to fetch campaigns")
 	}
 
 	// Map to GraphQL response type
 	var result []*generated.Campaign
-	for _, c := range campaigns {
-		result = append(result, &generated.Campaign{
-			CampaignID:      fmt.Sprintf("%d", c.ID),
-			CampaignName:    c.CampaignName,
-			CampaignCountry: c.CampaignCountry,
-		})
+	for i := range campaigns {
+		result = append(result, utils.ConvertCampaign(&campaigns[i]))
 	}
 
 	return &generated.CampaignPage{
@@ -1457,23 +2785,171 @@ func (r *queryResolver) GetCampaigns(ctx context.Context, filter *generated.Camp
 
 // GetCampaign is the resolver for the getCampaign field.
 func (r *queryResolver) GetCampaign(ctx context.Context, campaignID string) (*generated.Campaign, error) {
-	panic(fmt.Errorf("not implemented: GetCampaign - getCampaign"))
+	campaign, err := utils.FindScopedCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertCampaign(campaign), nil
 }
 
 // GetAllLeads is the resolver for the getAllLeads field.
 func (r *queryResolver) GetAllLeads(ctx context.Context, filter *generated.LeadFilter, pagination *generated.PaginationInput, sort *generated.LeadSortInput) (*generated.LeadPage, error) {
 	log.Println("GetAllLeads called")
 
-	var leads []models.Lead
-	query := initializers.DB.Model(&models.Lead{})
+	var leads []models.Lead
+	query, err := utils.LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- Apply Filters ---
+	if filter != nil {
+		if filter.Name != nil && *filter.Name != "" {
+			query = query.Where("leads.name ILIKE ?", "%"+*filter.Name+"%")
+		}
+		if filter.Email != nil && *filter.Email != "" {
+			query = query.Where("leads.email ILIKE ?", "%"+*filter.Email+"%")
+		}
+	}
+
+	// --- Apply Sorting ---
+	if sort != nil {
+		order := "ASC"
+		if sort.Order == generated.SortOrderDesc {
+			order = "DESC"
+		}
+		switch sort.Field {
+		case generated.LeadSortFieldLeadName:
+			query = query.Order("leads.name " + order)
+		case generated.LeadSortFieldCreatedAt:
+			query = query.Order("leads.created_at " + order)
+		}
+	}
+
+	// --- Apply Pagination ---
+	var totalCount int64
+	query.Count(&totalCount) // Get total count before applying pagination
+
+	if pagination != nil {
+		offset := (pagination.Page - 1) * pagination.PageSize
+		query = query.Offset(int(offset)).Limit(int(pagination.PageSize))
+	}
+
+	// Execute the query
+	if err := utils.PreloadLead(query).Find(&leads).Error; err != nil {
+		log.Printf("Error fetching leads: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch leads")
+	}
+
+	// Map to GraphQL response type
+	var result []*generated.Lead
+	for i := range leads {
+		result = append(result, utils.ConvertLead(&leads[i]))
+	}
+
+	return &generated.LeadPage{
+		Items:      result,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// GetOneLead is the resolver for the getOneLead field.
+func (r *queryResolver) GetOneLead(ctx context.Context, leadID string) (*generated.Lead, error) {
+	// Find the lead by ID, limited to the leads the caller may see
+	lead, err := utils.FindScopedLead(ctx, leadID, utils.LeadPreloads...)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertLead(lead), nil
+}
+
+// GetLeadStageHistory is the resolver for the getLeadStageHistory field.
+func (r *queryResolver) GetLeadStageHistory(ctx context.Context, leadID string) ([]*generated.LeadStageChange, error) {
+	// Only the history of leads the caller may see is returned
+	if _, err := utils.FindScopedLead(ctx, leadID); err != nil {
+		return nil, err
+	}
+
+	var history []models.LeadStageHistory
+	if err := initializers.DB.Preload("ChangedBy").Where("lead_id = ?", leadID).
+		Order("changed_at ASC").Find(&history).Error; err != nil {
+		log.Printf("Error fetching stage history of lead %s: %v", leadID, err)
+		return nil, fmt.Errorf("internal error: failed to fetch lead stage history")
+	}
+
+	// A stage is left when the next one is entered, the current stage is measured until now
+	result := make([]*generated.LeadStageChange, 0, len(history))
+	for i, entry := range history {
+		change := &generated.LeadStageChange{
+			ID:        entry.ID.String(),
+			ToStage:   generated.LeadStage(entry.ToStage),
+			ChangedBy: utils.ConvertUserSummary(entry.ChangedBy),
+			ChangedAt: entry.ChangedAt.Format(time.RFC3339),
+		}
+		if entry.FromStage != nil {
+			from := generated.LeadStage(*entry.FromStage)
+			change.FromStage = &from
+		}
+		if entry.Reason != "" {
+			change.Reason = &entry.Reason
+		}
+		leftAt := time.Now()
+		if i+1 < len(history) {
+			leftAt = history[i+1].ChangedAt
+			formatted := leftAt.Format(time.RFC3339)
+			change.LeftAt = &formatted
+		}
+		change.SecondsInStage = int32(leftAt.Sub(entry.ChangedAt).Seconds())
+		result = append(result, change)
+	}
+	return result, nil
+}
+
+// GetLeadPipeline is the resolver for the getLeadPipeline field.
+func (r *queryResolver) GetLeadPipeline(ctx context.Context) ([]*generated.LeadStageRule, error) {
+	pipeline, err := utils.LoadLeadPipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*generated.LeadStageRule, 0, len(generated.AllLeadStage))
+	for _, stage := range generated.AllLeadStage {
+		rule := pipeline.Stages[models.LeadStage(stage)]
+		transitions := make([]generated.LeadStage, 0, len(rule.Transitions))
+		for _, to := range rule.Transitions {
+			transitions = append(transitions, generated.LeadStage(to))
+		}
+		requires := rule.Requires
+		if requires == nil {
+			requires = []string{}
+		}
+		result = append(result, &generated.LeadStageRule{
+			Stage:       stage,
+			Initial:     pipeline.IsInitial(models.LeadStage(stage)),
+			Transitions: transitions,
+			Requires:    requires,
+		})
+	}
+	return result, nil
+}
+
+// GetDeals is the resolver for the getDeals field.
+func (r *queryResolver) GetDeals(ctx context.Context, filter *generated.DealFilter, pagination *generated.PaginationInput, sort *generated.DealSortInput) (*generated.DealPage, error) {
+	query, err := utils.DealScope(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// --- Apply Filters ---
 	if filter != nil {
-		if filter.Name != nil && *filter.Name != "" {
-			query = query.Where("leads.name ILIKE ?", "%"+*filter.Name+"%")
+		if filter.DealName != nil && *filter.DealName != "" {
+			query = query.Where("deals.deal_name ILIKE ?", "%"+*filter.DealName+"%")
 		}
-		if filter.Email != nil && *filter.Email != "" {
-			query = query.Where("leads.email ILIKE ?", "%"+*filter.Email+"%")
+		if filter.LeadID != nil && *filter.LeadID != "" {
+			query = query.Where("deals.lead_id = ?", *filter.LeadID)
+		}
+		if filter.DealStatus != nil {
+			query = query.Where("deals.deal_status = ?", filter.DealStatus.String())
 		}
 	}
 
@@ -1484,10 +2960,14 @@ func (r *queryResolver) GetAllLeads(ctx context.Context, filter *generated.LeadF
 			order = "DESC"
 		}
 		switch sort.Field {
-		case generated.LeadSortFieldLeadName:
-			query = query.Order("leads.name " + order)
-		case generated.LeadSortFieldCreatedAt:
-			query = query.Order("leads.created_at " + order)
+		case generated.DealSortFieldDealName:
+			query = query.Order("deals.deal_name " + order)
+		case generated.DealSortFieldDealStartDate:
+			query = query.Order("deals.deal_start_date " + order)
+		case generated.DealSortFieldDealEndDate:
+			query = query.Order("deals.deal_end_date " + order)
+		case generated.DealSortFieldCreatedAt:
+			query = query.Order("deals.created_at " + order)
 		}
 	}
 
@@ -1500,96 +2980,569 @@ func (r *queryResolver) GetAllLeads(ctx context.Context, filter *generated.LeadF
 		query = query.Offset(int(offset)).Limit(int(pagination.PageSize))
 	}
 
-	// Execute the query
-	if err := query.Find(&leads).Error; err != nil {
-		log.Printf("Error fetching leads: %v", err)
-		return nil, fmt.Errorf("internal error: failed to fetch leads")
+	var deals []models.Deals
+	if err := query.Find(&deals).Error; err != nil {
+		log.Printf("Error fetching deals: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch deals")
 	}
 
-	// Map to GraphQL response type
-	var result []*generated.Lead
-	for _, c := range leads {
-		result = append(result, &generated.Lead{
-			LeadID:    c.LeadID,
-			FirstName: c.FirstName,
-			Email:     c.Email,
+	return &generated.DealPage{
+		Items:      utils.ConvertDeals(deals),
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// GetDeal is the resolver for the getDeal field.
+func (r *queryResolver) GetDeal(ctx context.Context, dealID string) (*generated.Deal, error) {
+	deal, err := utils.FindScopedDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertDeal(deal), nil
+}
+
+// GetQuotes is the resolver for the getQuotes field.
+func (r *queryResolver) GetQuotes(ctx context.Context, dealID string) ([]*generated.Quote, error) {
+	deal, err := utils.FindScopedDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	var quotes []models.Quote
+	if err := initializers.DB.Preload("Template").Preload("CreatedBy").Omit("html", "pdf").
+		Where("deal_id = ?", deal.ID).Order("version DESC").Find(&quotes).Error; err != nil {
+		log.Printf("Error fetching quotes: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch quotes")
+	}
+	result := make([]*generated.Quote, 0, len(quotes))
+	for i := range quotes {
+		result = append(result, utils.ConvertQuote(&quotes[i]))
+	}
+	return result, nil
+}
+
+// GetQuote is the resolver for the getQuote field.
+func (r *queryResolver) GetQuote(ctx context.Context, id string) (*generated.Quote, error) {
+	q, err := utils.FindScopedQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertQuote(q), nil
+}
+
+// GetQuoteTemplates is the resolver for the getQuoteTemplates field.
+func (r *queryResolver) GetQuoteTemplates(ctx context.Context) ([]*generated.QuoteTemplate, error) {
+	var templates []models.QuoteTemplate
+	if err := initializers.DB.Order("name").Find(&templates).Error; err != nil {
+		log.Printf("Error fetching quote templates: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch quote templates")
+	}
+	result := make([]*generated.QuoteTemplate, 0, len(templates))
+	for i := range templates {
+		result = append(result, utils.ConvertQuoteTemplate(&templates[i]))
+	}
+	return result, nil
+}
+
+// GetAssignmentRules is the resolver for the getAssignmentRules field.
+func (r *queryResolver) GetAssignmentRules(ctx context.Context) ([]*generated.AssignmentRule, error) {
+	// Listed in the order they are evaluated
+	var rules []models.AssignmentRule
+	if err := initializers.DB.Order("priority, created_at").Find(&rules).Error; err != nil {
+		log.Printf("Error fetching assignment rules: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch assignment rules")
+	}
+	result := make([]*generated.AssignmentRule, 0, len(rules))
+	for i := range rules {
+		result = append(result, utils.ConvertAssignmentRule(&rules[i]))
+	}
+	return result, nil
+}
+
+// LeadFunnelReport is the resolver for the leadFunnelReport field.
+func (r *queryResolver) LeadFunnelReport(ctx context.Context, campaignID *string, period *generated.DateRangeInput, assigneeID *string, organizationID *string) (*generated.LeadFunnelReport, error) {
+	start, end, err := utils.ReportPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leads the caller may see, narrowed by the filters
+	scoped, err := utils.LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if campaignID != nil {
+		scoped = scoped.Where("leads.campaign_id = ?", *campaignID)
+	}
+	if assigneeID != nil {
+		scoped = scoped.Where("leads.lead_assigned_to = ?", *assigneeID)
+	}
+	if organizationID != nil {
+		scoped = scoped.Where("leads.organization_id = ?", *organizationID)
+	}
+	scoped = scoped.Select("leads.lead_id, leads.lead_stage")
+
+	// Every history entry is a stay in a stage that lasts until the next entry of the lead.
+	// The window runs over the whole history so stays that started in the period are
+	// measured even when they ended after it.
+	const staysCTE = `WITH scoped AS (?),
+	stays AS (
+		SELECT h.lead_id, h.from_stage, h.to_stage AS stage, h.changed_at AS entered_at,
+			LEAD(h.changed_at) OVER (PARTITION BY h.lead_id ORDER BY h.changed_at) AS left_at,
+			LEAD(h.to_stage) OVER (PARTITION BY h.lead_id ORDER BY h.changed_at) AS next_stage
+		FROM lead_stage_histories h
+		JOIN scoped ON scoped.lead_id = h.lead_id
+		WHERE h.deleted_at IS NULL
+	)`
+
+	var stageRows []struct {
+		Stage         string
+		Entered       int64
+		MedianSeconds *float64
+	}
+	if err := initializers.DB.Raw(staysCTE+`
+		SELECT stage, COUNT(DISTINCT lead_id) AS entered,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM left_at - entered_at))
+				FILTER (WHERE left_at IS NOT NULL) AS median_seconds
+		FROM stays
+		WHERE entered_at >= ? AND entered_at < ?
+		GROUP BY stage`, scoped, start, end).Scan(&stageRows).Error; err != nil {
+		log.Printf("Error computing lead funnel: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute lead funnel")
+	}
+
+	var conversionRows []struct {
+		FromStage string
+		ToStage   string
+		Leads     int64
+	}
+	if err := initializers.DB.Raw(staysCTE+`
+		SELECT stage AS from_stage, next_stage AS to_stage, COUNT(DISTINCT lead_id) AS leads
+		FROM stays
+		WHERE entered_at >= ? AND entered_at < ? AND next_stage IS NOT NULL
+		GROUP BY stage, next_stage
+		ORDER BY stage, next_stage`, scoped, start, end).Scan(&conversionRows).Error; err != nil {
+		log.Printf("Error computing lead conversions: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute lead funnel")
+	}
+
+	var leadsCreated int64
+	if err := initializers.DB.Raw(staysCTE+`
+		SELECT COUNT(DISTINCT lead_id) FROM stays
+		WHERE from_stage IS NULL AND entered_at >= ? AND entered_at < ?`, scoped, start, end).Scan(&leadsCreated).Error; err != nil {
+		log.Printf("Error counting created leads: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute lead funnel")
+	}
+
+	var currentRows []struct {
+		LeadStage string
+		Count     int64
+	}
+	if err := initializers.DB.Table("(?) AS scoped", scoped).
+		Select("lead_stage, COUNT(*) AS count").Group("lead_stage").Scan(&currentRows).Error; err != nil {
+		log.Printf("Error counting leads per stage: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute lead funnel")
+	}
+
+	// Every stage is listed, in pipeline order, even without leads
+	entered := map[string]int64{}
+	stages := make([]*generated.LeadFunnelStage, 0, len(generated.AllLeadStage))
+	byStage := map[string]*generated.LeadFunnelStage{}
+	for _, stage := range generated.AllLeadStage {
+		item := &generated.LeadFunnelStage{Stage: stage}
+		stages = append(stages, item)
+		byStage[stage.String()] = item
+	}
+	for _, row := range stageRows {
+		if item, ok := byStage[row.Stage]; ok {
+			item.Entered = int32(row.Entered)
+			item.MedianSecondsInStage = row.MedianSeconds
+			entered[row.Stage] = row.Entered
+		}
+	}
+	for _, row := range currentRows {
+		if item, ok := byStage[row.LeadStage]; ok {
+			item.Current = int32(row.Count)
+		}
+	}
+
+	conversions := make([]*generated.LeadStageConversion, 0, len(conversionRows))
+	for _, row := range conversionRows {
+		rate := 0.0
+		if entered[row.FromStage] > 0 {
+			rate = float64(row.Leads) / float64(entered[row.FromStage])
+		}
+		conversions = append(conversions, &generated.LeadStageConversion{
+			FromStage: generated.LeadStage(row.FromStage),
+			ToStage:   generated.LeadStage(row.ToStage),
+			Leads:     int32(row.Leads),
+			Rate:      rate,
 		})
 	}
 
-	return &generated.LeadPage{
-		Items:      result,
-		TotalCount: int32(totalCount),
+	return &generated.LeadFunnelReport{
+		From:         start,
+		To:           end.AddDate(0, 0, -1),
+		LeadsCreated: int32(leadsCreated),
+		Stages:       stages,
+		Conversions:  conversions,
 	}, nil
 }
 
-// GetOneLead is the resolver for the getOneLead field.
-func (r *queryResolver) GetOneLead(ctx context.Context, leadID string) (*generated.Lead, error) {
-	role, _ := auth.GetUserRoleFromJWT(ctx)
-	if role == "" {
-		fmt.Println("Role is empty")
-		return nil, fmt.Errorf("missing token")
+// DealValueReport is the resolver for the dealValueReport field.
+func (r *queryResolver) DealValueReport(ctx context.Context, baseCurrency string, period *generated.DateRangeInput, campaignID *string, dealStatus *generated.DealStatus, groupBy *generated.DealValueGroupBy) (*generated.DealValueReport, error) {
+	base := strings.ToUpper(strings.TrimSpace(baseCurrency))
+	if err := scalars.ValidateCurrency(base); err != nil {
+		return nil, err
+	}
+	start, end, err := utils.ReportPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	grouping := generated.DealValueGroupByCurrency
+	if groupBy != nil {
+		grouping = *groupBy
 	}
 
-	// Find the lead by ID
-	var lead models.Lead
-	if err := initializers.DB.Preload("Activities").Preload("Organization").Preload("Campaign").First(&lead, "lead_id = ?", leadID).Error; err != nil {
+	query, err := utils.DealScope(ctx)
+	if err != nil {
 		return nil, err
 	}
+	const dealDate = "COALESCE(deals.deal_start_date::timestamptz, deals.created_at)"
+	query = query.
+		Joins("LEFT JOIN leads ON leads.lead_id = deals.lead_id").
+		Joins("LEFT JOIN campaigns ON campaigns.id::text = leads.campaign_id AND campaigns.deleted_at IS NULL").
+		Where(dealDate+" >= ? AND "+dealDate+" < ?", start, end)
+	if campaignID != nil {
+		query = query.Where("leads.campaign_id = ?", *campaignID)
+	}
+	if dealStatus != nil {
+		query = query.Where("deals.deal_status = ?", dealStatus.String())
+	}
 
-	// Map the lead to the GraphQL response type
-	var activities []*generated.Activity
-	for _, activity := range lead.Activities {
-		activities = append(activities, &generated.Activity{
-			ActivityID:           activity.ActivityID,
-			LeadID:               activity.LeadID,
-			ActivityType:         activity.ActivityType,
-			DateTime:             activity.DateTime,
-			CommunicationChannel: activity.CommunicationChannel,
-			ContentNotes:         activity.ContentNotes,
-			ParticipantDetails:   activity.ParticipantDetails,
-		})
+	var rows []struct {
+		DealAmount      decimal.Decimal
+		DealCurrency    string
+		DealStatus      string
+		DealDate        time.Time
+		CampaignID      *string
+		CampaignName    *string
+		CampaignCountry *string
 	}
+	if err := query.Select("deals.deal_amount, deals.deal_currency, deals.deal_status, " + dealDate + " AS deal_date, " +
+		"campaigns.id::text AS campaign_id, campaigns.campaign_name, campaigns.campaign_country").
+		Scan(&rows).Error; err != nil {
+		log.Printf("Error loading deal values: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute deal values")
+	}
+
+	rates, err := utils.LoadRateTable(base)
+	if err != nil {
+		log.Printf("Error loading exchange rates: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute deal values")
+	}
+
+	report := &generated.DealValueReport{
+		BaseCurrency: base,
+		From:         start,
+		To:           end.AddDate(0, 0, -1),
+		Total:        scalars.Money{Currency: base},
+		Groups:       []*generated.DealValueGroup{},
+		Unconverted:  []*generated.UnconvertedDealValue{},
+	}
+	groups := map[string]*generated.DealValueGroup{}
+	unconverted := map[string]*generated.UnconvertedDealValue{}
+	for _, row := range rows {
+		value, ok := rates.Convert(row.DealAmount, row.DealCurrency, row.DealDate)
+		if !ok {
+			item, found := unconverted[row.DealCurrency]
+			if !found {
+				item = &generated.UnconvertedDealValue{Currency: row.DealCurrency, Total: scalars.Money{Currency: row.DealCurrency}}
+				unconverted[row.DealCurrency] = item
+				report.Unconverted = append(report.Unconverted, item)
+			}
+			item.Total.Amount = item.Total.Amount.Add(row.DealAmount)
+			item.DealCount++
+			continue
+		}
 
-	// Map Organization
-	var organization *generated.Organization
-	if lead.OrganizationID != "" {
-		organization = &generated.Organization{
-			ID:               fmt.Sprintf("%d", lead.Organization.ID),
-			OrganizationName: lead.Organization.OrganizationName,
+		var key *string
+		label := "No campaign"
+		switch grouping {
+		case generated.DealValueGroupByCurrency:
+			key, label = &row.DealCurrency, row.DealCurrency
+		case generated.DealValueGroupByStatus:
+			key, label = &row.DealStatus, row.DealStatus
+		case generated.DealValueGroupByCampaign:
+			if row.CampaignID != nil {
+				key, label = row.CampaignID, *row.CampaignName
+			}
+		case generated.DealValueGroupByCountry:
+			if row.CampaignCountry != nil {
+				key, label = row.CampaignCountry, *row.CampaignCountry
+			}
+		}
+		groupKey := ""
+		if key != nil {
+			groupKey = *key
 		}
+		group, found := groups[groupKey]
+		if !found {
+			group = &generated.DealValueGroup{Key: key, Label: label, Total: scalars.Money{Currency: base}}
+			groups[groupKey] = group
+			report.Groups = append(report.Groups, group)
+		}
+		group.Total.Amount = group.Total.Amount.Add(value)
+		group.DealCount++
+		report.Total.Amount = report.Total.Amount.Add(value)
+		report.DealCount++
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Total.Amount.GreaterThan(report.Groups[j].Total.Amount)
+	})
+	sort.Slice(report.Unconverted, func(i, j int) bool {
+		return report.Unconverted[i].Currency < report.Unconverted[j].Currency
+	})
+	return report, nil
+}
+
+// GetExchangeRates is the resolver for the getExchangeRates field.
+func (r *queryResolver) GetExchangeRates(ctx context.Context, fromCurrency *string, toCurrency *string) ([]*generated.ExchangeRate, error) {
+	query := initializers.DB.Preload("UpdatedBy").Order("from_currency, to_currency, effective_date DESC")
+	if fromCurrency != nil {
+		query = query.Where("from_currency = ?", strings.ToUpper(*fromCurrency))
+	}
+	if toCurrency != nil {
+		query = query.Where("to_currency = ?", strings.ToUpper(*toCurrency))
 	}
 
-	// Map Campaign
-	var campaign *generated.Campaign
-	if lead.CampaignID != "" {
-		campaign = &generated.Campaign{
-			CampaignID:       fmt.Sprintf("%d", lead.Campaign.ID),
-			CampaignName:     lead.Campaign.CampaignName,
-			CampaignCountry:  lead.Campaign.CampaignCountry,
-			CampaignRegion:   lead.Campaign.CampaignRegion,
-			IndustryTargeted: lead.Campaign.IndustryTargeted,
+	var rates []models.ExchangeRate
+	if err := query.Find(&rates).Error; err != nil {
+		log.Printf("Error fetching exchange rates: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch exchange rates")
+	}
+	result := make([]*generated.ExchangeRate, 0, len(rates))
+	for i := range rates {
+		result = append(result, utils.ConvertExchangeRate(&rates[i]))
+	}
+	return result, nil
+}
+
+// SalesForecast is the resolver for the salesForecast field.
+func (r *queryResolver) SalesForecast(ctx context.Context, period *generated.DateRangeInput, groupBy generated.ForecastGroupBy, baseCurrency *string) (*generated.SalesForecast, error) {
+	base := scalars.DefaultCurrency()
+	if baseCurrency != nil {
+		base = strings.ToUpper(strings.TrimSpace(*baseCurrency))
+	}
+	if err := scalars.ValidateCurrency(base); err != nil {
+		return nil, err
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start, end := today, today.AddDate(0, 0, 90)
+	if period != nil {
+		start, end = period.From, period.To
+	}
+	if end.Before(start) {
+		return nil, errors.New("period must not end before it starts")
+	}
+
+	probabilities, err := utils.WinProbabilities()
+	if err != nil {
+		log.Printf("Error fetching win probabilities: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute sales forecast")
+	}
+	weights := map[string]decimal.Decimal{}
+	for _, probability := range probabilities {
+		weights[string(probability.DealStatus)] = decimal.NewFromInt(int64(probability.Probability)).Div(decimal.NewFromInt(100))
+	}
+
+	query, err := utils.DealScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	const closeDate = "COALESCE(deals.expected_close_date, deals.deal_end_date)"
+	var rows []struct {
+		DealAmount   decimal.Decimal
+		DealCurrency string
+		DealStatus   string
+		CloseDate    time.Time
+		AssigneeID   *string
+		AssigneeName *string
+		CampaignID   *string
+		CampaignName *string
+	}
+	if err := query.
+		Joins("LEFT JOIN leads ON leads.lead_id = deals.lead_id").
+		Joins("LEFT JOIN users ON users.id::text = leads.lead_assigned_to AND users.deleted_at IS NULL").
+		Joins("LEFT JOIN campaigns ON campaigns.id::text = leads.campaign_id AND campaigns.deleted_at IS NULL").
+		Where("deals.deal_status NOT IN ?", []string{string(models.DealStatusCompleted), string(models.DealStatusCancelled)}).
+		Where(closeDate+" BETWEEN ? AND ?", start, end).
+		Select("deals.deal_amount, deals.deal_currency, deals.deal_status, " + closeDate + " AS close_date, " +
+			"users.id::text AS assignee_id, users.name AS assignee_name, campaigns.id::text AS campaign_id, campaigns.campaign_name").
+		Scan(&rows).Error; err != nil {
+		log.Printf("Error loading open deals: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute sales forecast")
+	}
+
+	rates, err := utils.LoadRateTable(base)
+	if err != nil {
+		log.Printf("Error loading exchange rates: %v", err)
+		return nil, fmt.Errorf("internal error: failed to compute sales forecast")
+	}
+
+	forecast := &generated.SalesForecast{
+		BaseCurrency:  base,
+		From:          start,
+		To:            end,
+		WeightedTotal: scalars.Money{Currency: base},
+		BestCaseTotal: scalars.Money{Currency: base},
+		Groups:        []*generated.SalesForecastGroup{},
+		Unconverted:   []*generated.UnconvertedDealValue{},
+	}
+	groups := map[string]*generated.SalesForecastGroup{}
+	unconverted := map[string]*generated.UnconvertedDealValue{}
+	for _, row := range rows {
+		value, ok := rates.Convert(row.DealAmount, row.DealCurrency, row.CloseDate)
+		if !ok {
+			item, found := unconverted[row.DealCurrency]
+			if !found {
+				item = &generated.UnconvertedDealValue{Currency: row.DealCurrency, Total: scalars.Money{Currency: row.DealCurrency}}
+				unconverted[row.DealCurrency] = item
+				forecast.Unconverted = append(forecast.Unconverted, item)
+			}
+			item.Total.Amount = item.Total.Amount.Add(row.DealAmount)
+			item.DealCount++
+			continue
+		}
+		weighted := value.Mul(weights[row.DealStatus])
+
+		var key *string
+		var label string
+		switch groupBy {
+		case generated.ForecastGroupByAssignee:
+			label = "Unassigned"
+			if row.AssigneeID != nil {
+				key, label = row.AssigneeID, *row.AssigneeName
+			}
+		case generated.ForecastGroupByCampaign:
+			label = "No campaign"
+			if row.CampaignID != nil {
+				key, label = row.CampaignID, *row.CampaignName
+			}
+		case generated.ForecastGroupByMonth:
+			month := row.CloseDate.Format("2006-01")
+			key, label = &month, month
+		}
+		groupKey := ""
+		if key != nil {
+			groupKey = *key
 		}
+		group, found := groups[groupKey]
+		if !found {
+			group = &generated.SalesForecastGroup{
+				Key:           key,
+				Label:         label,
+				WeightedTotal: scalars.Money{Currency: base},
+				BestCaseTotal: scalars.Money{Currency: base},
+			}
+			groups[groupKey] = group
+			forecast.Groups = append(forecast.Groups, group)
+		}
+		group.WeightedTotal.Amount = group.WeightedTotal.Amount.Add(weighted)
+		group.BestCaseTotal.Amount = group.BestCaseTotal.Amount.Add(value)
+		group.DealCount++
+		forecast.WeightedTotal.Amount = forecast.WeightedTotal.Amount.Add(weighted)
+		forecast.BestCaseTotal.Amount = forecast.BestCaseTotal.Amount.Add(value)
+		forecast.DealCount++
+	}
+
+	// Months are listed in order, everything else by weighted value
+	sort.Slice(forecast.Groups, func(i, j int) bool {
+		if groupBy == generated.ForecastGroupByMonth {
+			return forecast.Groups[i].Label < forecast.Groups[j].Label
+		}
+		return forecast.Groups[i].WeightedTotal.Amount.GreaterThan(forecast.Groups[j].WeightedTotal.Amount)
+	})
+	sort.Slice(forecast.Unconverted, func(i, j int) bool {
+		return forecast.Unconverted[i].Currency < forecast.Unconverted[j].Currency
+	})
+	return forecast, nil
+}
+
+// GetDealStageProbabilities is the resolver for the getDealStageProbabilities field.
+func (r *queryResolver) GetDealStageProbabilities(ctx context.Context) ([]*generated.DealStageProbability, error) {
+	probabilities, err := utils.WinProbabilities()
+	if err != nil {
+		log.Printf("Error fetching win probabilities: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch win probabilities")
 	}
+	result := make([]*generated.DealStageProbability, 0, len(probabilities))
+	for i := range probabilities {
+		result = append(result, utils.ConvertDealStageProbability(&probabilities[i]))
+	}
+	return result, nil
+}
 
-	// Map the lead to the GraphQL response type
-	return &generated.Lead{
-		LeadID:     lead.LeadID,
-		FirstName:  lead.FirstName,
-		LastName:   lead.LastName,
-		LinkedIn:   lead.LinkedIn,
-		Country:    lead.Country,
-		Phone:      lead.Phone,
-		LeadSource: lead.LeadSource,
-		// LeadCreatedBy:      lead.LeadCreatedBy,
-		// LeadAssignedTo:     lead.LeadAssignedTo,
-		LeadStage:          lead.LeadStage,
-		LeadPriority:       lead.LeadPriority,
-		LeadNotes:          lead.LeadNotes,
-		InitialContactDate: lead.InitialContactDate,
-		Activities:         activities,
-		Organization:       organization,
-		Campaign:           campaign,
-	}, nil
+// ResourceCommitments is the resolver for the resourceCommitments field.
+func (r *queryResolver) ResourceCommitments(ctx context.Context, resourceProfileID *string, resourceStatus *generated.ResourceStatus, from *time.Time, to *time.Time) ([]*generated.ResourceCommitment, error) {
+	deals, err := utils.DealScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deals = deals.Where("deals.deal_status <> ?", models.DealStatusCancelled).Select("deals.id")
+
+	query := initializers.DB.Model(&models.DealLineItem{}).
+		Joins("JOIN resource_profiles ON resource_profiles.id = deal_line_items.resource_profile_id AND resource_profiles.deleted_at IS NULL").
+		Where("deal_line_items.deal_id IN (?)", deals)
+	if resourceProfileID != nil {
+		query = query.Where("deal_line_items.resource_profile_id = ?", *resourceProfileID)
+	}
+	if resourceStatus != nil {
+		query = query.Where("resource_profiles.status = ?", resourceStatus.String())
+	}
+	// Line items without dates are treated as open ended
+	if from != nil {
+		query = query.Where("deal_line_items.end_date IS NULL OR deal_line_items.end_date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("deal_line_items.start_date IS NULL OR deal_line_items.start_date <= ?", *to)
+	}
+
+	var items []models.DealLineItem
+	if err := query.Preload("ResourceProfile.Skills").Preload("Skills").
+		Order("resource_profiles.last_name, resource_profiles.first_name, deal_line_items.start_date").
+		Find(&items).Error; err != nil {
+		log.Printf("Error fetching resource commitments: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch resource commitments")
+	}
+
+	dealIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		dealIDs = append(dealIDs, item.DealID)
+	}
+	var dealRows []models.Deals
+	if err := initializers.DB.Where("id IN ?", dealIDs).Find(&dealRows).Error; err != nil {
+		log.Printf("Error fetching committed deals: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch resource commitments")
+	}
+	dealsByID := map[uint]*models.Deals{}
+	for i := range dealRows {
+		dealsByID[dealRows[i].ID] = &dealRows[i]
+	}
+
+	result := make([]*generated.ResourceCommitment, 0, len(items))
+	for i := range items {
+		deal, ok := dealsByID[items[i].DealID]
+		if !ok {
+			continue
+		}
+		result = append(result, &generated.ResourceCommitment{
+			ResourceProfile: utils.ConvertResourceProfileSummary(items[i].ResourceProfile),
+			Deal:            utils.ConvertDeal(deal),
+			LineItem:        utils.ConvertDealLineItem(&items[i], deal.DealCurrency),
+		})
+	}
+	return result, nil
 }
 
 // Me is the resolver for the me field. To check the Connection and JWT Authentication
@@ -1616,32 +3569,95 @@ func (r *queryResolver) Me(ctx context.Context) (*generated.User, error) {
 }
 
 // GetOrganizations is the resolver for the getOrganizations field.
-func (r *queryResolver) GetOrganizations(ctx context.Context) ([]*generated.Organization, error) {
-	// Check database connection
-	if _, err := initializers.DB.DB(); err != nil {
-		log.Fatalf("Database connection error: %v", err)
-		return nil, err
+func (r *queryResolver) GetOrganizations(ctx context.Context, filter *generated.OrganizationFilter, pagination *generated.PaginationInput, sort *generated.OrganizationSortInput) (*generated.OrganizationPage, error) {
+	var organizations []models.Organization
+	var totalCount int64
+
+	db := initializers.DB.Model(&models.Organization{})
+
+	// Apply filtering
+	if filter != nil {
+		if filter.OrganizationName != nil {
+			db = db.Where("organization_name ILIKE ?", "%"+*filter.OrganizationName+"%")
+		}
+		if filter.Country != nil {
+			db = db.Where("country ILIKE ?", "%"+*filter.Country+"%")
+		}
+		if filter.City != nil {
+			db = db.Where("city ILIKE ?", "%"+*filter.City+"%")
+		}
+		if filter.ParentID != nil {
+			parent, err := utils.FindOrganization(initializers.DB, *filter.ParentID)
+			if err != nil {
+				return nil, err
+			}
+			db = db.Where("parent_id = ?", parent.ID)
+		}
+		if filter.TopLevel != nil {
+			if *filter.TopLevel {
+				db = db.Where("parent_id IS NULL")
+			} else {
+				db = db.Where("parent_id IS NOT NULL")
+			}
+		}
+		if filter.Search != nil {
+			searchPattern := "%" + *filter.Search + "%"
+			db = db.Where("organization_name ILIKE ? OR organization_email ILIKE ? OR organization_website ILIKE ?", searchPattern, searchPattern, searchPattern)
+		}
 	}
 
-	var organizations []models.Organization
+	// Apply sorting
+	if sort != nil {
+		var sortOrder string
+		if sort.Order == generated.SortOrderAsc {
+			sortOrder = "asc"
+		} else {
+			sortOrder = "desc"
+		}
+
+		switch sort.Field {
+		case generated.OrganizationSortFieldCreatedAt:
+			db = db.Order("created_at " + sortOrder)
+		case generated.OrganizationSortFieldUpdatedAt:
+			db = db.Order("updated_at " + sortOrder)
+		case generated.OrganizationSortFieldOrganizationName:
+			db = db.Order("organization_name " + sortOrder)
+		case generated.OrganizationSortFieldCountry:
+			db = db.Order("country " + sortOrder)
+		default:
+			return nil, fmt.Errorf("invalid sort field: %v", sort.Field)
+		}
+	} else {
+		//default sorting
+		db = db.Order("created_at desc")
+	}
+
+	// Count total records before applying limit/offset for pagination
+	if err := db.Count(&totalCount).Error; err != nil {
+		log.Printf("Error counting organizations: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch organizations")
+	}
+
+	// Apply pagination
+	if pagination != nil {
+		db = db.Offset(int((pagination.Page - 1) * pagination.PageSize)).Limit(int(pagination.PageSize))
+	}
 
-	// Fetch all organizations
-	if err := initializers.DB.Find(&organizations).Error; err != nil {
+	if err := db.Find(&organizations).Error; err != nil {
 		log.Printf("Error fetching organizations: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("internal error: failed to fetch organizations")
 	}
 
 	// Convert to GraphQL response type
-	var result []*generated.Organization
-	for _, org := range organizations {
-		result = append(result, &generated.Organization{
-			ID:               fmt.Sprintf("%d", org.ID),
-			OrganizationName: org.OrganizationName,
-			Country:          org.Country,
-		})
+	result := make([]*generated.Organization, 0, len(organizations))
+	for i := range organizations {
+		result = append(result, utils.ConvertOrganization(&organizations[i]))
 	}
 
-	return result, nil
+	return &generated.OrganizationPage{
+		Items:      result,
+		TotalCount: int32(totalCount),
+	}, nil
 }
 
 // GetOrganizationByID is the resolver for the getOrganizationByID field.
@@ -1661,11 +3677,7 @@ func (r *queryResolver) GetOrganizationByID(ctx context.Context, id string) (*ge
 	}
 
 	// Convert to GraphQL response type
-	return &generated.Organization{
-		ID:               fmt.Sprintf("%d", organization.ID),
-		OrganizationName: organization.OrganizationName,
-		Country:          organization.Country,
-	}, nil
+	return utils.ConvertOrganization(&organization), nil
 }
 
 // GetResourceProfiles is the resolver for the getResourceProfiles field.
@@ -2006,6 +4018,106 @@ func (r *queryResolver) GetVendor(ctx context.Context, id string) (*generated.Ve
 	}, nil
 }
 
+// GetLockoutEvents is the resolver for the getLockoutEvents field.
+func (r *queryResolver) GetLockoutEvents(ctx context.Context, pagination *generated.PaginationInput) (*generated.LockoutEventPage, error) {
+	var events []models.LockoutEvent
+	var totalCount int64
+
+	db := initializers.DB.Model(&models.LockoutEvent{})
+	db.Count(&totalCount)
+
+	// Apply pagination
+	if pagination != nil {
+		db = db.Offset(int((pagination.Page - 1) * pagination.PageSize)).Limit(int(pagination.PageSize))
+	}
+
+	if err := db.Preload("User").Preload("Actor").Order("created_at desc").Find(&events).Error; err != nil {
+		log.Printf("Error fetching lockout events: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch lockout events")
+	}
+
+	result := make([]*generated.LockoutEvent, len(events))
+	for i, event := range events {
+		result[i] = &generated.LockoutEvent{
+			ID:         event.ID.String(),
+			Kind:       generated.LockoutEventKind(event.Kind),
+			Scope:      generated.LockoutScope(event.Scope),
+			Identifier: event.Identifier,
+			User:       utils.ConvertUserSummary(event.User),
+			IPAddress: func() *string {
+				if event.IPAddress == "" {
+					return nil
+				}
+				return &event.IPAddress
+			}(),
+			Failures: int32(event.Failures),
+			LockedUntil: func() *string {
+				if event.LockedUntil == nil {
+					return nil
+				}
+				s := event.LockedUntil.Format(time.RFC3339)
+				return &s
+			}(),
+			Actor:     utils.ConvertUserSummary(event.Actor),
+			CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return &generated.LockoutEventPage{
+		Items:      result,
+		TotalCount: int32(totalCount),
+	}, nil
+}
+
+// GetMfaPolicies is the resolver for the getMfaPolicies field.
+func (r *queryResolver) GetMfaPolicies(ctx context.Context) ([]*generated.MfaPolicy, error) {
+	var policies []models.MfaPolicy
+	if err := initializers.DB.Order("role").Find(&policies).Error; err != nil {
+		log.Printf("Error fetching MFA policies: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch MFA policies")
+	}
+
+	result := make([]*generated.MfaPolicy, 0, len(policies))
+	for _, policy := range policies {
+		result = append(result, &generated.MfaPolicy{
+			Role:      generated.UserRole(policy.Role),
+			Required:  policy.Required,
+			UpdatedAt: policy.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return result, nil
+}
+
+// GetAPIKeys is the resolver for the getApiKeys field.
+func (r *queryResolver) GetAPIKeys(ctx context.Context, includeRevoked *bool) ([]*generated.APIKey, error) {
+	db := initializers.DB.Model(&models.APIKey{}).Preload("CreatedBy")
+	if includeRevoked == nil || !*includeRevoked {
+		db = db.Where("revoked_at IS NULL")
+	}
+
+	var keys []models.APIKey
+	if err := db.Order("created_at DESC").Find(&keys).Error; err != nil {
+		log.Printf("Error fetching API keys: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch API keys")
+	}
+
+	result := make([]*generated.APIKey, 0, len(keys))
+	for i := range keys {
+		result = append(result, utils.ConvertAPIKey(&keys[i]))
+	}
+	return result, nil
+}
+
+// GetDealAutomationConfig is the resolver for the getDealAutomationConfig field.
+func (r *queryResolver) GetDealAutomationConfig(ctx context.Context) (*generated.DealAutomationConfig, error) {
+	config, err := utils.LoadDealAutomationConfig(initializers.DB)
+	if err != nil {
+		log.Printf("Error fetching deal automation config: %v", err)
+		return nil, fmt.Errorf("internal error: failed to fetch deal automation config")
+	}
+	return utils.ConvertDealAutomationConfig(&config), nil
+}
+
 // GetAllCaseStudy is the resolver for the getAllCaseStudy field.
 func (r *queryResolver) GetAllCaseStudy(ctx context.Context) ([]*generated.CaseStudy, error) {
 	panic(fmt.Errorf("not implemented: GetAllCaseStudy - getAllCaseStudy"))
@@ -2016,11 +4128,23 @@ func (r *queryResolver) GetOneCaseStudy(ctx context.Context, caseStudyID string)
 	panic(fmt.Errorf("not implemented: GetOneCaseStudy - getOneCaseStudy"))
 }
 
+// Campaign returns generated.CampaignResolver implementation.
+func (r *Resolver) Campaign() generated.CampaignResolver { return &campaignResolver{r} }
+
+// Deal returns generated.DealResolver implementation.
+func (r *Resolver) Deal() generated.DealResolver { return &dealResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
+// Organization returns generated.OrganizationResolver implementation.
+func (r *Resolver) Organization() generated.OrganizationResolver { return &organizationResolver{r} }
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+type campaignResolver struct{ *Resolver }
+type dealResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type organizationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -0,0 +1,147 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// secretFieldPattern matches the names of fields that look like they carry credentials
+var secretFieldPattern = regexp.MustCompile(`(?i)password|secret|hash|token|credential`)
+
+// publishedSecrets are the credential fields that exist to hand a credential to its owner
+var publishedSecrets = map[string]bool{
+	"AuthPayload.token":           true,
+	"AuthPayload.refreshToken":    true,
+	"MfaChallenge.challengeToken": true,
+}
+
+var bcryptHash = regexp.MustCompile(`\$2[aby]?\$\d{2}\$`)
+
+const introspectionQuery = `{ __schema { queryType { name } mutationType { name } types { name kind fields(includeDeprecated: true) { name } } } }`
+
+func TestSchemaExposesNoSecrets(t *testing.T) {
+	srv, _ := newServer(t)
+	resp := execute(t, srv, nil, introspectionQuery, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("introspection failed: %s", resp.raw)
+	}
+
+	var schema struct {
+		QueryType    struct{ Name string }
+		MutationType struct{ Name string }
+		Types        []struct {
+			Name   string
+			Kind   string
+			Fields []struct{ Name string }
+		}
+	}
+	if err := json.Unmarshal(resp.Data["__schema"], &schema); err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Types) == 0 {
+		t.Fatal("introspection returned no types")
+	}
+
+	var exposed []string
+	for _, typ := range schema.Types {
+		// Input types carry credentials into the API, the root types hold operations
+		if typ.Kind != "OBJECT" && typ.Kind != "INTERFACE" {
+			continue
+		}
+		if typ.Name == schema.QueryType.Name || typ.Name == schema.MutationType.Name || strings.HasPrefix(typ.Name, "__") {
+			continue
+		}
+		for _, field := range typ.Fields {
+			name := typ.Name + "." + field.Name
+			if secretFieldPattern.MatchString(field.Name) && !publishedSecrets[name] {
+				exposed = append(exposed, name)
+			}
+		}
+	}
+	sort.Strings(exposed)
+	if len(exposed) > 0 {
+		t.Fatalf("the schema exposes secret bearing fields: %s", strings.Join(exposed, ", "))
+	}
+}
+
+const userFields = `userID googleId name email phone role`
+
+// storedUser makes the scripted database know user 9 with a bcrypt hash of the password
+func storedUser(t *testing.T, db *testdb.DB, password string) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "users"`, "LOWER(email) = LOWER($1)"},
+		Columns:  []string{"id", "google_id", "name", "email", "phone", "role", "password"},
+		Rows:     [][]driver.Value{{int64(9), "", "Stored", "stored@example.com", "+49 30 1234", "SALES_EXECUTIVE", string(hash)}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "users"`, "id = $1"},
+		Columns:  []string{"id", "google_id", "name", "email", "phone", "role", "password"},
+		Rows:     [][]driver.Value{{int64(9), "", "Stored", "stored@example.com", "+49 30 1234", "SALES_EXECUTIVE", string(hash)}},
+	})
+}
+
+func TestResponsesContainNoPasswordHashes(t *testing.T) {
+	admin := &models.User{Model: gormModel(4), Role: string(generated.UserRoleAdmin)}
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		headers   func(*testing.T, *testdb.DB) map[string]string
+	}{
+		{
+			name:      "login",
+			query:     `mutation($email: String!, $password: String!) { login(email: $email, password: $password) { ... on AuthPayload { user { ` + userFields + ` } } } }`,
+			variables: map[string]interface{}{"email": "stored@example.com", "password": "Stored-Password-1"},
+		},
+		{
+			name:  "createUser",
+			query: `mutation($input: CreateUserInput!) { createUser(input: $input) { ` + userFields + ` } }`,
+			variables: map[string]interface{}{"input": map[string]interface{}{
+				"googleId": "", "name": "New", "email": "new@example.com", "phone": "+49 30 5678",
+				"password": "New-Password-1", "role": "SALES_EXECUTIVE",
+			}},
+			headers: func(t *testing.T, db *testdb.DB) map[string]string { return bearer(t, db, admin) },
+		},
+		{
+			name:      "updateUser",
+			query:     `mutation($input: UpdateUserInput!) { updateUser(user_id: "9", input: $input) { ` + userFields + ` } }`,
+			variables: map[string]interface{}{"input": map[string]interface{}{"name": "Renamed"}},
+			headers:   func(t *testing.T, db *testdb.DB) map[string]string { return bearer(t, db, admin) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newUnguardedServer(t)
+			var headers map[string]string
+			if tt.headers != nil {
+				headers = tt.headers(t, db)
+			}
+			storedUser(t, db, "Stored-Password-1")
+
+			resp := execute(t, srv, headers, tt.query, tt.variables)
+			if len(resp.Errors) > 0 {
+				t.Fatalf("%s failed: %s", tt.name, resp.raw)
+			}
+			if bcryptHash.Match(resp.raw) {
+				t.Fatalf("%s returned a password hash: %s", tt.name, resp.raw)
+			}
+			if strings.Contains(string(resp.raw), "Password-1") {
+				t.Fatalf("%s returned a password: %s", tt.name, resp.raw)
+			}
+		})
+	}
+}
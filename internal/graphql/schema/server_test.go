@@ -0,0 +1,121 @@
+package schema_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/schema"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+	raw []byte
+}
+
+// newServer serves the schema the way the GraphQL handler does, on a scripted database
+func newServer(t *testing.T) (http.Handler, *testdb.DB) {
+	t.Helper()
+	return serve(t, true)
+}
+
+// newUnguardedServer serves the schema without SecretGuard, so tests see what the resolvers
+// return rather than what the guard lets through
+func newUnguardedServer(t *testing.T) (http.Handler, *testdb.DB) {
+	t.Helper()
+	return serve(t, false)
+}
+
+func serve(t *testing.T, guarded bool) (http.Handler, *testdb.DB) {
+	t.Helper()
+	gdb, db := testdb.Open(t)
+	previous := initializers.DB
+	initializers.DB = gdb
+	t.Cleanup(func() { initializers.DB = previous })
+
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  &schema.Resolver{},
+		Directives: auth.Directives(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(auth.PublicFields{})
+	if guarded {
+		srv.Use(auth.SecretGuard{})
+	}
+	return auth.Middleware(srv), db
+}
+
+// execute runs the operation with the headers and decodes the response
+func execute(t *testing.T, h http.Handler, headers map[string]string, query string, variables map[string]interface{}) response {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp response
+	resp.raw = rec.Body.Bytes()
+	if err := json.Unmarshal(resp.raw, &resp); err != nil {
+		t.Fatalf("decode response %q: %v", resp.raw, err)
+	}
+	return resp
+}
+
+// bearer returns the Authorization header of an access token of the user and makes the
+// scripted database know the user and report its session as active
+func bearer(t *testing.T, db *testdb.DB, user *models.User) map[string]string {
+	t.Helper()
+	token, err := auth.GenerateJWT(user, "6f1c2a52-3b1e-4c55-9f0c-0d5a8c1e7b10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.On(testdb.Rule{Contains: []string{`FROM "sessions"`, "count(*)"}, Columns: []string{"count"}, Rows: [][]driver.Value{{int64(1)}}})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "users"`},
+		Columns:  []string{"id", "name", "email", "role"},
+		Rows:     [][]driver.Value{{int64(user.ID), user.Name, user.Email, user.Role}},
+	})
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func errorCode(resp response) string {
+	if len(resp.Errors) == 0 {
+		return ""
+	}
+	code, _ := resp.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func gormModel(id uint) gorm.Model {
+	return gorm.Model{ID: id}
+}
@@ -0,0 +1,110 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+const getOrganizationsQuery = `{ getOrganizations { totalCount items { ID } } }`
+
+// apiKey makes the scripted database know an active API key with the scopes and returns
+// the header that presents it
+func apiKey(db *testdb.DB, scopes ...string) map[string]string {
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "api_keys"`},
+		Columns:  []string{"id", "name", "prefix", "key_hash", "scopes", "created_by_id"},
+		Rows: [][]driver.Value{{
+			"0b6c3f0e-8a55-4a8e-9a4f-3f5b1f4f2c11", "reporting", "abcdefgh", "hash",
+			[]byte(`["` + strings.Join(scopes, `","`) + `"]`), int64(4),
+		}},
+	})
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "users"`},
+		Columns:  []string{"id", "name", "email", "role"},
+		Rows:     [][]driver.Value{{int64(4), "Admin", "admin@example.com", "ADMIN"}},
+	})
+	return map[string]string{auth.APIKeyHeader: "crm_abcdefgh_secret"}
+}
+
+func TestEveryDirectiveIsImplemented(t *testing.T) {
+	directives := reflect.ValueOf(auth.Directives())
+	for i := 0; i < directives.NumField(); i++ {
+		if directives.Field(i).IsNil() {
+			t.Errorf("directive %s has no implementation", directives.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestServiceAccessFieldWithUser(t *testing.T) {
+	srv, db := newServer(t)
+	headers := bearer(t, db, &models.User{Model: gormModel(7), Role: string(generated.UserRoleSalesExecutive)})
+
+	resp := execute(t, srv, headers, getOrganizationsQuery, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("getOrganizations failed for a user: %s", resp.raw)
+	}
+	if _, ok := resp.Data["getOrganizations"]; !ok {
+		t.Fatalf("getOrganizations missing from %s", resp.raw)
+	}
+}
+
+func TestServiceAccessFieldWithAPIKey(t *testing.T) {
+	srv, db := newServer(t)
+
+	resp := execute(t, srv, apiKey(db, "getOrganizations"), getOrganizationsQuery, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("getOrganizations failed for a scoped API key: %s", resp.raw)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		query  string
+	}{
+		{"field outside the scopes", []string{"getCampaigns"}, getOrganizationsQuery},
+		{"field without @serviceAccess", []string{"getOrganizations"}, `{ getQuoteTemplates { id } }`},
+		{"mutation with @hasRole", []string{"getOrganizations"}, `mutation { deleteQuoteTemplate(id: "x") { id } }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			resp := execute(t, srv, apiKey(db, tt.scopes...), tt.query, nil)
+			if code := errorCode(resp); code != auth.ErrCodeForbidden {
+				t.Fatalf("expected %s, got %s", auth.ErrCodeForbidden, resp.raw)
+			}
+		})
+	}
+}
+
+func TestInvalidAPIKeyIsAnonymous(t *testing.T) {
+	srv, _ := newServer(t)
+
+	resp := execute(t, srv, map[string]string{auth.APIKeyHeader: "crm_unknown_secret"}, getOrganizationsQuery, nil)
+	if code := errorCode(resp); code != auth.ErrCodeUnauthenticated {
+		t.Fatalf("expected %s, got %s", auth.ErrCodeUnauthenticated, resp.raw)
+	}
+}
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	scopes, err := auth.NormalizeAPIKeyScopes([]string{" getOrganizations", "getCampaigns", "getOrganizations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scopes, []string{"getCampaigns", "getOrganizations"}) {
+		t.Fatalf("unexpected scopes %v", scopes)
+	}
+	for _, scopes := range [][]string{nil, {"getUsers"}, {"getOrganizations", "deleteUser"}} {
+		if _, err := auth.NormalizeAPIKeyScopes(scopes); err == nil {
+			t.Errorf("scopes %v were accepted", scopes)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package schema_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+func TestRevokedSessionIsUnauthenticated(t *testing.T) {
+	srv, db := newServer(t)
+	headers := bearer(t, db, &models.User{Model: gormModel(7), Role: string(generated.UserRoleSalesExecutive)})
+	db.On(testdb.Rule{Contains: []string{`FROM "sessions"`, "count(*)"}, Columns: []string{"count"}, Rows: [][]driver.Value{{int64(0)}}})
+
+	resp := execute(t, srv, headers, `mutation { logout }`, nil)
+	if code := errorCode(resp); code != auth.ErrCodeUnauthenticated {
+		t.Fatalf("expected %s, got %s", auth.ErrCodeUnauthenticated, resp.raw)
+	}
+}
+
+func TestLogoutRevokesTheSession(t *testing.T) {
+	srv, db := newServer(t)
+	headers := bearer(t, db, &models.User{Model: gormModel(7), Role: string(generated.UserRoleSalesExecutive)})
+
+	resp := execute(t, srv, headers, `mutation { logout }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("logout failed: %s", resp.raw)
+	}
+	statements := db.Statements(`UPDATE "sessions" SET "revoked_at"`, "id = $")
+	if len(statements) != 1 {
+		t.Fatal("logout did not revoke the session")
+	}
+	if len(db.Statements(`UPDATE "sessions"`, "user_id = $")) != 0 {
+		t.Fatal("logout revoked the other sessions of the user")
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       generated.UserRole
+		userID     string
+		targetRole generated.UserRole
+		allowed    bool
+	}{
+		{"own sessions", generated.UserRoleSalesExecutive, "7", generated.UserRoleSalesExecutive, true},
+		{"sessions of another user", generated.UserRoleSalesExecutive, "8", generated.UserRoleSalesExecutive, false},
+		{"manager", generated.UserRoleManager, "8", generated.UserRoleSalesExecutive, true},
+		{"manager revoking another manager", generated.UserRoleManager, "8", generated.UserRoleManager, true},
+		{"manager revoking an admin", generated.UserRoleManager, "8", generated.UserRoleAdmin, false},
+		{"admin revoking an admin", generated.UserRoleAdmin, "8", generated.UserRoleAdmin, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, db := newServer(t)
+			headers := bearer(t, db, &models.User{Model: gormModel(7), Role: string(tt.role)})
+			db.On(testdb.Rule{
+				Contains: []string{`FROM "users"`},
+				Columns:  []string{"id", "role"},
+				Rows:     [][]driver.Value{{tt.userID, string(tt.targetRole)}},
+			})
+
+			resp := execute(t, srv, headers, `mutation($id: ID!) { revokeAllSessions(userID: $id) }`, map[string]interface{}{"id": tt.userID})
+			if allowed := len(resp.Errors) == 0; allowed != tt.allowed {
+				t.Fatalf("allowed = %v, want %v: %s", allowed, tt.allowed, resp.raw)
+			}
+			if revoked := len(db.Statements(`UPDATE "sessions" SET "revoked_at"`, "user_id = $")) > 0; revoked != tt.allowed {
+				t.Fatalf("sessions revoked = %v, want %v", revoked, tt.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package schema_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const loginWithGoogleMutation = `mutation($idToken: String!) { loginWithGoogle(idToken: $idToken) { __typename } }`
+
+// googleIDToken points Google sign-in at a stub issuer and returns an ID token it signed
+// for the Google subject of the user
+func googleIDToken(t *testing.T, subject, email string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "stub",
+			"kty": "RSA",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(issuer.Close)
+	t.Setenv("GOOGLE_JWKS_URL", issuer.URL)
+	t.Setenv("GOOGLE_ISSUERS", issuer.URL)
+	t.Setenv("GOOGLE_CLIENT_ID", "crm-web")
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            issuer.URL,
+		"aud":            "crm-web",
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "stub"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestLockedAccountCannotLogin(t *testing.T) {
+	srv, db := newServer(t)
+	loginUser(t, db, "secret-password", false)
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "login_throttles"`},
+		Columns:  []string{"key", "failures", "locked_until"},
+		Rows:     [][]driver.Value{{"account:mfa@example.com", int64(5), time.Now().Add(time.Minute)}},
+	})
+
+	resp := execute(t, srv, nil, loginMutation, map[string]interface{}{"email": "mfa@example.com", "password": "secret-password"})
+	if code := errorCode(resp); code != "TOO_MANY_ATTEMPTS" {
+		t.Fatalf("expected TOO_MANY_ATTEMPTS, got %s", resp.raw)
+	}
+	if len(db.Statements(`FROM "users"`)) != 0 {
+		t.Fatal("the password was checked while the account was locked")
+	}
+}
+
+func TestLockedAccountCannotLoginWithGoogle(t *testing.T) {
+	srv, db := newServer(t)
+	loginUser(t, db, "secret-password", false)
+	idToken := googleIDToken(t, "google-9", "mfa@example.com")
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "login_throttles"`},
+		Columns:  []string{"key", "failures", "locked_until"},
+		Rows:     [][]driver.Value{{"account:mfa@example.com", int64(5), time.Now().Add(time.Minute)}},
+	})
+
+	resp := execute(t, srv, nil, loginWithGoogleMutation, map[string]interface{}{"idToken": idToken})
+	if code := errorCode(resp); code != "TOO_MANY_ATTEMPTS" {
+		t.Fatalf("expected TOO_MANY_ATTEMPTS, got %s", resp.raw)
+	}
+	if len(db.Statements(`INSERT INTO "sessions"`)) != 0 {
+		t.Fatal("a session was started for the locked account")
+	}
+}
+
+func TestWrongPasswordCountsAsFailure(t *testing.T) {
+	for _, email := range []string{"mfa@example.com", "unknown@example.com"} {
+		t.Run(email, func(t *testing.T) {
+			srv, db := newServer(t)
+			loginUser(t, db, "secret-password", false)
+			if email != "mfa@example.com" {
+				db.On(testdb.Rule{Contains: []string{`FROM "users"`}})
+			}
+
+			resp := execute(t, srv, nil, loginMutation, map[string]interface{}{"email": email, "password": "wrong-password"})
+			if len(resp.Errors) == 0 || resp.Errors[0].Message != "invalid credentials" {
+				t.Fatalf("expected invalid credentials, got %s", resp.raw)
+			}
+			if len(db.Statements(`INSERT INTO "login_throttles"`)) == 0 {
+				t.Fatal("the failure was not counted")
+			}
+			if accountThrottleResets(db) != 0 {
+				t.Fatal("a failed login reset the account throttle")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Package testdb is a scripted database/sql driver for tests of code written against gorm
+// and Postgres. It needs no database: statements are matched against the rules of the test,
+// a matching rule answers with its rows, affected rows or error, every other statement returns
+// no rows and affects one row. All statements are recorded so tests can assert on what was written.
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const driverName = "testdb"
+
+var (
+	registerOnce sync.Once
+	databases    sync.Map
+	nextID       atomic.Int64
+)
+
+// Rule answers the statements that contain every fragment of Contains
+type Rule struct {
+	Contains []string
+	Columns  []string
+	Rows     [][]driver.Value
+	Err      error
+	// RowsAffected is reported for the statements without result rows the rule matches
+	RowsAffected int64
+	// Times limits how often the rule matches, 0 matches forever
+	Times int
+
+	used int
+}
+
+// Statement is an executed statement with its arguments
+type Statement struct {
+	SQL  string
+	Args []driver.Value
+}
+
+// DB holds the rules and the statements of one test
+type DB struct {
+	mu         sync.Mutex
+	rules      []*Rule
+	statements []Statement
+}
+
+// Open returns a gorm connection backed by a new scripted database
+func Open(t testing.TB) (*gorm.DB, *DB) {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register(driverName, testDriver{}) })
+
+	dsn := fmt.Sprintf("testdb-%d", nextID.Add(1))
+	db := &DB{}
+	databases.Store(dsn, db)
+	t.Cleanup(func() { databases.Delete(dsn) })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{DriverName: driverName, DSN: dsn}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	return gdb, db
+}
+
+// On adds a rule. Rules added later take precedence, so a test can override a general rule.
+func (db *DB) On(rule Rule) *DB {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.rules = append([]*Rule{&rule}, db.rules...)
+	return db
+}
+
+// Statements returns the executed statements that contain every fragment
+func (db *DB) Statements(contains ...string) []Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var result []Statement
+	for _, statement := range db.statements {
+		if containsAll(statement.SQL, contains) {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
+
+func (db *DB) run(query string, args []driver.NamedValue) (*Rule, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	db.statements = append(db.statements, Statement{SQL: query, Args: values})
+	for _, rule := range db.rules {
+		if rule.Times > 0 && rule.used >= rule.Times {
+			continue
+		}
+		if containsAll(query, rule.Contains) {
+			rule.used++
+			return rule, rule.Err
+		}
+	}
+	return nil, nil
+}
+
+func containsAll(query string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if !strings.Contains(query, fragment) {
+			return false
+		}
+	}
+	return true
+}
+
+type testDriver struct{}
+
+func (testDriver) Open(dsn string) (driver.Conn, error) {
+	db, ok := databases.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("unknown test database %q", dsn)
+	}
+	return &conn{db: db.(*DB)}, nil
+}
+
+type conn struct {
+	db *DB
+}
+
+var (
+	_ driver.QueryerContext    = (*conn)(nil)
+	_ driver.ExecerContext     = (*conn)(nil)
+	_ driver.ConnBeginTx       = (*conn)(nil)
+	_ driver.NamedValueChecker = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.db.run("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return tx{db: c.db}, nil
+}
+
+// CheckNamedValue passes every argument through unchanged, nothing is sent anywhere
+func (c *conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rule, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return &rows{}, nil
+	}
+	return &rows{columns: rule.Columns, values: rule.Rows}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rule, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return driver.RowsAffected(1), nil
+	}
+	return driver.RowsAffected(rule.RowsAffected), nil
+}
+
+type tx struct {
+	db *DB
+}
+
+func (t tx) Commit() error {
+	_, err := t.db.run("COMMIT", nil)
+	return err
+}
+
+func (t tx) Rollback() error {
+	_, err := t.db.run("ROLLBACK", nil)
+	return err
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string { return r.columns }
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
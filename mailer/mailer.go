@@ -0,0 +1,131 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends outbound email. Resolvers depend on this interface so the transport
+// can be switched per environment.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the mailer selected by MAILER:
+//   - smtp: delivers through SMTP_HOST, SMTP_PORT, SMTP_USERNAME and SMTP_PASSWORD
+//   - file: appends messages to MAILER_FILE (defaults to mail.log)
+//   - log:  prints messages to the server log, for local development only
+//
+// There is no default: mails carry password reset tokens, which must not end up in the
+// server log of a deployment that forgot to configure a transport.
+func FromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch strings.ToLower(os.Getenv("MAILER")) {
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return &FileMailer{Path: path, From: from}, nil
+	case "log":
+		return &LogMailer{From: from}, nil
+	case "":
+		return nil, fmt.Errorf("MAILER is not set, use smtp, file or log")
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q, use smtp, file or log", os.Getenv("MAILER"))
+	}
+}
+
+// SMTPMailer delivers messages through an SMTP server using PLAIN auth
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if m.Host == "" {
+		return fmt.Errorf("SMTP_HOST is not configured")
+	}
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, msg.To, format(m.From, msg))
+}
+
+// LogMailer writes messages to the server log instead of sending them
+type LogMailer struct {
+	From string
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer appends messages to a file, handy to inspect mails during development
+type FileMailer struct {
+	Path string
+	From string
+	mu   sync.Mutex
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(format(m.From, msg), []byte("\r\n\r\n")...)); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+	return []byte(b.String())
+}
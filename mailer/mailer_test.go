@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := map[string]Mailer{
+		"smtp": &SMTPMailer{},
+		"file": &FileMailer{},
+		"LOG":  &LogMailer{},
+	}
+	for value, want := range tests {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("MAILER", value)
+			m, err := FromEnv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%T", m) != fmt.Sprintf("%T", want) {
+				t.Fatalf("got a %T, want a %T", m, want)
+			}
+		})
+	}
+}
+
+func TestFromEnvRequiresMailer(t *testing.T) {
+	for _, value := range []string{"", "sendmail"} {
+		t.Setenv("MAILER", value)
+		m, err := FromEnv()
+		if err == nil {
+			t.Fatalf("MAILER=%q built a %T", value, m)
+		}
+		if !strings.Contains(err.Error(), "MAILER") {
+			t.Fatalf("error %q does not name MAILER", err)
+		}
+	}
+}
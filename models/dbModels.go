@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +20,8 @@ type Lead struct {
 	LeadSource         string         `json:"leadSource"`
 	InitialContactDate string         `json:"initialContactDate"`
 	DeletedAt          gorm.DeletedAt `gorm:"index"` // Soft delete
+	// NULL for leads created before the column existed
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
 
 	LeadCreatedBy string `gorm:"index" json:"leadCreatedBy"`
 	Creator       User   `gorm:"foreignKey:LeadCreatedBy;constraint:OnDelete:SET NULL;" json:"creator"`
@@ -26,8 +29,11 @@ type Lead struct {
 	// Foreign Key for Assignee
 	LeadAssignedTo string `gorm:"index" json:"leadAssignedTo"`
 	Assignee       User   `gorm:"foreignKey:LeadAssignedTo;constraint:OnDelete:SET NULL;" json:"assignee"`
+	// Set when the assignee was picked by an assignment rule
+	AssignmentRuleID *uuid.UUID      `gorm:"type:uuid;index" json:"assignmentRuleId,omitempty"`
+	AssignmentRule   *AssignmentRule `gorm:"foreignKey:AssignmentRuleID;constraint:OnDelete:SET NULL;" json:"assignmentRule,omitempty"`
 
-	LeadStage      string       `json:"leadStage"`
+	LeadStage      LeadStage    `gorm:"type:lead_stage;not null;default:'NEW'" json:"leadStage"`
 	LeadNotes      string       `json:"leadNotes"`
 	LeadPriority   string       `json:"leadPriority"`
 	OrganizationID string       `gorm:"index" json:"organizationId"`
@@ -35,6 +41,62 @@ type Lead struct {
 	CampaignID     string       `gorm:"index" json:"campaignId"`
 	Campaign       Campaign     `gorm:"foreignKey:CampaignID" json:"campaign"`
 	Activities     []Activity   `gorm:"foreignKey:LeadID" json:"activities"`
+	// No foreign key constraint: deals created before lead IDs were validated may reference missing leads
+	Deals []Deals `gorm:"foreignKey:LeadID;constraint:-" json:"deals"`
+}
+
+// AssignmentRule picks the assignee of a lead that is created without one. Enabled rules are
+// tried by priority, lowest first; the first rule that matches the lead and has a candidate
+// below capacity assigns it. Each rule rotates through its candidates.
+type AssignmentRule struct {
+	BaseModel
+	Name     string             `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Type     AssignmentRuleType `gorm:"type:assignment_rule_type;not null" json:"type"`
+	Priority int                `gorm:"not null;default:0" json:"priority"`
+	Enabled  bool               `gorm:"not null" json:"enabled"`
+	// Only leads of the campaign, leads of any campaign when empty
+	CampaignID *uint `gorm:"index" json:"campaignId,omitempty"`
+	// Lead.Country and Campaign.CampaignRegion values the rule applies to, any when empty
+	Countries []string `gorm:"serializer:json;type:jsonb;not null" json:"countries"`
+	Regions   []string `gorm:"serializer:json;type:jsonb;not null" json:"regions"`
+	// Candidates of TERRITORY rules. ROUND_ROBIN rules pick from the OWNER and MEMBER members
+	// of the lead's campaign.
+	UserIDs []uint `gorm:"serializer:json;type:jsonb;not null" json:"userIds"`
+	// Candidates with this many open leads are skipped, no cap when empty
+	MaxOpenLeads *int `json:"maxOpenLeads,omitempty"`
+	// The candidate that got the last lead, the next lead goes to the one after
+	LastAssignedUserID *uint `json:"lastAssignedUserId,omitempty"`
+	CreatedByID        *uint `json:"createdById,omitempty"`
+}
+
+type AssignmentRuleType string
+
+const (
+	AssignmentRuleRoundRobin AssignmentRuleType = "ROUND_ROBIN"
+	AssignmentRuleTerritory  AssignmentRuleType = "TERRITORY"
+)
+
+type LeadStage string
+
+const (
+	LeadStageNew        LeadStage = "NEW"
+	LeadStageInProgress LeadStage = "IN_PROGRESS"
+	LeadStageFollowUp   LeadStage = "FOLLOW_UP"
+	LeadStageClosedWon  LeadStage = "CLOSED_WON"
+	LeadStageClosedLost LeadStage = "CLOSED_LOST"
+)
+
+// LeadStageHistory records every stage a lead entered, including the initial one, so the
+// time spent in each stage can be measured.
+type LeadStageHistory struct {
+	BaseModel
+	LeadID      string     `gorm:"index;not null" json:"leadId"`
+	FromStage   *LeadStage `gorm:"type:lead_stage" json:"fromStage,omitempty"`
+	ToStage     LeadStage  `gorm:"type:lead_stage;not null" json:"toStage"`
+	Reason      string     `gorm:"type:text" json:"reason"`
+	ChangedByID *uint      `gorm:"index" json:"changedById,omitempty"`
+	ChangedBy   *User      `gorm:"foreignKey:ChangedByID;constraint:OnDelete:SET NULL;" json:"changedBy,omitempty"`
+	ChangedAt   time.Time  `gorm:"not null;index" json:"changedAt"`
 }
 
 type Activity struct {
@@ -54,19 +116,157 @@ type Campaign struct {
 	CampaignCountry  string `json:"campaignCountry"`
 	CampaignRegion   string `json:"campaignRegion"`
 	IndustryTargeted string `json:"industryTargeted"`
-	Leads            []Lead `gorm:"foreignKey:CampaignID" json:"leads"`
-	Users            []User `gorm:"many2many:campaign_users;joinForeignKey:CampaignID;joinReferences:UserID;constraint:OnDelete:CASCADE;" json:"users"`
+	// Campaigns from before statuses existed were running, new campaigns start as DRAFT
+	Status    CampaignStatus `gorm:"type:campaign_status;not null;default:'ACTIVE'" json:"status"`
+	StartDate *time.Time     `gorm:"type:date" json:"startDate,omitempty"`
+	EndDate   *time.Time     `gorm:"type:date" json:"endDate,omitempty"`
+	// Planned spend, empty without a budget. Budget and actual spend are in Currency.
+	Budget      decimal.NullDecimal `gorm:"type:numeric(19,4)" json:"budget"`
+	ActualSpend decimal.Decimal     `gorm:"type:numeric(19,4);not null;default:0" json:"actualSpend"`
+	Currency    string              `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"`
+	Leads       []Lead              `gorm:"foreignKey:CampaignID" json:"leads"`
+	Users       []User              `gorm:"many2many:campaign_users;joinForeignKey:CampaignID;joinReferences:UserID;constraint:OnDelete:CASCADE;" json:"users"`
+	// The same campaign_users rows as Users, with their role
+	Members []CampaignUser `gorm:"foreignKey:CampaignID" json:"members"`
+}
+
+// CampaignUser is the membership of a user in a campaign, the join model of Campaign.Users.
+// Memberships from before roles existed are MEMBER.
+type CampaignUser struct {
+	CampaignID uint         `gorm:"primaryKey" json:"campaignId"`
+	UserID     uint         `gorm:"primaryKey" json:"userId"`
+	User       User         `gorm:"foreignKey:UserID" json:"user"`
+	Role       CampaignRole `gorm:"type:campaign_role;not null;default:'MEMBER'" json:"role"`
+	JoinedAt   time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"joinedAt"`
+}
+
+// CampaignRole decides what a member can do with the leads of a campaign: every member sees
+// them, VIEWER cannot change them and OWNER can also manage the members.
+type CampaignRole string
+
+const (
+	CampaignRoleOwner  CampaignRole = "OWNER"
+	CampaignRoleMember CampaignRole = "MEMBER"
+	CampaignRoleViewer CampaignRole = "VIEWER"
+)
+
+type CampaignStatus string
+
+const (
+	CampaignStatusDraft     CampaignStatus = "DRAFT"
+	CampaignStatusActive    CampaignStatus = "ACTIVE"
+	CampaignStatusPaused    CampaignStatus = "PAUSED"
+	CampaignStatusCompleted CampaignStatus = "COMPLETED"
+	CampaignStatusArchived  CampaignStatus = "ARCHIVED"
+)
+
+// campaignTransitions lists the statuses a campaign can move to from each status
+var campaignTransitions = map[CampaignStatus][]CampaignStatus{
+	CampaignStatusDraft:     {CampaignStatusActive, CampaignStatusArchived},
+	CampaignStatusActive:    {CampaignStatusPaused, CampaignStatusCompleted},
+	CampaignStatusPaused:    {CampaignStatusActive, CampaignStatusCompleted},
+	CampaignStatusCompleted: {CampaignStatusArchived},
+}
+
+// CanMoveTo reports whether a campaign with the status can move to the next one
+func (s CampaignStatus) CanMoveTo(next CampaignStatus) bool {
+	for _, allowed := range campaignTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
 }
 
 type User struct {
 	gorm.Model
-	GoogleId  string     `json:"googleId"`
-	Name      string     `json:"name"`
-	Email     string     `gorm:"unique" json:"email"`
-	Phone     string     `json:"phone"`
-	Role      string     `json:"role"`
-	Password  string     `json:"password"`
-	Campaigns []Campaign `gorm:"many2many:campaign_users;joinForeignKey:UserID;joinReferences:CampaignID;constraint:OnDelete:CASCADE;" json:"campaigns"`
+	GoogleId         string     `json:"googleId"`
+	Name             string     `json:"name"`
+	Email            string     `gorm:"unique" json:"email"`
+	Phone            string     `json:"phone"`
+	Role             string     `json:"role"`
+	Password         string     `json:"-"`
+	TotpSecret       string     `json:"-"` // set on enrollment, only used once TotpEnabled is true
+	TotpEnabled      bool       `gorm:"not null;default:false" json:"totpEnabled"`
+	TotpLastUsedStep int64      `gorm:"not null;default:0" json:"-"`
+	Campaigns        []Campaign `gorm:"many2many:campaign_users;joinForeignKey:UserID;joinReferences:CampaignID;constraint:OnDelete:CASCADE;" json:"campaigns"`
+}
+
+// Session backs a refresh token. Access tokens carry the session ID so revoking
+// the session invalidates them before they expire.
+type Session struct {
+	BaseModel
+	UserID           uint       `gorm:"index;not null" json:"userId"`
+	User             User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	RefreshTokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	ReplacedByID     *uuid.UUID `gorm:"type:uuid" json:"replacedById,omitempty"`
+}
+
+// PasswordResetToken is a single use token mailed to users who forgot their password
+type PasswordResetToken struct {
+	BaseModel
+	UserID    uint       `gorm:"index;not null" json:"userId"`
+	User      User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+// LoginThrottle counts failed logins per account ("account:<email>") or client IP ("ip:<addr>")
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;type:varchar(320)" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt *time.Time `json:"lastFailureAt,omitempty"`
+	LockedUntil   *time.Time `gorm:"index" json:"lockedUntil,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// LockoutEvent records lockouts and manual unlocks so managers can review them
+type LockoutEvent struct {
+	BaseModel
+	Kind        string     `gorm:"type:varchar(20);not null" json:"kind"`
+	Scope       string     `gorm:"type:varchar(20);not null" json:"scope"`
+	Identifier  string     `gorm:"type:varchar(320);not null;index" json:"identifier"`
+	UserID      *uint      `gorm:"index" json:"userId,omitempty"`
+	User        *User      `gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL;" json:"user,omitempty"`
+	IPAddress   string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+	ActorID     *uint      `json:"actorId,omitempty"`
+	Actor       *User      `gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL;" json:"actor,omitempty"`
+}
+
+// RecoveryCode is a hashed single use code that replaces a TOTP code when the device is lost
+type RecoveryCode struct {
+	BaseModel
+	UserID   uint       `gorm:"index;not null" json:"userId"`
+	User     User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	CodeHash string     `gorm:"type:varchar(64);not null;index" json:"-"`
+	UsedAt   *time.Time `json:"usedAt,omitempty"`
+}
+
+// MfaPolicy makes MFA mandatory for every user of a role
+type MfaPolicy struct {
+	Role        string    `gorm:"primaryKey;type:varchar(32)" json:"role"`
+	Required    bool      `gorm:"not null;default:false" json:"required"`
+	UpdatedByID *uint     `json:"updatedById,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// APIKey authenticates a backend service. Only a hash of the key is stored; the prefix
+// identifies the key in listings and lookups.
+type APIKey struct {
+	BaseModel
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix      string     `gorm:"type:varchar(16);not null;uniqueIndex" json:"prefix"`
+	KeyHash     string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Scopes      []string   `gorm:"serializer:json;type:jsonb;not null" json:"scopes"`
+	CreatedByID uint       `gorm:"index;not null" json:"createdById"`
+	CreatedBy   User       `gorm:"foreignKey:CreatedByID;constraint:OnDelete:CASCADE;" json:"createdBy"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
 }
 
 type CaseStudy struct {
@@ -90,19 +290,183 @@ type Organization struct {
 	City                string `json:"city"`
 	Country             string `json:"country"`
 	NoOfEmployees       string `json:"noOfEmployees"`
-	AnnualRevenue       string `json:"annualRevenue"`
-	Leads               []Lead `gorm:"foreignKey:OrganizationID" json:"leads"`
+	// Empty when the revenue is unknown
+	AnnualRevenue         decimal.NullDecimal `gorm:"type:numeric(19,4)" json:"annualRevenue"`
+	AnnualRevenueCurrency string              `gorm:"type:varchar(3)" json:"annualRevenueCurrency"`
+	Leads                 []Lead              `gorm:"foreignKey:OrganizationID" json:"leads"`
+	// Empty for top level organizations
+	ParentID     *uint          `gorm:"index" json:"parentId,omitempty"`
+	Subsidiaries []Organization `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL;" json:"subsidiaries"`
 }
 
 type Deals struct {
 	gorm.Model
-	DealName            string `json:"dealName"`
-	LeadID              string `gorm:"index" json:"leadId"`
-	DealStartDate       string `json:"dealStartDate"`
-	DealEndDate         string `json:"dealEndDate"`
-	ProjectRequirements string `json:"projectRequirements"`
-	DealAmount          string `json:"dealAmount"`
-	DealStatus          string `json:"dealStatus"`
+	DealName            string          `json:"dealName"`
+	LeadID              string          `gorm:"index" json:"leadId"`
+	DealStartDate       *time.Time      `gorm:"type:date" json:"dealStartDate"`
+	DealEndDate         *time.Time      `gorm:"type:date" json:"dealEndDate"`
+	ProjectRequirements string          `json:"projectRequirements"`
+	DealAmount          decimal.Decimal `gorm:"type:numeric(19,4);not null;default:0" json:"dealAmount"`
+	DealCurrency        string          `gorm:"type:varchar(3);not null;default:'USD'" json:"dealCurrency"`
+	DealStatus          DealStatus      `gorm:"type:deal_status;not null;default:'STARTED'" json:"dealStatus"`
+	ExpectedCloseDate   *time.Time      `gorm:"type:date" json:"expectedCloseDate,omitempty"`
+	ClosedAt            *time.Time      `json:"closedAt,omitempty"`
+	// Once a deal has line items its amount is their total
+	LineItems []DealLineItem `gorm:"foreignKey:DealID;constraint:OnDelete:CASCADE;" json:"lineItems"`
+}
+
+type DealStatus string
+
+const (
+	DealStatusStarted   DealStatus = "STARTED"
+	DealStatusPending   DealStatus = "PENDING"
+	DealStatusCompleted DealStatus = "COMPLETED"
+	DealStatusCancelled DealStatus = "CANCELLED"
+)
+
+// IsClosed reports whether the deal was closed with closeDeal
+func (s DealStatus) IsClosed() bool {
+	return s == DealStatusCompleted || s == DealStatusCancelled
+}
+
+// DealLineItem is a staffing position of a deal, filled by a resource profile or described
+// by a role and the skills it needs. The rate is in the currency of the deal.
+type DealLineItem struct {
+	BaseModel
+	DealID            uint             `gorm:"index;not null" json:"dealId"`
+	ResourceProfileID *uuid.UUID       `gorm:"type:uuid;index" json:"resourceProfileId,omitempty"`
+	ResourceProfile   *ResourceProfile `gorm:"foreignKey:ResourceProfileID;constraint:OnDelete:SET NULL;" json:"resourceProfile,omitempty"`
+	Role              string           `gorm:"type:varchar(100)" json:"role"`
+	Skills            []Skill          `gorm:"many2many:deal_line_item_skills;" json:"skills"`
+	Rate              decimal.Decimal  `gorm:"type:numeric(19,4);not null" json:"rate"`
+	Unit              LineItemUnit     `gorm:"type:line_item_unit;not null" json:"unit"`
+	Quantity          decimal.Decimal  `gorm:"type:numeric(12,2);not null" json:"quantity"`
+	StartDate         *time.Time       `gorm:"type:date" json:"startDate,omitempty"`
+	EndDate           *time.Time       `gorm:"type:date" json:"endDate,omitempty"`
+	Description       string           `gorm:"type:text" json:"description"`
+}
+
+// Total is the rate times the quantity
+func (item *DealLineItem) Total() decimal.Decimal {
+	return item.Rate.Mul(item.Quantity)
+}
+
+type LineItemUnit string
+
+const (
+	LineItemUnitHour  LineItemUnit = "HOUR"
+	LineItemUnitDay   LineItemUnit = "DAY"
+	LineItemUnitMonth LineItemUnit = "MONTH"
+)
+
+// QuoteTemplate is an html/template document quotes are rendered with, see package quote
+type QuoteTemplate struct {
+	BaseModel
+	Name        string `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	Body        string `gorm:"type:text;not null" json:"body"`
+	CreatedByID *uint  `json:"createdById,omitempty"`
+}
+
+// Quote is a rendered version of a deal's quote. The documents are stored as generated so a
+// quote that was sent does not change when the deal or the template does.
+type Quote struct {
+	BaseModel
+	DealID      uint            `gorm:"not null;uniqueIndex:idx_quote_deal_version" json:"dealId"`
+	Version     int             `gorm:"not null;uniqueIndex:idx_quote_deal_version" json:"version"`
+	Number      string          `gorm:"type:varchar(50);not null" json:"number"`
+	TemplateID  *uuid.UUID      `gorm:"type:uuid" json:"templateId,omitempty"`
+	Template    *QuoteTemplate  `gorm:"foreignKey:TemplateID;constraint:OnDelete:SET NULL;" json:"template,omitempty"`
+	Status      QuoteStatus     `gorm:"type:quote_status;not null;default:'DRAFT'" json:"status"`
+	Total       decimal.Decimal `gorm:"type:numeric(19,4);not null" json:"total"`
+	Currency    string          `gorm:"type:varchar(3);not null" json:"currency"`
+	HTML        string          `gorm:"type:text;not null" json:"-"`
+	PDF         []byte          `gorm:"type:bytea;not null" json:"-"`
+	CreatedByID *uint           `json:"createdById,omitempty"`
+	CreatedBy   *User           `gorm:"foreignKey:CreatedByID;constraint:OnDelete:SET NULL;" json:"createdBy,omitempty"`
+	SentAt      *time.Time      `json:"sentAt,omitempty"`
+	DecidedAt   *time.Time      `json:"decidedAt,omitempty"`
+}
+
+type QuoteStatus string
+
+const (
+	QuoteStatusDraft    QuoteStatus = "DRAFT"
+	QuoteStatusSent     QuoteStatus = "SENT"
+	QuoteStatusAccepted QuoteStatus = "ACCEPTED"
+	QuoteStatusRejected QuoteStatus = "REJECTED"
+)
+
+// quoteTransitions lists the statuses a quote can move to from each status
+var quoteTransitions = map[QuoteStatus][]QuoteStatus{
+	QuoteStatusDraft: {QuoteStatusSent},
+	QuoteStatusSent:  {QuoteStatusAccepted, QuoteStatusRejected},
+}
+
+// CanMoveTo reports whether a quote with the status can move to the next one
+func (s QuoteStatus) CanMoveTo(next QuoteStatus) bool {
+	for _, allowed := range quoteTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// DealAutomationConfig controls the deal that is created when a lead is won. There is a
+// single row, with ID 1; without it the defaults of utils.DefaultDealAutomationConfig apply.
+type DealAutomationConfig struct {
+	ID              uint               `gorm:"primaryKey" json:"id"`
+	Enabled         bool               `gorm:"not null" json:"enabled"`
+	DefaultAmount   decimal.Decimal    `gorm:"type:numeric(19,4);not null" json:"defaultAmount"`
+	DefaultCurrency string             `gorm:"type:varchar(3);not null" json:"defaultCurrency"`
+	DurationMonths  int                `gorm:"not null" json:"durationMonths"`
+	DefaultStatus   DealStatus         `gorm:"type:deal_status;not null" json:"defaultStatus"`
+	FieldMappings   []DealFieldMapping `gorm:"serializer:json;type:jsonb;not null" json:"fieldMappings"`
+	UpdatedByID     *uint              `json:"updatedById,omitempty"`
+	UpdatedBy       *User              `gorm:"foreignKey:UpdatedByID;constraint:OnDelete:SET NULL;" json:"updatedBy,omitempty"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
+}
+
+// DealFieldMapping copies a field of the won lead into a field of its new deal
+type DealFieldMapping struct {
+	DealField string `json:"dealField"`
+	LeadField string `json:"leadField"`
+}
+
+// DealStageProbability is the chance, in percent, that an open deal with the status is won.
+// Statuses without a row use the defaults of the sales forecast.
+type DealStageProbability struct {
+	DealStatus  DealStatus `gorm:"primaryKey;type:deal_status" json:"dealStatus"`
+	Probability int        `gorm:"not null" json:"probability"`
+	UpdatedByID *uint      `json:"updatedById,omitempty"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// ExchangeRate says one FromCurrency is worth Rate ToCurrency from EffectiveDate on. Rates
+// are replaced rather than soft deleted so a pair has a single rate per date.
+type ExchangeRate struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FromCurrency  string          `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_pair_date" json:"fromCurrency"`
+	ToCurrency    string          `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_pair_date" json:"toCurrency"`
+	EffectiveDate time.Time       `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_pair_date" json:"effectiveDate"`
+	Rate          decimal.Decimal `gorm:"type:numeric(19,8);not null" json:"rate"`
+	UpdatedByID   *uint           `json:"updatedById,omitempty"`
+	UpdatedBy     *User           `gorm:"foreignKey:UpdatedByID;constraint:OnDelete:SET NULL;" json:"updatedBy,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+// DataMigrationIssue records a value a data migration could not convert. The original value
+// is kept here because the column it came from now holds a default or nothing.
+type DataMigrationIssue struct {
+	BaseModel
+	Migration   string `gorm:"type:varchar(100);not null;index" json:"migration"`
+	SourceTable string `gorm:"type:varchar(100);not null" json:"sourceTable"`
+	RowID       string `gorm:"type:varchar(100);not null" json:"rowId"`
+	Column      string `gorm:"type:varchar(100);not null" json:"column"`
+	Value       string `gorm:"type:text" json:"value"`
+	Problem     string `gorm:"type:text;not null" json:"problem"`
 }
 
 type ResourceType string
@@ -0,0 +1,180 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const lineHeight = 5.0
+
+// tableColumns are the columns of the line item table, their widths add up to the 180mm
+// between the margins of an A4 page
+var tableColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Position", 68, "L"},
+	{"Period", 42, "L"},
+	{"Quantity", 24, "R"},
+	{"Rate", 22, "R"},
+	{"Total", 24, "R"},
+}
+
+// RenderPDF lays the quote out as an A4 document. It does not use the HTML template, the
+// PDF has a fixed layout with the same content.
+func RenderPDF(data Data) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetTitle("Quote "+data.Number, true)
+	// The core fonts only cover cp1252, translate the UTF-8 input to it
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, tr("Quote "+data.Number), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat(0, lineHeight, tr(fmt.Sprintf("Issued %s, valid until %s (version %d)", data.IssuedOn, data.ValidUntil, data.Version)), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(6)
+
+	heading(pdf, tr("Prepared for"))
+	pdf.SetFont("Helvetica", "", 10)
+	client := []string{data.Client.Organization}
+	if data.Client.ContactName != "" {
+		contact := data.Client.ContactName
+		if data.Client.ContactEmail != "" {
+			contact += ", " + data.Client.ContactEmail
+		}
+		client = append(client, contact)
+	}
+	if place := joinNonEmpty(", ", data.Client.City, data.Client.Country); place != "" {
+		client = append(client, place)
+	}
+	for _, line := range client {
+		pdf.CellFormat(0, lineHeight, tr(line), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	heading(pdf, tr(data.Deal.Name))
+	pdf.SetFont("Helvetica", "", 10)
+	if data.Deal.Requirements != "" {
+		pdf.MultiCell(0, lineHeight, tr(data.Deal.Requirements), "", "L", false)
+	}
+	if data.Deal.StartDate != "" {
+		period := "From " + data.Deal.StartDate
+		if data.Deal.EndDate != "" {
+			period += " to " + data.Deal.EndDate
+		}
+		pdf.CellFormat(0, lineHeight, tr(period), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	for _, column := range tableColumns {
+		title := column.title
+		if column.title == "Rate" || column.title == "Total" {
+			title += " (" + data.Currency + ")"
+		}
+		pdf.CellFormat(column.width, 7, tr(title), "B", 0, column.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range data.Lines {
+		position := joinNonEmpty("\n", line.Position, line.Skills, line.Notes)
+		tableRow(pdf, tr, []string{position, line.Period, line.Quantity + " " + line.Unit, line.Rate, line.Total})
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	totalWidth := 0.0
+	for _, column := range tableColumns[:len(tableColumns)-1] {
+		totalWidth += column.width
+	}
+	pdf.CellFormat(totalWidth, 8, tr("Total"), "", 0, "L", false, 0, "")
+	pdf.CellFormat(tableColumns[len(tableColumns)-1].width, 8, tr(data.Currency+" "+data.Total), "", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	if len(data.CaseStudies) > 0 {
+		heading(pdf, tr("Related work"))
+		for _, study := range data.CaseStudies {
+			title := study.ProjectName
+			if study.ClientName != "" {
+				title += " for " + study.ClientName
+			}
+			pdf.SetFont("Helvetica", "B", 10)
+			pdf.MultiCell(0, lineHeight, tr(title), "", "L", false)
+			pdf.SetFont("Helvetica", "", 10)
+			if study.KeyOutcomes != "" {
+				pdf.MultiCell(0, lineHeight, tr(study.KeyOutcomes), "", "L", false)
+			}
+			pdf.SetFont("Helvetica", "", 8)
+			if details := joinNonEmpty(", ", study.TechStack, study.Duration); details != "" {
+				pdf.MultiCell(0, lineHeight, tr(details), "", "L", false)
+			}
+			pdf.Ln(2)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, fmt.Errorf("failed to render quote PDF: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func heading(pdf *fpdf.Fpdf, text string) {
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, text, "", 1, "L", false, 0, "")
+}
+
+// tableRow draws one row of the line item table, wrapping long cells and starting a new
+// page when the row does not fit anymore
+func tableRow(pdf *fpdf.Fpdf, tr func(string) string, cells []string) {
+	lines := make([][]string, len(cells))
+	height := lineHeight
+	for i, cell := range cells {
+		for _, part := range strings.Split(tr(cell), "\n") {
+			for _, wrapped := range pdf.SplitLines([]byte(part), tableColumns[i].width-2) {
+				lines[i] = append(lines[i], string(wrapped))
+			}
+		}
+		if h := float64(len(lines[i])) * lineHeight; h > height {
+			height = h
+		}
+	}
+	height += 2
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetXY()
+	for i, column := range tableColumns {
+		pdf.SetXY(x, y+1)
+		for _, line := range lines[i] {
+			pdf.CellFormat(column.width, lineHeight, line, "", 2, column.align, false, 0, "")
+		}
+		x += column.width
+	}
+	left, _, _, _ := pdf.GetMargins()
+	pdf.SetXY(left, y+height)
+	pdf.Line(left, y+height, x, y+height)
+}
+
+func joinNonEmpty(separator string, values ...string) string {
+	var parts []string
+	for _, value := range values {
+		if value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, separator)
+}
@@ -0,0 +1,144 @@
+package quote
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultTemplate is used for quotes generated without a template
+//
+//go:embed default.html.tmpl
+var DefaultTemplate string
+
+// Data is what a quote template is rendered with. Amounts and dates are preformatted so
+// templates only have to place them.
+type Data struct {
+	Number      string
+	Version     int
+	IssuedOn    string
+	ValidUntil  string
+	Currency    string
+	Total       string
+	Deal        Deal
+	Client      Client
+	Lines       []Line
+	CaseStudies []CaseStudy
+}
+
+type Deal struct {
+	Name         string
+	Requirements string
+	StartDate    string
+	EndDate      string
+}
+
+type Client struct {
+	Organization string
+	Email        string
+	Website      string
+	City         string
+	Country      string
+	ContactName  string
+	ContactEmail string
+}
+
+type Line struct {
+	Position string
+	Skills   string
+	Period   string
+	Quantity string
+	Unit     string
+	Rate     string
+	Total    string
+	Notes    string
+}
+
+type CaseStudy struct {
+	ProjectName string
+	ClientName  string
+	TechStack   string
+	Duration    string
+	KeyOutcomes string
+}
+
+// validity is how long a quote can be accepted after it was issued
+const validity = 30 * 24 * time.Hour
+
+// NewData fills the quote number and dates of a quote issued now
+func NewData(dealID uint, version int, issued time.Time) Data {
+	return Data{
+		Number:     fmt.Sprintf("Q-%d-%d", dealID, version),
+		Version:    version,
+		IssuedOn:   FormatDate(issued),
+		ValidUntil: FormatDate(issued.Add(validity)),
+	}
+}
+
+// FormatDate formats a date the way quotes show it, e.g. "2 Jan 2006"
+func FormatDate(t time.Time) string {
+	return t.Format("2 Jan 2006")
+}
+
+// FormatAmount formats an amount with two decimals and thousands separators, e.g. "1,200.50"
+func FormatAmount(amount decimal.Decimal) string {
+	fixed := amount.StringFixed(2)
+	sign := ""
+	if strings.HasPrefix(fixed, "-") {
+		sign, fixed = "-", fixed[1:]
+	}
+	whole, fraction, _ := strings.Cut(fixed, ".")
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	return sign + grouped.String() + "." + fraction
+}
+
+// ParseTemplate parses the HTML template of a quote and renders it once with sample data,
+// so templates that reference unknown fields are rejected when they are saved.
+func ParseTemplate(source string) (*template.Template, error) {
+	tmpl, err := template.New("quote").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quote template: %w", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, sampleData()); err != nil {
+		return nil, fmt.Errorf("invalid quote template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderHTML renders the quote with the given template source
+func RenderHTML(source string, data Data) (string, error) {
+	tmpl, err := ParseTemplate(source)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render quote: %w", err)
+	}
+	return out.String(), nil
+}
+
+func sampleData() Data {
+	data := NewData(1, 1, time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC))
+	data.Currency = "USD"
+	data.Total = "12,000.00"
+	data.Deal = Deal{Name: "Sample deal", Requirements: "Two backend engineers", StartDate: "6 Jan 2025", EndDate: "30 Jun 2025"}
+	data.Client = Client{Organization: "Example Ltd", Email: "info@example.com", Website: "example.com",
+		City: "Berlin", Country: "Germany", ContactName: "Jane Doe", ContactEmail: "jane@example.com"}
+	data.Lines = []Line{{Position: "Backend engineer", Skills: "Go, PostgreSQL", Period: "6 Jan 2025 - 30 Jun 2025",
+		Quantity: "100", Unit: "DAY", Rate: "120.00", Total: "12,000.00"}}
+	data.CaseStudies = []CaseStudy{{ProjectName: "Billing platform", ClientName: "Acme", TechStack: "Go",
+		Duration: "6 months", KeyOutcomes: "Invoices are sent on time"}}
+	return data
+}
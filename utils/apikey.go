@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+// ConvertAPIKey maps an API key to its GraphQL shape. The creator is only set when preloaded.
+func ConvertAPIKey(key *models.APIKey) *generated.APIKey {
+	result := &generated.APIKey{
+		ID:        key.ID.String(),
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.CreatedBy.ID != 0 {
+		result.CreatedBy = ConvertUserSummary(&key.CreatedBy)
+	}
+	if key.LastUsedAt != nil {
+		lastUsedAt := key.LastUsedAt.Format(time.RFC3339)
+		result.LastUsedAt = &lastUsedAt
+	}
+	if key.RevokedAt != nil {
+		revokedAt := key.RevokedAt.Format(time.RFC3339)
+		result.RevokedAt = &revokedAt
+	}
+	if result.Scopes == nil {
+		result.Scopes = []string{}
+	}
+	return result
+}
@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConvertAssignmentRule maps an assignment rule to its GraphQL shape
+func ConvertAssignmentRule(rule *models.AssignmentRule) *generated.AssignmentRule {
+	result := &generated.AssignmentRule{
+		ID:        rule.ID.String(),
+		Name:      rule.Name,
+		Type:      generated.AssignmentRuleType(rule.Type),
+		Priority:  int32(rule.Priority),
+		Enabled:   rule.Enabled,
+		Countries: append([]string{}, rule.Countries...),
+		Regions:   append([]string{}, rule.Regions...),
+		UserIDs:   make([]string, 0, len(rule.UserIDs)),
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+	}
+	if rule.CampaignID != nil {
+		campaignID := fmt.Sprintf("%d", *rule.CampaignID)
+		result.CampaignID = &campaignID
+	}
+	for _, userID := range rule.UserIDs {
+		result.UserIDs = append(result.UserIDs, fmt.Sprintf("%d", userID))
+	}
+	if rule.MaxOpenLeads != nil {
+		maxOpenLeads := int32(*rule.MaxOpenLeads)
+		result.MaxOpenLeads = &maxOpenLeads
+	}
+	return result
+}
+
+// BuildAssignmentRule validates the input of createAssignmentRule and updateAssignmentRule and
+// fills the rule with it
+func BuildAssignmentRule(db *gorm.DB, input generated.AssignmentRuleInput, rule *models.AssignmentRule) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if input.MaxOpenLeads != nil && *input.MaxOpenLeads < 1 {
+		return errors.New("maxOpenLeads must be at least 1")
+	}
+
+	rule.CampaignID = nil
+	if input.CampaignID != nil {
+		campaign, err := FindCampaign(db, *input.CampaignID)
+		if err != nil {
+			return err
+		}
+		rule.CampaignID = &campaign.ID
+	}
+
+	userIDs := make([]uint, 0, len(input.UserIDs))
+	for _, userID := range input.UserIDs {
+		id, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil {
+			return errors.New("user not found")
+		}
+		userIDs = append(userIDs, uint(id))
+	}
+	userIDs = uniqueUints(userIDs)
+	if len(userIDs) > 0 {
+		var found int64
+		if err := db.Model(&models.User{}).Where("id IN ?", userIDs).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(userIDs) {
+			return errors.New("user not found")
+		}
+	}
+
+	ruleType := models.AssignmentRuleType(input.Type)
+	countries := trimmedValues(input.Countries)
+	regions := trimmedValues(input.Regions)
+	switch ruleType {
+	case models.AssignmentRuleTerritory:
+		if len(userIDs) == 0 {
+			return errors.New("TERRITORY rules need userIDs")
+		}
+		if len(countries) == 0 && len(regions) == 0 {
+			return errors.New("TERRITORY rules need countries or regions")
+		}
+	case models.AssignmentRuleRoundRobin:
+		if len(userIDs) > 0 {
+			return errors.New("ROUND_ROBIN rules pick from the campaign members, userIDs are not used")
+		}
+	}
+
+	rule.Name = name
+	rule.Type = ruleType
+	rule.Priority = int(input.Priority)
+	rule.Enabled = input.Enabled
+	rule.Countries = countries
+	rule.Regions = regions
+	rule.UserIDs = userIDs
+	rule.MaxOpenLeads = nil
+	if input.MaxOpenLeads != nil {
+		maxOpenLeads := int(*input.MaxOpenLeads)
+		rule.MaxOpenLeads = &maxOpenLeads
+	}
+	return nil
+}
+
+// AssignLead picks the assignee of a lead created without one with the first matching
+// assignment rule and records the rule on the lead. The lead stays unassigned when no rule
+// has a candidate for it. The rules are locked until the transaction ends so concurrent
+// leads move the rotation on one after another.
+func AssignLead(tx *gorm.DB, lead *models.Lead, campaign *models.Campaign) error {
+	var rules []models.AssignmentRule
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("enabled = ?", true).
+		Order("priority, created_at").Find(&rules).Error; err != nil {
+		return err
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if !assignmentRuleMatches(rule, lead, campaign) {
+			continue
+		}
+		candidates, err := assignmentCandidates(tx, rule, campaign)
+		if err != nil {
+			return err
+		}
+		userID, err := nextAssignee(tx, rule, candidates)
+		if err != nil {
+			return err
+		}
+		if userID == 0 {
+			continue
+		}
+
+		// The rotation is not a change of the rule, updated_at stays
+		if err := tx.Model(rule).UpdateColumn("last_assigned_user_id", userID).Error; err != nil {
+			return err
+		}
+		lead.LeadAssignedTo = fmt.Sprintf("%d", userID)
+		lead.AssignmentRuleID = &rule.ID
+		return nil
+	}
+	return nil
+}
+
+func assignmentRuleMatches(rule *models.AssignmentRule, lead *models.Lead, campaign *models.Campaign) bool {
+	if rule.CampaignID != nil && *rule.CampaignID != campaign.ID {
+		return false
+	}
+	if len(rule.Countries) > 0 && !containsFold(rule.Countries, lead.Country) {
+		return false
+	}
+	if len(rule.Regions) > 0 && !containsFold(rule.Regions, campaign.CampaignRegion) {
+		return false
+	}
+	return true
+}
+
+// assignmentCandidates returns the users a rule can assign, ordered by ID
+func assignmentCandidates(tx *gorm.DB, rule *models.AssignmentRule, campaign *models.Campaign) ([]uint, error) {
+	var candidates []uint
+	var err error
+	switch rule.Type {
+	case models.AssignmentRuleRoundRobin:
+		// Viewers cannot work on the leads of the campaign
+		err = tx.Model(&models.CampaignUser{}).
+			Where("campaign_id = ? AND role IN ?", campaign.ID, []models.CampaignRole{models.CampaignRoleOwner, models.CampaignRoleMember}).
+			Order("user_id").Pluck("user_id", &candidates).Error
+	case models.AssignmentRuleTerritory:
+		if len(rule.UserIDs) == 0 {
+			return nil, nil
+		}
+		// Users deleted since the rule was saved are skipped
+		err = tx.Model(&models.User{}).Where("id IN ?", rule.UserIDs).Order("id").Pluck("id", &candidates).Error
+	}
+	return candidates, err
+}
+
+// nextAssignee returns the first candidate after the one that got the last lead of the rule
+// that is below the capacity of the rule, 0 when every candidate is at capacity
+func nextAssignee(tx *gorm.DB, rule *models.AssignmentRule, candidates []uint) (uint, error) {
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	openLeads := map[string]int{}
+	if rule.MaxOpenLeads != nil {
+		ids := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			ids = append(ids, fmt.Sprintf("%d", candidate))
+		}
+		var counts []struct {
+			LeadAssignedTo string
+			Count          int
+		}
+		if err := tx.Model(&models.Lead{}).
+			Where("lead_assigned_to IN ? AND lead_stage NOT IN ?", ids, []models.LeadStage{models.LeadStageClosedWon, models.LeadStageClosedLost}).
+			Select("lead_assigned_to, COUNT(*) AS count").Group("lead_assigned_to").Scan(&counts).Error; err != nil {
+			return 0, err
+		}
+		for _, count := range counts {
+			openLeads[count.LeadAssignedTo] = count.Count
+		}
+	}
+
+	start := 0
+	if rule.LastAssignedUserID != nil {
+		start = sort.Search(len(candidates), func(i int) bool { return candidates[i] > *rule.LastAssignedUserID })
+	}
+	for i := 0; i < len(candidates); i++ {
+		candidate := candidates[(start+i)%len(candidates)]
+		if rule.MaxOpenLeads != nil && openLeads[fmt.Sprintf("%d", candidate)] >= *rule.MaxOpenLeads {
+			continue
+		}
+		return candidate, nil
+	}
+	return 0, nil
+}
+
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimmedValues(values []string) []string {
+	result := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func uniqueUints(values []uint) []uint {
+	seen := map[uint]bool{}
+	result := []uint{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
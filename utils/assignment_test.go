@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var assignmentRuleColumns = []string{"id", "name", "type", "priority", "enabled", "campaign_id", "countries", "regions", "user_ids", "max_open_leads", "last_assigned_user_id"}
+
+// assignmentRule is a row of assignment_rules, nil values are NULL
+type assignmentRule struct {
+	ruleType     models.AssignmentRuleType
+	campaignID   driver.Value
+	countries    string
+	regions      string
+	userIDs      string
+	maxOpenLeads driver.Value
+	lastAssigned driver.Value
+}
+
+func TestAssignLead(t *testing.T) {
+	roundRobin := func(lastAssigned, maxOpenLeads driver.Value) assignmentRule {
+		return assignmentRule{models.AssignmentRuleRoundRobin, nil, "[]", "[]", "[]", maxOpenLeads, lastAssigned}
+	}
+	tests := []struct {
+		name  string
+		rules []assignmentRule
+		// members are the OWNER and MEMBER users of the campaign, users the existing users
+		members []driver.Value
+		users   []driver.Value
+		// openLeads counts the open leads per assignee
+		openLeads map[string]int64
+		// assignee is empty and rule -1 when the lead stays unassigned
+		assignee string
+		rule     int
+	}{
+		{
+			name:     "round robin starts with the first member",
+			rules:    []assignmentRule{roundRobin(nil, nil)},
+			members:  []driver.Value{int64(3), int64(5), int64(7)},
+			assignee: "3",
+		},
+		{
+			name:     "round robin continues after the last assignee",
+			rules:    []assignmentRule{roundRobin(int64(3), nil)},
+			members:  []driver.Value{int64(3), int64(5), int64(7)},
+			assignee: "5",
+		},
+		{
+			name:     "round robin wraps around",
+			rules:    []assignmentRule{roundRobin(int64(7), nil)},
+			members:  []driver.Value{int64(3), int64(5), int64(7)},
+			assignee: "3",
+		},
+		{
+			name:     "round robin continues after a last assignee who left the campaign",
+			rules:    []assignmentRule{roundRobin(int64(4), nil)},
+			members:  []driver.Value{int64(3), int64(5), int64(7)},
+			assignee: "5",
+		},
+		{
+			name:      "users at capacity are skipped",
+			rules:     []assignmentRule{roundRobin(int64(3), int64(2))},
+			members:   []driver.Value{int64(3), int64(5), int64(7)},
+			openLeads: map[string]int64{"5": 2, "7": 1},
+			assignee:  "7",
+		},
+		{
+			name:      "skipping wraps around",
+			rules:     []assignmentRule{roundRobin(int64(5), int64(2))},
+			members:   []driver.Value{int64(3), int64(5), int64(7)},
+			openLeads: map[string]int64{"7": 3},
+			assignee:  "3",
+		},
+		{
+			name:      "everybody at capacity leaves the lead unassigned",
+			rules:     []assignmentRule{roundRobin(nil, int64(1))},
+			members:   []driver.Value{int64(3), int64(5)},
+			openLeads: map[string]int64{"3": 1, "5": 4},
+			rule:      -1,
+		},
+		{
+			name:  "no candidate leaves the lead unassigned",
+			rules: []assignmentRule{roundRobin(nil, nil)},
+			rule:  -1,
+		},
+		{
+			name:     "territory matches the country ignoring case",
+			rules:    []assignmentRule{{models.AssignmentRuleTerritory, nil, `["de", "AT"]`, "[]", "[8, 9]", nil, nil}},
+			users:    []driver.Value{int64(8), int64(9)},
+			assignee: "8",
+		},
+		{
+			name:     "territory matches the region of the campaign",
+			rules:    []assignmentRule{{models.AssignmentRuleTerritory, nil, "[]", `["EMEA"]`, "[9]", nil, nil}},
+			users:    []driver.Value{int64(9)},
+			assignee: "9",
+		},
+		{
+			name: "the next rule applies when the territory does not match",
+			rules: []assignmentRule{
+				{models.AssignmentRuleTerritory, nil, `["FR"]`, "[]", "[8]", nil, nil},
+				{models.AssignmentRuleTerritory, nil, "[]", `["APAC"]`, "[8]", nil, nil},
+				roundRobin(nil, nil),
+			},
+			members:  []driver.Value{int64(5)},
+			users:    []driver.Value{int64(8)},
+			assignee: "5",
+			rule:     2,
+		},
+		{
+			name: "rules of another campaign do not apply",
+			rules: []assignmentRule{
+				{models.AssignmentRuleTerritory, int64(99), "[]", "[]", "[8]", nil, nil},
+				roundRobin(nil, nil),
+			},
+			members:  []driver.Value{int64(5)},
+			users:    []driver.Value{int64(8)},
+			assignee: "5",
+			rule:     1,
+		},
+		{
+			name: "territories whose users were deleted fall through",
+			rules: []assignmentRule{
+				{models.AssignmentRuleTerritory, int64(2), `["DE"]`, "[]", "[8]", nil, nil},
+				roundRobin(nil, nil),
+			},
+			members:  []driver.Value{int64(5)},
+			assignee: "5",
+			rule:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gdb, db := testdb.Open(t)
+			ruleIDs := make([]uuid.UUID, len(tt.rules))
+			rows := make([][]driver.Value, len(tt.rules))
+			for i, rule := range tt.rules {
+				ruleIDs[i] = uuid.New()
+				rows[i] = []driver.Value{ruleIDs[i].String(), "rule", string(rule.ruleType), int64(i), true, rule.campaignID, rule.countries, rule.regions, rule.userIDs, rule.maxOpenLeads, rule.lastAssigned}
+			}
+			db.On(testdb.Rule{Contains: []string{`FROM "assignment_rules"`}, Columns: assignmentRuleColumns, Rows: rows})
+			db.On(testdb.Rule{Contains: []string{`FROM "campaign_users"`}, Columns: []string{"user_id"}, Rows: column(tt.members)})
+			db.On(testdb.Rule{Contains: []string{`FROM "users"`}, Columns: []string{"id"}, Rows: column(tt.users)})
+			var counts [][]driver.Value
+			for assignee, count := range tt.openLeads {
+				counts = append(counts, []driver.Value{assignee, count})
+			}
+			db.On(testdb.Rule{Contains: []string{`FROM "leads"`, "COUNT(*)"}, Columns: []string{"lead_assigned_to", "count"}, Rows: counts})
+
+			lead := &models.Lead{LeadID: "lead-1", Country: "DE "}
+			campaign := &models.Campaign{Model: gorm.Model{ID: 2}, CampaignRegion: "emea"}
+			if err := AssignLead(gdb, lead, campaign); err != nil {
+				t.Fatal(err)
+			}
+
+			if lead.LeadAssignedTo != tt.assignee {
+				t.Fatalf("assigned to %q, want %q", lead.LeadAssignedTo, tt.assignee)
+			}
+			rotations := db.Statements(`UPDATE "assignment_rules" SET "last_assigned_user_id"`)
+			if tt.rule < 0 {
+				if lead.AssignmentRuleID != nil || len(rotations) > 0 {
+					t.Fatalf("an unassigned lead moved a rotation on: %v, %v", lead.AssignmentRuleID, rotations)
+				}
+				return
+			}
+			if lead.AssignmentRuleID == nil || *lead.AssignmentRuleID != ruleIDs[tt.rule] {
+				t.Fatalf("assigned by rule %v, want rule %d", lead.AssignmentRuleID, tt.rule)
+			}
+			if len(rotations) != 1 || !containsValue(rotations[0].Args, ruleIDs[tt.rule]) {
+				t.Fatalf("the rotation of rule %d was not moved on: %v", tt.rule, rotations)
+			}
+		})
+	}
+}
+
+func column(values []driver.Value) [][]driver.Value {
+	rows := make([][]driver.Value, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, []driver.Value{value})
+	}
+	return rows
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// dealAutomationConfigID is the ID of the single DealAutomationConfig row
+const dealAutomationConfigID = 1
+
+// DefaultDealAutomationConfig is used until an admin saves a configuration. It matches what
+// won leads always got: a six month deal without an amount.
+func DefaultDealAutomationConfig() models.DealAutomationConfig {
+	return models.DealAutomationConfig{
+		ID:              dealAutomationConfigID,
+		Enabled:         true,
+		DefaultAmount:   decimal.Zero,
+		DefaultCurrency: scalars.DefaultCurrency(),
+		DurationMonths:  6,
+		DefaultStatus:   models.DealStatusStarted,
+		FieldMappings:   []models.DealFieldMapping{},
+	}
+}
+
+// LoadDealAutomationConfig returns the saved configuration or the default one
+func LoadDealAutomationConfig(db *gorm.DB) (models.DealAutomationConfig, error) {
+	var config models.DealAutomationConfig
+	err := db.Preload("UpdatedBy").First(&config, dealAutomationConfigID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultDealAutomationConfig(), nil
+	}
+	return config, err
+}
+
+// NewDealAutomationConfig validates the input of updateDealAutomationConfig
+func NewDealAutomationConfig(input generated.DealAutomationConfigInput, actorID *uint) (models.DealAutomationConfig, error) {
+	if input.DefaultAmount.Amount.IsNegative() {
+		return models.DealAutomationConfig{}, errors.New("defaultAmount must not be negative")
+	}
+	if input.DurationMonths < 1 || input.DurationMonths > 120 {
+		return models.DealAutomationConfig{}, errors.New("durationMonths must be between 1 and 120")
+	}
+	status := models.DealStatus(input.DefaultStatus)
+	if status.IsClosed() {
+		return models.DealAutomationConfig{}, errors.New("defaultStatus must be an open status")
+	}
+
+	mappings := make([]models.DealFieldMapping, 0, len(input.FieldMappings))
+	mapped := map[generated.DealMappingTarget]bool{}
+	for _, mapping := range input.FieldMappings {
+		if mapped[mapping.DealField] {
+			return models.DealAutomationConfig{}, fmt.Errorf("%s is mapped more than once", mapping.DealField)
+		}
+		mapped[mapping.DealField] = true
+		mappings = append(mappings, models.DealFieldMapping{
+			DealField: mapping.DealField.String(),
+			LeadField: mapping.LeadField.String(),
+		})
+	}
+
+	return models.DealAutomationConfig{
+		ID:              dealAutomationConfigID,
+		Enabled:         input.Enabled,
+		DefaultAmount:   input.DefaultAmount.Amount,
+		DefaultCurrency: input.DefaultAmount.Currency,
+		DurationMonths:  int(input.DurationMonths),
+		DefaultStatus:   status,
+		FieldMappings:   mappings,
+		UpdatedByID:     actorID,
+	}, nil
+}
+
+// ConvertDealAutomationConfig maps the configuration to its GraphQL shape
+func ConvertDealAutomationConfig(config *models.DealAutomationConfig) *generated.DealAutomationConfig {
+	result := &generated.DealAutomationConfig{
+		Enabled:        config.Enabled,
+		DefaultAmount:  scalars.Money{Amount: config.DefaultAmount, Currency: config.DefaultCurrency},
+		DurationMonths: int32(config.DurationMonths),
+		DefaultStatus:  generated.DealStatus(config.DefaultStatus),
+		FieldMappings:  make([]*generated.DealFieldMapping, 0, len(config.FieldMappings)),
+		UpdatedBy:      ConvertUserSummary(config.UpdatedBy),
+	}
+	for _, mapping := range config.FieldMappings {
+		result.FieldMappings = append(result.FieldMappings, &generated.DealFieldMapping{
+			DealField: generated.DealMappingTarget(mapping.DealField),
+			LeadField: generated.LeadMappingSource(mapping.LeadField),
+		})
+	}
+	if !config.UpdatedAt.IsZero() {
+		result.UpdatedAt = &config.UpdatedAt
+	}
+	return result
+}
+
+// createDealForWonLead creates the deal of a lead that was just won, unless the lead already
+// has one or the automation is disabled. It runs in the transaction of the stage change so
+// the lead is not won without its deal.
+func createDealForWonLead(tx *gorm.DB, lead *models.Lead) error {
+	config, err := LoadDealAutomationConfig(tx)
+	if err != nil {
+		return err
+	}
+	if !config.Enabled {
+		return nil
+	}
+
+	// A lead that is won again after being reopened keeps its deal
+	var existing int64
+	if err := tx.Model(&models.Deals{}).Where("lead_id = ?", lead.LeadID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	start := time.Now()
+	end := start.AddDate(0, config.DurationMonths, 0)
+	deal := models.Deals{
+		LeadID:        lead.LeadID,
+		DealName:      fmt.Sprintf("Deal for %s %s", lead.FirstName, lead.LastName),
+		DealAmount:    config.DefaultAmount,
+		DealCurrency:  config.DefaultCurrency,
+		DealStartDate: &start,
+		DealEndDate:   &end,
+		DealStatus:    config.DefaultStatus,
+	}
+	for _, mapping := range config.FieldMappings {
+		value, err := leadFieldValue(tx, lead, mapping.LeadField)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		switch mapping.DealField {
+		case generated.DealMappingTargetDealName.String():
+			deal.DealName = value
+		case generated.DealMappingTargetProjectRequirements.String():
+			deal.ProjectRequirements = value
+		}
+	}
+
+	if err := tx.Create(&deal).Error; err != nil {
+		log.Printf("Error creating deal for lead %s: %v", lead.LeadID, err)
+		return err
+	}
+	return nil
+}
+
+// leadFieldValue returns the value of a LeadMappingSource for the lead
+func leadFieldValue(tx *gorm.DB, lead *models.Lead, field string) (string, error) {
+	switch generated.LeadMappingSource(field) {
+	case generated.LeadMappingSourceFullName:
+		return strings.TrimSpace(lead.FirstName + " " + lead.LastName), nil
+	case generated.LeadMappingSourceEmail:
+		return lead.Email, nil
+	case generated.LeadMappingSourceLeadNotes:
+		return lead.LeadNotes, nil
+	case generated.LeadMappingSourceLeadSource:
+		return lead.LeadSource, nil
+	case generated.LeadMappingSourceLeadPriority:
+		return lead.LeadPriority, nil
+	case generated.LeadMappingSourceOrganizationName:
+		id, err := strconv.ParseUint(lead.OrganizationID, 10, 64)
+		if err != nil {
+			return "", nil
+		}
+		var names []string
+		err = tx.Model(&models.Organization{}).Where("id = ?", id).Pluck("organization_name", &names).Error
+		if err != nil || len(names) == 0 {
+			return "", err
+		}
+		return names[0], nil
+	case generated.LeadMappingSourceCampaignName:
+		id, err := strconv.ParseUint(lead.CampaignID, 10, 64)
+		if err != nil {
+			return "", nil
+		}
+		var names []string
+		err = tx.Model(&models.Campaign{}).Where("id = ?", id).Pluck("campaign_name", &names).Error
+		if err != nil || len(names) == 0 {
+			return "", err
+		}
+		return names[0], nil
+	}
+	return "", nil
+}
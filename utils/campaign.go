@@ -0,0 +1,487 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ConvertCampaign maps a campaign with its preloaded members. Leads are not mapped, a
+// campaign can have too many of them; metrics summarizes them instead.
+func ConvertCampaign(campaign *models.Campaign) *generated.Campaign {
+	result := &generated.Campaign{
+		CampaignID:       fmt.Sprintf("%d", campaign.ID),
+		CampaignName:     campaign.CampaignName,
+		CampaignCountry:  campaign.CampaignCountry,
+		CampaignRegion:   campaign.CampaignRegion,
+		IndustryTargeted: campaign.IndustryTargeted,
+		Status:           generated.CampaignStatus(campaign.Status),
+		StartDate:        campaign.StartDate,
+		EndDate:          campaign.EndDate,
+		ActualSpend:      scalars.Money{Amount: campaign.ActualSpend, Currency: campaign.Currency},
+		Users:            make([]*generated.User, 0, len(campaign.Users)),
+		Members:          make([]*generated.CampaignMember, 0, len(campaign.Members)),
+		Leads:            []*generated.Lead{},
+	}
+	if campaign.Budget.Valid {
+		result.Budget = &scalars.Money{Amount: campaign.Budget.Decimal, Currency: campaign.Currency}
+	}
+	for i := range campaign.Users {
+		result.Users = append(result.Users, ConvertUserSummary(&campaign.Users[i]))
+	}
+	for i := range campaign.Members {
+		result.Members = append(result.Members, &generated.CampaignMember{
+			User:     ConvertUserSummary(&campaign.Members[i].User),
+			Role:     generated.CampaignRole(campaign.Members[i].Role),
+			JoinedAt: campaign.Members[i].JoinedAt,
+		})
+	}
+	return result
+}
+
+// PreloadCampaign preloads the members ConvertCampaign maps, the longest members first
+func PreloadCampaign(db *gorm.DB) *gorm.DB {
+	return db.Preload("Users").
+		Preload("Members", func(db *gorm.DB) *gorm.DB { return db.Order("joined_at, user_id") }).
+		Preload("Members.User")
+}
+
+// FindCampaign loads a campaign with its members, the longest members first
+func FindCampaign(db *gorm.DB, campaignID string) (*models.Campaign, error) {
+	id, err := strconv.ParseUint(campaignID, 10, 64)
+	if err != nil {
+		return nil, errors.New("campaign not found")
+	}
+	var campaign models.Campaign
+	if err := PreloadCampaign(db).First(&campaign, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("campaign not found")
+		}
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// CampaignScope limits campaigns to the ones the current user may see. ADMIN and MANAGER
+// see every campaign, everybody else the campaigns they are a member of and those with a
+// lead they may see under LeadScope.
+func CampaignScope(ctx context.Context) (*gorm.DB, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, errors.New("unauthorized")
+	}
+
+	query := initializers.DB.Model(&models.Campaign{})
+
+	role, _ := claims["role"].(string)
+	if role == "ADMIN" || role == "MANAGER" {
+		return query, nil
+	}
+
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, errors.New("failed to extract user ID from JWT")
+	}
+	leads, err := LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Where(
+		"(campaigns.id IN (SELECT campaign_users.campaign_id FROM campaign_users WHERE campaign_users.user_id = ?) OR CAST(campaigns.id AS TEXT) IN (?))",
+		userID, leads.Select("leads.campaign_id"),
+	), nil
+}
+
+// FindScopedCampaign loads a campaign like FindCampaign through CampaignScope. Campaigns
+// outside the scope are reported as not found.
+func FindScopedCampaign(ctx context.Context, campaignID string) (*models.Campaign, error) {
+	query, err := CampaignScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FindCampaign(query, campaignID)
+}
+
+// CampaignMetrics aggregates the leads of the campaign visible to the caller, their deals and
+// their activities in the database
+func CampaignMetrics(ctx context.Context, campaignID string) (*generated.CampaignMetrics, error) {
+	scoped, err := LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scoped = scoped.Where("leads.campaign_id = ?", campaignID).Select("leads.lead_id, leads.lead_stage")
+
+	var stageRows []struct {
+		LeadStage string
+		Count     int64
+	}
+	if err := initializers.DB.Table("(?) AS scoped", scoped).
+		Select("lead_stage, COUNT(*) AS count").Group("lead_stage").Scan(&stageRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count leads per stage: %w", err)
+	}
+	perStage := map[generated.LeadStage]int64{}
+	var total int64
+	for _, row := range stageRows {
+		perStage[generated.LeadStage(row.LeadStage)] = row.Count
+		total += row.Count
+	}
+
+	var dealRows []struct {
+		Currency string
+		Deals    int64
+		Total    decimal.Decimal
+	}
+	if err := initializers.DB.Table("deals").
+		Joins("JOIN (?) AS scoped ON scoped.lead_id = deals.lead_id", scoped).
+		Where("deals.deleted_at IS NULL").
+		Select("deals.deal_currency AS currency, COUNT(*) AS deals, SUM(deals.deal_amount) AS total").
+		Group("deals.deal_currency").Order("deals.deal_currency").Scan(&dealRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum deals: %w", err)
+	}
+
+	var channelRows []struct {
+		Channel    string
+		Activities int64
+	}
+	if err := initializers.DB.Table("activities").
+		Joins("JOIN (?) AS scoped ON scoped.lead_id = activities.lead_id", scoped).
+		Select("activities.communication_channel AS channel, COUNT(*) AS activities").
+		Group("activities.communication_channel").Order("activities DESC, channel").Scan(&channelRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count activities: %w", err)
+	}
+
+	won := perStage[generated.LeadStageClosedWon]
+	metrics := &generated.CampaignMetrics{
+		TotalLeads:          int32(total),
+		LeadsByStage:        make([]*generated.LeadStageCount, 0, len(generated.AllLeadStage)),
+		WonLeads:            int32(won),
+		DealValue:           make([]*scalars.Money, 0, len(dealRows)),
+		ActivitiesByChannel: make([]*generated.ChannelActivityCount, 0, len(channelRows)),
+	}
+	if total > 0 {
+		metrics.ConversionRate = float64(won) / float64(total)
+	}
+	for _, stage := range generated.AllLeadStage {
+		metrics.LeadsByStage = append(metrics.LeadsByStage, &generated.LeadStageCount{
+			Stage: stage,
+			Leads: int32(perStage[stage]),
+		})
+	}
+	for _, row := range dealRows {
+		metrics.DealCount += int32(row.Deals)
+		metrics.DealValue = append(metrics.DealValue, &scalars.Money{Amount: row.Total, Currency: row.Currency})
+	}
+	for _, row := range channelRows {
+		metrics.ActivitiesByChannel = append(metrics.ActivitiesByChannel, &generated.ChannelActivityCount{
+			Channel:    row.Channel,
+			Activities: int32(row.Activities),
+		})
+	}
+
+	if err := addCampaignCosts(ctx, metrics, campaignID); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// addCampaignCosts divides the spend of the campaign by all its leads and by the deals of its
+// CLOSED_WON leads. Unlike the other metrics the costs are not limited to the leads the caller
+// can see, so they would reveal how many leads are hidden and are only added for ADMIN and
+// MANAGER, who see every lead anyway.
+func addCampaignCosts(ctx context.Context, metrics *generated.CampaignMetrics, campaignID string) error {
+	id, err := strconv.ParseUint(campaignID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	claims, _ := auth.GetUserFromJWT(ctx)
+	role, _ := claims["role"].(string)
+	seesAllLeads := role == "ADMIN" || role == "MANAGER"
+	var costs []struct {
+		Budget      decimal.NullDecimal
+		ActualSpend decimal.Decimal
+		Currency    string
+		Leads       int64
+		WonDeals    int64
+	}
+	if err := initializers.DB.Table("campaigns").
+		Joins("LEFT JOIN leads ON leads.campaign_id = CAST(campaigns.id AS TEXT) AND leads.deleted_at IS NULL").
+		Joins("LEFT JOIN deals ON deals.lead_id = leads.lead_id AND deals.deleted_at IS NULL").
+		Where("campaigns.id = ? AND campaigns.deleted_at IS NULL", id).
+		Select("campaigns.budget, campaigns.actual_spend, campaigns.currency, "+
+			"COUNT(DISTINCT leads.lead_id) AS leads, "+
+			"COUNT(DISTINCT deals.id) FILTER (WHERE leads.lead_stage = ?) AS won_deals", models.LeadStageClosedWon).
+		Group("campaigns.id").Scan(&costs).Error; err != nil {
+		return fmt.Errorf("failed to compute campaign costs: %w", err)
+	}
+	if len(costs) == 0 {
+		return nil
+	}
+
+	cost := costs[0]
+	if seesAllLeads && cost.Leads > 0 {
+		metrics.CostPerLead = &scalars.Money{Amount: cost.ActualSpend.DivRound(decimal.NewFromInt(cost.Leads), 2), Currency: cost.Currency}
+	}
+	if seesAllLeads && cost.WonDeals > 0 {
+		metrics.CostPerWonDeal = &scalars.Money{Amount: cost.ActualSpend.DivRound(decimal.NewFromInt(cost.WonDeals), 2), Currency: cost.Currency}
+	}
+	if cost.Budget.Valid {
+		metrics.RemainingBudget = &scalars.Money{Amount: cost.Budget.Decimal.Sub(cost.ActualSpend), Currency: cost.Currency}
+	}
+	return nil
+}
+
+// NewCampaign validates the input of createCampaign. New campaigns start as DRAFT.
+func NewCampaign(input generated.CreateCampaignInput) (*models.Campaign, error) {
+	campaign := &models.Campaign{
+		CampaignName:     input.CampaignName,
+		CampaignCountry:  input.CampaignCountry,
+		CampaignRegion:   input.CampaignRegion,
+		IndustryTargeted: input.IndustryTargeted,
+		Status:           models.CampaignStatusDraft,
+		StartDate:        input.StartDate,
+		EndDate:          input.EndDate,
+		Currency:         scalars.DefaultCurrency(),
+	}
+	if input.Budget != nil {
+		if input.Budget.Amount.IsNegative() {
+			return nil, errors.New("budget must not be negative")
+		}
+		campaign.Budget = decimal.NewNullDecimal(input.Budget.Amount)
+		campaign.Currency = input.Budget.Currency
+	}
+	if err := validateCampaignSchedule(campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// ApplyCampaignPatch copies the fields set in the input onto the campaign and returns the
+// columns that changed
+func ApplyCampaignPatch(campaign *models.Campaign, input generated.UpdateCampaignInput) ([]string, error) {
+	if campaign.Status == models.CampaignStatusArchived {
+		return nil, errors.New("archived campaigns cannot be changed")
+	}
+
+	var columns []string
+	text := func(field graphql.Omittable[*string], name, column string, target *string) error {
+		value, ok := field.ValueOK()
+		if !ok {
+			return nil
+		}
+		if value == nil || strings.TrimSpace(*value) == "" {
+			return fmt.Errorf("%s cannot be cleared", name)
+		}
+		*target = *value
+		columns = append(columns, column)
+		return nil
+	}
+	for _, err := range []error{
+		text(input.CampaignName, "campaignName", "campaign_name", &campaign.CampaignName),
+		text(input.CampaignCountry, "campaignCountry", "campaign_country", &campaign.CampaignCountry),
+		text(input.CampaignRegion, "campaignRegion", "campaign_region", &campaign.CampaignRegion),
+		text(input.IndustryTargeted, "industryTargeted", "industry_targeted", &campaign.IndustryTargeted),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if startDate, ok := input.StartDate.ValueOK(); ok {
+		campaign.StartDate = startDate
+		columns = append(columns, "start_date")
+	}
+	if endDate, ok := input.EndDate.ValueOK(); ok {
+		campaign.EndDate = endDate
+		columns = append(columns, "end_date")
+	}
+	if err := validateCampaignSchedule(campaign); err != nil {
+		return nil, err
+	}
+
+	budget, budgetSet := input.Budget.ValueOK()
+	spend, spendSet := input.ActualSpend.ValueOK()
+	if spendSet && spend == nil {
+		return nil, errors.New("actualSpend cannot be cleared")
+	}
+	if budget != nil && budget.Amount.IsNegative() {
+		return nil, errors.New("budget must not be negative")
+	}
+	if spend != nil && spend.Amount.IsNegative() {
+		return nil, errors.New("actualSpend must not be negative")
+	}
+
+	// Budget and spend share the currency of the campaign, an amount that is kept must be
+	// empty or zero for it to change
+	currency := campaign.Currency
+	if budget != nil {
+		currency = budget.Currency
+	}
+	if spend != nil {
+		if budget != nil && spend.Currency != budget.Currency {
+			return nil, errors.New("budget and actualSpend must be in the same currency")
+		}
+		currency = spend.Currency
+	}
+	if currency != campaign.Currency {
+		if !budgetSet && campaign.Budget.Valid && !campaign.Budget.Decimal.IsZero() {
+			return nil, fmt.Errorf("budget is in %s, change it in the same update to switch the currency", campaign.Currency)
+		}
+		if !spendSet && !campaign.ActualSpend.IsZero() {
+			return nil, fmt.Errorf("actualSpend is in %s, change it in the same update to switch the currency", campaign.Currency)
+		}
+		campaign.Currency = currency
+		columns = append(columns, "currency")
+	}
+
+	if budgetSet {
+		campaign.Budget = decimal.NullDecimal{}
+		if budget != nil {
+			campaign.Budget = decimal.NewNullDecimal(budget.Amount)
+		}
+		columns = append(columns, "budget")
+	}
+	if spendSet {
+		campaign.ActualSpend = spend.Amount
+		columns = append(columns, "actual_spend")
+	}
+	return columns, nil
+}
+
+// ChangeCampaignStatus moves the campaign to the status and returns the columns that changed.
+// Starting a campaign fills a missing start date, completing it a missing end date.
+func ChangeCampaignStatus(campaign *models.Campaign, status models.CampaignStatus) ([]string, error) {
+	if !campaign.Status.CanMoveTo(status) {
+		return nil, fmt.Errorf("campaign is %s and cannot become %s", campaign.Status, status)
+	}
+	columns := []string{"status"}
+	campaign.Status = status
+
+	today := time.Now().Truncate(24 * time.Hour)
+	if status == models.CampaignStatusActive && campaign.StartDate == nil {
+		campaign.StartDate = &today
+		columns = append(columns, "start_date")
+	}
+	if status == models.CampaignStatusCompleted && campaign.EndDate == nil {
+		campaign.EndDate = &today
+		columns = append(columns, "end_date")
+	}
+	if err := validateCampaignSchedule(campaign); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+func validateCampaignSchedule(campaign *models.Campaign) error {
+	if campaign.StartDate != nil && campaign.EndDate != nil && campaign.EndDate.Before(*campaign.StartDate) {
+		return errors.New("endDate must not be before startDate")
+	}
+	return nil
+}
+
+// CanManageCampaignMembers reports whether the current user may change the members of the
+// campaign: ADMIN and MANAGER may for every campaign, everybody else only as its OWNER.
+func CanManageCampaignMembers(ctx context.Context, campaign *models.Campaign) bool {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return false
+	}
+	if role, _ := claims["role"].(string); role == "ADMIN" || role == "MANAGER" {
+		return true
+	}
+	actorID := ActorID(ctx)
+	if actorID == nil {
+		return false
+	}
+	for _, member := range campaign.Members {
+		if member.UserID == *actorID && member.Role == models.CampaignRoleOwner {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCampaignMembers validates the input of setCampaignMembers
+func NewCampaignMembers(db *gorm.DB, campaign *models.Campaign, input []*generated.CampaignMemberInput) ([]models.CampaignUser, error) {
+	members := make([]models.CampaignUser, 0, len(input))
+	listed := map[uint]bool{}
+	userIDs := make([]uint, 0, len(input))
+	for _, member := range input {
+		id, err := strconv.ParseUint(member.UserID, 10, 64)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if listed[uint(id)] {
+			return nil, fmt.Errorf("user %s is listed more than once", member.UserID)
+		}
+		listed[uint(id)] = true
+		userIDs = append(userIDs, uint(id))
+		members = append(members, models.CampaignUser{CampaignID: campaign.ID, UserID: uint(id), Role: models.CampaignRole(member.Role)})
+	}
+	if len(userIDs) > 0 {
+		var found int64
+		if err := db.Model(&models.User{}).Where("id IN ?", userIDs).Count(&found).Error; err != nil {
+			return nil, err
+		}
+		if int(found) != len(userIDs) {
+			return nil, errors.New("user not found")
+		}
+	}
+	return members, nil
+}
+
+// ReplaceCampaignMembers makes the members of the campaign match the given ones. Members that
+// stay keep their join date, only their role is updated.
+func ReplaceCampaignMembers(tx *gorm.DB, campaign *models.Campaign, members []models.CampaignUser) error {
+	roles := make(map[uint]models.CampaignRole, len(members))
+	for _, member := range members {
+		roles[member.UserID] = member.Role
+	}
+
+	var removed []uint
+	for _, current := range campaign.Members {
+		role, ok := roles[current.UserID]
+		if !ok {
+			removed = append(removed, current.UserID)
+			continue
+		}
+		delete(roles, current.UserID)
+		if role != current.Role {
+			if err := tx.Model(&models.CampaignUser{}).
+				Where("campaign_id = ? AND user_id = ?", campaign.ID, current.UserID).
+				Update("role", role).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if len(removed) > 0 {
+		if err := tx.Where("campaign_id = ? AND user_id IN ?", campaign.ID, removed).
+			Delete(&models.CampaignUser{}).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, member := range members {
+		if _, added := roles[member.UserID]; !added {
+			continue
+		}
+		member.JoinedAt = now
+		if err := tx.Omit("User").Create(&member).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// openDB points the package at a scripted database for the duration of the test
+func openDB(t *testing.T) *testdb.DB {
+	t.Helper()
+	gdb, db := testdb.Open(t)
+	previous := initializers.DB
+	initializers.DB = gdb
+	t.Cleanup(func() { initializers.DB = previous })
+	return db
+}
+
+func TestAddCampaignCosts(t *testing.T) {
+	db := openDB(t)
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "campaigns"`, "won_deals"},
+		Columns:  []string{"budget", "actual_spend", "currency", "leads", "won_deals"},
+		Rows:     [][]driver.Value{{"5000", "1000", "EUR", int64(8), int64(3)}},
+	})
+
+	metrics := &generated.CampaignMetrics{}
+	if err := addCampaignCosts(withRole("MANAGER"), metrics, "2"); err != nil {
+		t.Fatal(err)
+	}
+	if metrics.CostPerLead.Amount.String() != "125" || metrics.CostPerWonDeal.Amount.String() != "333.33" || metrics.RemainingBudget.Amount.String() != "4000" {
+		t.Fatalf("unexpected costs %v, %v, %v", metrics.CostPerLead, metrics.CostPerWonDeal, metrics.RemainingBudget)
+	}
+
+	// Deals are won with their lead, their own status stays STARTED until the work is done
+	query := db.Statements(`FROM "campaigns"`, "won_deals")
+	if len(query) != 1 || !containsValue(query[0].Args, models.LeadStageClosedWon) {
+		t.Fatalf("won deals are not the deals of CLOSED_WON leads: %v", query)
+	}
+}
+
+func TestAddCampaignCostsHidesLeadCounts(t *testing.T) {
+	db := openDB(t)
+	db.On(testdb.Rule{
+		Contains: []string{`FROM "campaigns"`, "won_deals"},
+		Columns:  []string{"budget", "actual_spend", "currency", "leads", "won_deals"},
+		Rows:     [][]driver.Value{{"5000", "1000", "EUR", int64(8), int64(3)}},
+	})
+
+	metrics := &generated.CampaignMetrics{}
+	if err := addCampaignCosts(withRole("SALES_EXECUTIVE"), metrics, "2"); err != nil {
+		t.Fatal(err)
+	}
+	if metrics.CostPerLead != nil || metrics.CostPerWonDeal != nil {
+		t.Fatalf("costs over leads the caller may not see were returned: %v, %v", metrics.CostPerLead, metrics.CostPerWonDeal)
+	}
+	if metrics.RemainingBudget == nil {
+		t.Fatal("the remaining budget is missing")
+	}
+}
+
+// withRole returns a context authenticated as user 7 with the role
+func withRole(role string) context.Context {
+	return context.WithValue(context.Background(), auth.UserCtxKey, jwt.MapClaims{"user_id": "7", "role": role})
+}
+
+func containsValue(values []driver.Value, value driver.Value) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
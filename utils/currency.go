@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/shopspring/decimal"
+)
+
+// ConvertExchangeRate maps an exchange rate to its GraphQL shape
+func ConvertExchangeRate(rate *models.ExchangeRate) *generated.ExchangeRate {
+	return &generated.ExchangeRate{
+		ID:            rate.ID.String(),
+		FromCurrency:  rate.FromCurrency,
+		ToCurrency:    rate.ToCurrency,
+		Rate:          rate.Rate,
+		EffectiveDate: rate.EffectiveDate,
+		UpdatedBy:     ConvertUserSummary(rate.UpdatedBy),
+		UpdatedAt:     rate.UpdatedAt,
+	}
+}
+
+// datedRate is a rate into the base currency of a RateTable
+type datedRate struct {
+	effective time.Time
+	rate      decimal.Decimal
+}
+
+// RateTable converts amounts into one base currency
+type RateTable struct {
+	base string
+	// rates per source currency, latest first
+	rates map[string][]datedRate
+}
+
+// LoadRateTable loads every rate from or into the base currency. Rates into the base are
+// used as they are, rates from the base inverted.
+func LoadRateTable(base string) (*RateTable, error) {
+	var rows []models.ExchangeRate
+	if err := initializers.DB.Where("from_currency = ? OR to_currency = ?", base, base).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return newRateTable(base, rows), nil
+}
+
+func newRateTable(base string, rows []models.ExchangeRate) *RateTable {
+	table := &RateTable{base: base, rates: map[string][]datedRate{}}
+	for _, row := range rows {
+		if row.ToCurrency == base {
+			table.rates[row.FromCurrency] = append(table.rates[row.FromCurrency], datedRate{row.EffectiveDate, row.Rate})
+		}
+	}
+	// Inverted rates go after the direct ones so a direct rate wins on the same date
+	for _, row := range rows {
+		if row.FromCurrency == base {
+			table.rates[row.ToCurrency] = append(table.rates[row.ToCurrency], datedRate{row.EffectiveDate, decimal.NewFromInt(1).Div(row.Rate)})
+		}
+	}
+	for _, rates := range table.rates {
+		sort.SliceStable(rates, func(i, j int) bool { return rates[i].effective.After(rates[j].effective) })
+	}
+	return table
+}
+
+// Convert converts an amount with the rate effective on the given date. ok is false when
+// no rate was effective yet.
+func (t *RateTable) Convert(amount decimal.Decimal, currency string, on time.Time) (converted decimal.Decimal, ok bool) {
+	if currency == t.base {
+		return amount, true
+	}
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	for _, rate := range t.rates[currency] {
+		if !rate.effective.After(day) {
+			return amount.Mul(rate.rate), true
+		}
+	}
+	return decimal.Decimal{}, false
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/shopspring/decimal"
+)
+
+func exchangeRate(from, to, effective, rate string) models.ExchangeRate {
+	date, err := time.Parse(time.DateOnly, effective)
+	if err != nil {
+		panic(err)
+	}
+	return models.ExchangeRate{FromCurrency: from, ToCurrency: to, EffectiveDate: date, Rate: decimal.RequireFromString(rate)}
+}
+
+func TestRateTableConvert(t *testing.T) {
+	table := newRateTable("EUR", []models.ExchangeRate{
+		exchangeRate("USD", "EUR", "2026-01-01", "0.9"),
+		exchangeRate("USD", "EUR", "2026-03-01", "0.8"),
+		// Inverted to 0.5, the direct rate of the same day wins
+		exchangeRate("EUR", "USD", "2026-03-01", "2"),
+		// Only known from EUR, inverted
+		exchangeRate("EUR", "GBP", "2026-02-01", "0.8"),
+		// Neither from nor into the base
+		exchangeRate("USD", "GBP", "2025-01-01", "0.7"),
+	})
+
+	tests := []struct {
+		name     string
+		currency string
+		on       string
+		want     string
+		ok       bool
+	}{
+		{"base currency", "EUR", "2020-01-01", "100", true},
+		{"before the first rate", "USD", "2025-12-31", "", false},
+		{"on the effective date", "USD", "2026-01-01", "90", true},
+		{"between two rates", "USD", "2026-02-28", "90", true},
+		{"the later rate", "USD", "2026-03-01", "80", true},
+		{"after the last rate", "USD", "2027-06-30", "80", true},
+		{"inverted rate", "GBP", "2026-02-01", "125", true},
+		{"only a rate between other currencies", "GBP", "2026-01-31", "", false},
+		{"unknown currency", "JPY", "2026-03-01", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			on, _ := time.Parse(time.DateOnly, tt.on)
+			// The time of day does not matter
+			on = on.Add(23 * time.Hour)
+
+			converted, ok := table.Convert(decimal.NewFromInt(100), tt.currency, on)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !converted.Equal(decimal.RequireFromString(tt.want)) {
+				t.Fatalf("got %s, want %s", converted, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateTablePrefersDirectRatesRegardlessOfOrder(t *testing.T) {
+	table := newRateTable("EUR", []models.ExchangeRate{
+		exchangeRate("EUR", "USD", "2026-03-01", "2"),
+		exchangeRate("USD", "EUR", "2026-03-01", "0.8"),
+	})
+	converted, ok := table.Convert(decimal.NewFromInt(100), "USD", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if !ok || !converted.Equal(decimal.NewFromInt(80)) {
+		t.Fatalf("got %s, %v, want the direct rate", converted, ok)
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm"
+)
+
+// ConvertDeal maps a deal to its GraphQL shape
+func ConvertDeal(deal *models.Deals) *generated.Deal {
+	return &generated.Deal{
+		DealID:              fmt.Sprintf("%d", deal.ID),
+		DealName:            deal.DealName,
+		LeadID:              deal.LeadID,
+		DealStartDate:       deal.DealStartDate,
+		DealEndDate:         deal.DealEndDate,
+		ProjectRequirements: deal.ProjectRequirements,
+		DealAmount:          scalars.Money{Amount: deal.DealAmount, Currency: deal.DealCurrency},
+		DealStatus:          generated.DealStatus(deal.DealStatus),
+		ExpectedCloseDate:   deal.ExpectedCloseDate,
+		ClosedAt:            deal.ClosedAt,
+		CreatedAt:           deal.CreatedAt,
+		UpdatedAt:           deal.UpdatedAt,
+	}
+}
+
+// ConvertDeals maps a list of deals to their GraphQL shape
+func ConvertDeals(deals []models.Deals) []*generated.Deal {
+	result := make([]*generated.Deal, 0, len(deals))
+	for i := range deals {
+		result = append(result, ConvertDeal(&deals[i]))
+	}
+	return result
+}
+
+// DealScope returns a query on deals limited to the deals of leads the current user may see
+func DealScope(ctx context.Context) (*gorm.DB, error) {
+	leads, err := LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return initializers.DB.Model(&models.Deals{}).
+		Where("deals.lead_id IN (?)", leads.Select("leads.lead_id")), nil
+}
+
+// FindScopedDeal loads a single deal through DealScope. Deals outside the scope are
+// reported as not found so their existence is not revealed.
+func FindScopedDeal(ctx context.Context, dealID string) (*models.Deals, error) {
+	query, err := DealScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deal models.Deals
+	if err := query.First(&deal, "deals.id = ?", dealID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("deal not found")
+		}
+		return nil, err
+	}
+	return &deal, nil
+}
+
+// FindEditableDeal loads a deal like FindScopedDeal and makes sure the current user may
+// change it, which VIEWER members of the campaign of its lead may not
+func FindEditableDeal(ctx context.Context, dealID string) (*models.Deals, error) {
+	deal, err := FindScopedDeal(ctx, dealID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDealEditable(ctx, deal); err != nil {
+		return nil, err
+	}
+	return deal, nil
+}
+
+func checkDealEditable(ctx context.Context, deal *models.Deals) error {
+	query, err := EditableLeadScope(ctx)
+	if err != nil {
+		return err
+	}
+	var editable int64
+	if err := query.Where("leads.lead_id = ?", deal.LeadID).Count(&editable).Error; err != nil {
+		return err
+	}
+	if editable == 0 {
+		return errors.New("campaign viewers cannot change the deals of its leads")
+	}
+	return nil
+}
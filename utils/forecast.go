@@ -0,0 +1,51 @@
+package utils
+
+import (
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+// DefaultWinProbabilities apply to open deal statuses that have no configured probability
+var DefaultWinProbabilities = map[models.DealStatus]int{
+	models.DealStatusStarted: 20,
+	models.DealStatusPending: 50,
+}
+
+// WinProbabilities returns the win probability, in percent, of every open deal status
+func WinProbabilities() ([]models.DealStageProbability, error) {
+	var configured []models.DealStageProbability
+	if err := initializers.DB.Find(&configured).Error; err != nil {
+		return nil, err
+	}
+	byStatus := map[models.DealStatus]models.DealStageProbability{}
+	for _, probability := range configured {
+		byStatus[probability.DealStatus] = probability
+	}
+
+	result := []models.DealStageProbability{}
+	for _, status := range generated.AllDealStatus {
+		dealStatus := models.DealStatus(status)
+		if dealStatus.IsClosed() {
+			continue
+		}
+		probability, ok := byStatus[dealStatus]
+		if !ok {
+			probability = models.DealStageProbability{DealStatus: dealStatus, Probability: DefaultWinProbabilities[dealStatus]}
+		}
+		result = append(result, probability)
+	}
+	return result, nil
+}
+
+// ConvertDealStageProbability maps a win probability to its GraphQL shape
+func ConvertDealStageProbability(probability *models.DealStageProbability) *generated.DealStageProbability {
+	result := &generated.DealStageProbability{
+		DealStatus:     generated.DealStatus(probability.DealStatus),
+		WinProbability: int32(probability.Probability),
+	}
+	if !probability.UpdatedAt.IsZero() {
+		result.UpdatedAt = &probability.UpdatedAt
+	}
+	return result
+}
@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/auth"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+	"gorm.io/gorm"
+)
+
+// LeadScope returns a query on leads limited to the ones the current user may see.
+// ADMIN and MANAGER see every lead, everybody else only the leads they created, are
+// assigned to or that belong to a campaign they are a member of, in any role.
+func LeadScope(ctx context.Context) (*gorm.DB, error) {
+	return leadScope(ctx, []models.CampaignRole{models.CampaignRoleOwner, models.CampaignRoleMember, models.CampaignRoleViewer})
+}
+
+// EditableLeadScope is LeadScope without the leads the current user only sees as a VIEWER
+// of their campaign
+func EditableLeadScope(ctx context.Context) (*gorm.DB, error) {
+	return leadScope(ctx, []models.CampaignRole{models.CampaignRoleOwner, models.CampaignRoleMember})
+}
+
+// leadScope limits leads to the ones the current user created, is assigned to or that belong
+// to a campaign they are a member of in one of the roles
+func leadScope(ctx context.Context, campaignRoles []models.CampaignRole) (*gorm.DB, error) {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil, errors.New("unauthorized")
+	}
+
+	query := initializers.DB.Model(&models.Lead{})
+
+	role, _ := claims["role"].(string)
+	if role == "ADMIN" || role == "MANAGER" {
+		return query, nil
+	}
+
+	userID, ok := claims["user_id"].(string)
+	if !ok || userID == "" {
+		return nil, errors.New("failed to extract user ID from JWT")
+	}
+
+	return query.Where(
+		"(leads.lead_created_by = ? OR leads.lead_assigned_to = ? OR leads.campaign_id IN (SELECT CAST(campaign_users.campaign_id AS TEXT) FROM campaign_users WHERE campaign_users.user_id = ? AND campaign_users.role IN ?))",
+		userID, userID, userID, campaignRoles,
+	), nil
+}
+
+// FindScopedLead loads a single lead through LeadScope. Leads outside the scope are
+// reported as not found so their existence is not revealed.
+func FindScopedLead(ctx context.Context, leadID string, preloads ...string) (*models.Lead, error) {
+	query, err := LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	var lead models.Lead
+	if err := query.First(&lead, "leads.lead_id = ?", leadID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("lead not found")
+		}
+		return nil, err
+	}
+	return &lead, nil
+}
+
+// FindEditableLead loads a lead like FindScopedLead and makes sure the current user may
+// change it, which VIEWER members of its campaign may not
+func FindEditableLead(ctx context.Context, leadID string, preloads ...string) (*models.Lead, error) {
+	lead, err := FindScopedLead(ctx, leadID, preloads...)
+	if err != nil {
+		return nil, err
+	}
+	query, err := EditableLeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var editable int64
+	if err := query.Where("leads.lead_id = ?", leadID).Count(&editable).Error; err != nil {
+		return nil, err
+	}
+	if editable == 0 {
+		return nil, errors.New("campaign viewers cannot change its leads")
+	}
+	return lead, nil
+}
+
+// ActorID returns the ID of the authenticated user for audit records, nil if there is none
+func ActorID(ctx context.Context) *uint {
+	claims, ok := auth.GetUserFromJWT(ctx)
+	if !ok {
+		return nil
+	}
+	userID, _ := claims["user_id"].(string)
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	actorID := uint(id)
+	return &actorID
+}
+
+// RecordInitialStage writes the first stage history entry of a newly created lead
+func RecordInitialStage(tx *gorm.DB, lead *models.Lead, actorID *uint) error {
+	return tx.Create(&models.LeadStageHistory{
+		LeadID:      lead.LeadID,
+		ToStage:     lead.LeadStage,
+		ChangedByID: actorID,
+		ChangedAt:   time.Now(),
+	}).Error
+}
+
+// ChangeLeadStage moves the lead to the stage and records the change in its history. Every
+// stage change goes through here so won leads always get a deal. The change must have been
+// validated with the LeadPipeline before.
+func ChangeLeadStage(tx *gorm.DB, lead *models.Lead, to models.LeadStage, reason string, actorID *uint) error {
+	from := lead.LeadStage
+	if err := tx.Model(lead).Update("lead_stage", to).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.LeadStageHistory{
+		LeadID:      lead.LeadID,
+		FromStage:   &from,
+		ToStage:     to,
+		Reason:      reason,
+		ChangedByID: actorID,
+		ChangedAt:   time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+
+	if to != models.LeadStageClosedWon {
+		return nil
+	}
+	return createDealForWonLead(tx, lead)
+}
+
+// ConvertLead maps a lead with its preloaded creator, assignee, assignment rule, organization,
+// campaign, activities and deals to its GraphQL shape
+func ConvertLead(lead *models.Lead) *generated.Lead {
+	result := &generated.Lead{
+		LeadID:             lead.LeadID,
+		FirstName:          lead.FirstName,
+		LastName:           lead.LastName,
+		Email:              lead.Email,
+		LinkedIn:           lead.LinkedIn,
+		Country:            lead.Country,
+		Phone:              lead.Phone,
+		LeadSource:         lead.LeadSource,
+		InitialContactDate: lead.InitialContactDate,
+		LeadCreatedBy:      ConvertUserSummary(&lead.Creator),
+		LeadStage:          generated.LeadStage(lead.LeadStage),
+		LeadNotes:          lead.LeadNotes,
+		LeadPriority:       lead.LeadPriority,
+		Activities:         make([]*generated.Activity, 0, len(lead.Activities)),
+		Deals:              ConvertDeals(lead.Deals),
+	}
+	if lead.Creator.ID == 0 {
+		result.LeadCreatedBy = &generated.User{UserID: lead.LeadCreatedBy}
+	}
+	if lead.LeadAssignedTo != "" {
+		result.LeadAssignedTo = ConvertUserSummary(&lead.Assignee)
+	}
+	if lead.OrganizationID != "" && lead.Organization.ID != 0 {
+		result.Organization = ConvertOrganization(&lead.Organization)
+	}
+	if lead.AssignmentRule != nil {
+		result.AssignmentRule = ConvertAssignmentRule(lead.AssignmentRule)
+	}
+	result.Campaign = ConvertCampaign(&lead.Campaign)
+	result.Campaign.CampaignID = lead.CampaignID
+	for _, activity := range lead.Activities {
+		result.Activities = append(result.Activities, &generated.Activity{
+			ActivityID:           activity.ActivityID,
+			LeadID:               activity.LeadID,
+			ActivityType:         activity.ActivityType,
+			DateTime:             activity.DateTime,
+			CommunicationChannel: activity.CommunicationChannel,
+			ContentNotes:         activity.ContentNotes,
+			ParticipantDetails:   activity.ParticipantDetails,
+			FollowUpActions:      activity.FollowUpActions,
+		})
+	}
+	return result
+}
+
+// LeadPreloads are the relations ConvertLead maps, for FindScopedLead
+var LeadPreloads = []string{"Creator", "Assignee", "AssignmentRule", "Organization", "Campaign", "Activities", "Deals"}
+
+// PreloadLead preloads everything ConvertLead maps
+func PreloadLead(db *gorm.DB) *gorm.DB {
+	for _, preload := range LeadPreloads {
+		db = db.Preload(preload)
+	}
+	return db
+}
+
+// LoadLead loads a lead with everything ConvertLead maps
+func LoadLead(db *gorm.DB, leadID string) (*models.Lead, error) {
+	var lead models.Lead
+	err := PreloadLead(db).First(&lead, "lead_id = ?", leadID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lead, nil
+}
+
+// ApplyLeadPatch copies the fields set in the input onto the lead and returns the columns that
+// changed. The stage is left alone, it is changed with ChangeLeadStage. Referenced users,
+// organizations and campaigns are checked the way CreateLead checks them.
+func ApplyLeadPatch(db *gorm.DB, lead *models.Lead, input generated.UpdateLeadInput) ([]string, error) {
+	var columns []string
+	text := func(field graphql.Omittable[*string], name, column string, target *string, required bool) error {
+		value, ok := field.ValueOK()
+		if !ok {
+			return nil
+		}
+		if value == nil || strings.TrimSpace(*value) == "" {
+			if required {
+				return fmt.Errorf("%s cannot be cleared", name)
+			}
+			*target = ""
+		} else {
+			*target = *value
+		}
+		columns = append(columns, column)
+		return nil
+	}
+	for _, err := range []error{
+		text(input.FirstName, "firstName", "first_name", &lead.FirstName, true),
+		text(input.LastName, "lastName", "last_name", &lead.LastName, true),
+		text(input.Email, "email", "email", &lead.Email, true),
+		text(input.LinkedIn, "linkedIn", "linked_in", &lead.LinkedIn, false),
+		text(input.Country, "country", "country", &lead.Country, false),
+		text(input.Phone, "phone", "phone", &lead.Phone, false),
+		text(input.LeadSource, "leadSource", "lead_source", &lead.LeadSource, true),
+		text(input.InitialContactDate, "initialContactDate", "initial_contact_date", &lead.InitialContactDate, false),
+		text(input.LeadNotes, "leadNotes", "lead_notes", &lead.LeadNotes, false),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if priority, ok := input.LeadPriority.ValueOK(); ok {
+		if priority == nil {
+			return nil, errors.New("leadPriority cannot be cleared")
+		}
+		lead.LeadPriority = priority.String()
+		columns = append(columns, "lead_priority")
+	}
+
+	// A manual assignment replaces the rule that assigned the lead, if any
+	if assignee, ok := input.LeadAssignedTo.ValueOK(); ok {
+		lead.LeadAssignedTo = ""
+		lead.Assignee = models.User{}
+		lead.AssignmentRuleID = nil
+		lead.AssignmentRule = nil
+		if assignee != nil && *assignee != "" {
+			if err := findReference(db, &lead.Assignee, *assignee, "assigned user not found"); err != nil {
+				return nil, err
+			}
+			lead.LeadAssignedTo = *assignee
+		}
+		columns = append(columns, "lead_assigned_to", "assignment_rule_id")
+	}
+	if organizationID, ok := input.OrganizationID.ValueOK(); ok {
+		lead.OrganizationID = ""
+		lead.Organization = models.Organization{}
+		if organizationID != nil && *organizationID != "" {
+			if err := findReference(db, &lead.Organization, *organizationID, "organization not found"); err != nil {
+				return nil, err
+			}
+			lead.OrganizationID = *organizationID
+		}
+		columns = append(columns, "organization_id")
+	}
+	if campaignID, ok := input.CampaignID.ValueOK(); ok {
+		if campaignID == nil || *campaignID == "" {
+			return nil, errors.New("campaignID cannot be cleared")
+		}
+		if err := findReference(db, &lead.Campaign, *campaignID, "campaign not found"); err != nil {
+			return nil, err
+		}
+		lead.CampaignID = *campaignID
+		columns = append(columns, "campaign_id")
+	}
+	return columns, nil
+}
+
+// findReference loads the record a lead references by its ID as text
+func findReference(db *gorm.DB, dest interface{}, id string, notFound string) error {
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return errors.New(notFound)
+	}
+	if err := db.First(dest, parsed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(notFound)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"slices"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/google/uuid"
+)
+
+func TestApplyLeadPatchReplacesTheAssignmentRule(t *testing.T) {
+	assignee := "8"
+	tests := []struct {
+		name     string
+		assignee *string
+	}{
+		{"reassigned", &assignee},
+		{"unassigned", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gdb, db := testdb.Open(t)
+			db.On(testdb.Rule{Contains: []string{`FROM "users"`}, Columns: []string{"id", "name"}, Rows: [][]driver.Value{{int64(8), "Sam"}}})
+			ruleID := uuid.New()
+			lead := &models.Lead{LeadID: "lead-1", LeadAssignedTo: "7", AssignmentRuleID: &ruleID, AssignmentRule: &models.AssignmentRule{}}
+
+			columns, err := ApplyLeadPatch(gdb, lead, generated.UpdateLeadInput{LeadAssignedTo: graphql.OmittableOf(tt.assignee)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lead.AssignmentRuleID != nil || lead.AssignmentRule != nil {
+				t.Fatal("the lead still refers to the rule that assigned it")
+			}
+			if !slices.Contains(columns, "lead_assigned_to") || !slices.Contains(columns, "assignment_rule_id") {
+				t.Fatalf("columns %v do not save the assignment", columns)
+			}
+			if tt.assignee != nil && lead.LeadAssignedTo != assignee {
+				t.Fatalf("assigned to %q, want %q", lead.LeadAssignedTo, assignee)
+			}
+		})
+	}
+}
+
+func TestApplyLeadPatchKeepsTheAssignmentRule(t *testing.T) {
+	gdb, _ := testdb.Open(t)
+	ruleID := uuid.New()
+	firstName := "Jane"
+	lead := &models.Lead{LeadID: "lead-1", LeadAssignedTo: "7", AssignmentRuleID: &ruleID}
+
+	columns, err := ApplyLeadPatch(gdb, lead, generated.UpdateLeadInput{FirstName: graphql.OmittableOf(&firstName)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lead.AssignmentRuleID == nil || slices.Contains(columns, "assignment_rule_id") {
+		t.Fatal("a change that does not touch the assignee dropped the assignment rule")
+	}
+}
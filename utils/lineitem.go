@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ConvertResourceProfileSummary maps a resource profile without its vendor and past projects
+func ConvertResourceProfileSummary(profile *models.ResourceProfile) *generated.ResourceProfile {
+	if profile == nil {
+		return nil
+	}
+	result := &generated.ResourceProfile{
+		ID:                 profile.ID.String(),
+		CreatedAt:          profile.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          profile.UpdatedAt.Format(time.RFC3339),
+		Type:               generated.ResourceType(profile.Type),
+		FirstName:          profile.FirstName,
+		LastName:           profile.LastName,
+		TotalExperience:    profile.TotalExperience,
+		ContactInformation: string(profile.ContactInformation),
+		GoogleDriveLink:    profile.GoogleDriveLink,
+		Status:             generated.ResourceStatus(profile.Status),
+		Skills:             ConvertSkills(profile.Skills),
+		PastProjects:       []*generated.PastProject{},
+	}
+	if profile.VendorID != nil {
+		vendorID := profile.VendorID.String()
+		result.VendorID = &vendorID
+	}
+	return result
+}
+
+// ConvertDealLineItem maps a line item to its GraphQL shape, in the currency of its deal
+func ConvertDealLineItem(item *models.DealLineItem, currency string) *generated.DealLineItem {
+	result := &generated.DealLineItem{
+		ID:              item.ID.String(),
+		DealID:          fmt.Sprintf("%d", item.DealID),
+		ResourceProfile: ConvertResourceProfileSummary(item.ResourceProfile),
+		Skills:          ConvertSkills(item.Skills),
+		Rate:            scalars.Money{Amount: item.Rate, Currency: currency},
+		Unit:            generated.LineItemUnit(item.Unit),
+		Quantity:        item.Quantity,
+		Total:           scalars.Money{Amount: item.Total(), Currency: currency},
+		StartDate:       item.StartDate,
+		EndDate:         item.EndDate,
+	}
+	if item.Role != "" {
+		result.Role = &item.Role
+	}
+	if item.Description != "" {
+		result.Description = &item.Description
+	}
+	return result
+}
+
+// FindScopedDealLineItem loads a line item together with its deal, which must be within DealScope
+func FindScopedDealLineItem(ctx context.Context, id string) (*models.DealLineItem, *models.Deals, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, errors.New("deal line item not found")
+	}
+	var item models.DealLineItem
+	if err := initializers.DB.Preload("ResourceProfile.Skills").Preload("Skills").First(&item, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("deal line item not found")
+		}
+		return nil, nil, err
+	}
+	deal, err := FindScopedDeal(ctx, fmt.Sprintf("%d", item.DealID))
+	if err != nil {
+		return nil, nil, errors.New("deal line item not found")
+	}
+	return &item, deal, nil
+}
+
+// FindEditableDealLineItem loads a line item like FindScopedDealLineItem and makes sure the
+// current user may change its deal
+func FindEditableDealLineItem(ctx context.Context, id string) (*models.DealLineItem, *models.Deals, error) {
+	item, deal, err := FindScopedDealLineItem(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkDealEditable(ctx, deal); err != nil {
+		return nil, nil, err
+	}
+	return item, deal, nil
+}
+
+// BuildDealLineItem validates the input against the deal and fills the line item with it
+func BuildDealLineItem(tx *gorm.DB, deal *models.Deals, input generated.DealLineItemInput, item *models.DealLineItem) error {
+	if input.Rate.Currency != deal.DealCurrency {
+		return fmt.Errorf("rate must be in the currency of the deal, %s", deal.DealCurrency)
+	}
+	if input.Rate.Amount.IsNegative() {
+		return errors.New("rate must not be negative")
+	}
+	if !input.Quantity.IsPositive() {
+		return errors.New("quantity must be positive")
+	}
+	if input.StartDate != nil && input.EndDate != nil && input.EndDate.Before(*input.StartDate) {
+		return errors.New("endDate must not be before startDate")
+	}
+
+	role := ""
+	if input.Role != nil {
+		role = strings.TrimSpace(*input.Role)
+	}
+	item.ResourceProfileID = nil
+	item.ResourceProfile = nil
+	if input.ResourceProfileID != nil {
+		profileID, err := uuid.Parse(*input.ResourceProfileID)
+		if err != nil {
+			return errors.New("resource profile not found")
+		}
+		var profile models.ResourceProfile
+		if err := tx.Preload("Skills").First(&profile, "id = ?", profileID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("resource profile not found")
+			}
+			return err
+		}
+		item.ResourceProfileID = &profile.ID
+		item.ResourceProfile = &profile
+	} else if role == "" {
+		return errors.New("either resourceProfileID or role is required")
+	}
+
+	item.Skills = nil
+	if len(input.SkillIDs) > 0 {
+		skillIDs := make([]uuid.UUID, 0, len(input.SkillIDs))
+		for _, skillID := range input.SkillIDs {
+			id, err := uuid.Parse(skillID)
+			if err != nil {
+				return errors.New("skill not found")
+			}
+			skillIDs = append(skillIDs, id)
+		}
+		skillIDs = uniqueUUIDs(skillIDs)
+		if err := tx.Where("id IN ?", skillIDs).Find(&item.Skills).Error; err != nil {
+			return err
+		}
+		if len(item.Skills) != len(skillIDs) {
+			return errors.New("skill not found")
+		}
+	}
+
+	item.DealID = deal.ID
+	item.Role = role
+	item.Rate = input.Rate.Amount
+	item.Unit = models.LineItemUnit(input.Unit)
+	item.Quantity = input.Quantity
+	item.StartDate = input.StartDate
+	item.EndDate = input.EndDate
+	item.Description = ""
+	if input.Description != nil {
+		item.Description = strings.TrimSpace(*input.Description)
+	}
+	return nil
+}
+
+// RecalculateDealAmount sets the amount of the deal to the total of its line items
+func RecalculateDealAmount(tx *gorm.DB, deal *models.Deals) error {
+	var total decimal.Decimal
+	if err := tx.Model(&models.DealLineItem{}).Where("deal_id = ?", deal.ID).
+		Select("COALESCE(SUM(rate * quantity), 0)").Scan(&total).Error; err != nil {
+		return err
+	}
+	deal.DealAmount = total
+	return tx.Model(deal).Update("deal_amount", total).Error
+}
+
+func uniqueUUIDs(values []uuid.UUID) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	result := []uuid.UUID{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
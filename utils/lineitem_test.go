@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/internal/testdb"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestBuildDealLineItemIgnoresDuplicateSkills(t *testing.T) {
+	gdb, db := testdb.Open(t)
+	skillID := uuid.New()
+	db.On(testdb.Rule{Contains: []string{`FROM "skills"`}, Columns: []string{"id", "name"}, Rows: [][]driver.Value{{skillID.String(), "Go"}}})
+
+	role := "Backend developer"
+	input := generated.DealLineItemInput{
+		Role: &role,
+		// The same skill twice, once in upper case
+		SkillIDs: []string{skillID.String(), strings.ToUpper(skillID.String())},
+		Rate:     scalars.Money{Amount: decimal.NewFromInt(80), Currency: "EUR"},
+		Unit:     generated.LineItemUnitHour,
+		Quantity: decimal.NewFromInt(10),
+	}
+	var item models.DealLineItem
+	if err := BuildDealLineItem(gdb, &models.Deals{DealCurrency: "EUR"}, input, &item); err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Skills) != 1 || item.Skills[0].ID != skillID {
+		t.Fatalf("got skills %v, want the one skill", item.Skills)
+	}
+}
+
+func TestBuildDealLineItemRejectsUnknownSkills(t *testing.T) {
+	gdb, db := testdb.Open(t)
+	skillID := uuid.New()
+	db.On(testdb.Rule{Contains: []string{`FROM "skills"`}, Columns: []string{"id", "name"}, Rows: [][]driver.Value{{skillID.String(), "Go"}}})
+
+	role := "Backend developer"
+	input := generated.DealLineItemInput{
+		Role:     &role,
+		SkillIDs: []string{skillID.String(), uuid.NewString()},
+		Rate:     scalars.Money{Amount: decimal.NewFromInt(80), Currency: "EUR"},
+		Unit:     generated.LineItemUnitHour,
+		Quantity: decimal.NewFromInt(10),
+	}
+	err := BuildDealLineItem(gdb, &models.Deals{DealCurrency: "EUR"}, input, &models.DealLineItem{})
+	if err == nil || err.Error() != "skill not found" {
+		t.Fatalf("expected skill not found, got %v", err)
+	}
+}
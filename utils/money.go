@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/shopspring/decimal"
+)
+
+// OrganizationRevenue returns the annual revenue of an organization, nil when it is unknown
+func OrganizationRevenue(org *models.Organization) *scalars.Money {
+	if !org.AnnualRevenue.Valid {
+		return nil
+	}
+	return &scalars.Money{Amount: org.AnnualRevenue.Decimal, Currency: org.AnnualRevenueCurrency}
+}
+
+// SetOrganizationRevenue stores an optional revenue on the organization
+func SetOrganizationRevenue(org *models.Organization, revenue *scalars.Money) {
+	if revenue == nil {
+		org.AnnualRevenue = decimal.NullDecimal{}
+		org.AnnualRevenueCurrency = ""
+		return
+	}
+	org.AnnualRevenue = decimal.NewNullDecimal(revenue.Amount)
+	org.AnnualRevenueCurrency = revenue.Currency
+}
+
+// ValidateDealDates rejects a deal that ends before it starts
+func ValidateDealDates(start, end *time.Time) error {
+	if start != nil && end != nil && end.Before(*start) {
+		return errors.New("dealEndDate must not be before dealStartDate")
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ConvertOrganization maps an organization to its GraphQL shape. The parent, subsidiaries,
+// leads and rollup have their own resolvers.
+func ConvertOrganization(organization *models.Organization) *generated.Organization {
+	result := &generated.Organization{
+		ID:                fmt.Sprintf("%d", organization.ID),
+		OrganizationName:  organization.OrganizationName,
+		OrganizationEmail: organization.OrganizationEmail,
+		City:              organization.City,
+		Country:           organization.Country,
+		NoOfEmployees:     organization.NoOfEmployees,
+		AnnualRevenue:     OrganizationRevenue(organization),
+		CreatedAt:         organization.CreatedAt,
+		UpdatedAt:         organization.UpdatedAt,
+	}
+	if organization.OrganizationWebsite != "" {
+		website := organization.OrganizationWebsite
+		result.OrganizationWebsite = &website
+	}
+	if organization.ParentID != nil {
+		parentID := fmt.Sprintf("%d", *organization.ParentID)
+		result.ParentID = &parentID
+	}
+	return result
+}
+
+// FindOrganization loads an organization by its ID
+func FindOrganization(db *gorm.DB, organizationID string) (*models.Organization, error) {
+	id, err := strconv.ParseUint(organizationID, 10, 64)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	var organization models.Organization
+	if err := db.First(&organization, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// FindParentOrganization loads the organization that is to become the parent of the
+// organization and makes sure that does not make the hierarchy circular
+func FindParentOrganization(db *gorm.DB, organization *models.Organization, parentID string) (*models.Organization, error) {
+	var parent models.Organization
+	if err := findReference(db, &parent, parentID, "parent organization not found"); err != nil {
+		return nil, err
+	}
+	if organization.ID == 0 {
+		return &parent, nil
+	}
+	if parent.ID == organization.ID {
+		return nil, errors.New("an organization cannot be its own parent")
+	}
+	var circular int64
+	if err := db.Model(&models.Organization{}).
+		Where("id = ? AND id IN (?)", parent.ID, organizationTree(db, organization.ID)).
+		Count(&circular).Error; err != nil {
+		return nil, err
+	}
+	if circular > 0 {
+		return nil, errors.New("an organization cannot be a subsidiary of one of its subsidiaries")
+	}
+	return &parent, nil
+}
+
+// ApplyOrganizationPatch copies the fields set in the input onto the organization and returns
+// the columns that changed
+func ApplyOrganizationPatch(db *gorm.DB, organization *models.Organization, input generated.UpdateOrganizationInput) ([]string, error) {
+	var columns []string
+	text := func(field graphql.Omittable[*string], name, column string, target *string, required bool) error {
+		value, ok := field.ValueOK()
+		if !ok {
+			return nil
+		}
+		if value == nil || strings.TrimSpace(*value) == "" {
+			if required {
+				return fmt.Errorf("%s cannot be cleared", name)
+			}
+			*target = ""
+		} else {
+			*target = *value
+		}
+		columns = append(columns, column)
+		return nil
+	}
+	for _, err := range []error{
+		text(input.OrganizationName, "organizationName", "organization_name", &organization.OrganizationName, true),
+		text(input.OrganizationEmail, "organizationEmail", "organization_email", &organization.OrganizationEmail, true),
+		text(input.OrganizationWebsite, "organizationWebsite", "organization_website", &organization.OrganizationWebsite, false),
+		text(input.City, "city", "city", &organization.City, true),
+		text(input.Country, "country", "country", &organization.Country, true),
+		text(input.NoOfEmployees, "noOfEmployees", "no_of_employees", &organization.NoOfEmployees, true),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if revenue, ok := input.AnnualRevenue.ValueOK(); ok {
+		SetOrganizationRevenue(organization, revenue)
+		columns = append(columns, "annual_revenue", "annual_revenue_currency")
+	}
+	if parentID, ok := input.ParentID.ValueOK(); ok {
+		organization.ParentID = nil
+		if parentID != nil && *parentID != "" {
+			parent, err := FindParentOrganization(db, organization, *parentID)
+			if err != nil {
+				return nil, err
+			}
+			organization.ParentID = &parent.ID
+		}
+		columns = append(columns, "parent_id")
+	}
+	return columns, nil
+}
+
+// DeleteOrganization moves the subsidiaries of the organization up to its parent, moves its
+// leads to reassignTo, or leaves them without organization when it is nil, and deletes it
+func DeleteOrganization(tx *gorm.DB, organization *models.Organization, reassignTo *models.Organization) error {
+	organizationID := ""
+	if reassignTo != nil {
+		organizationID = fmt.Sprintf("%d", reassignTo.ID)
+	}
+	if err := tx.Model(&models.Lead{}).Where("organization_id = ?", fmt.Sprintf("%d", organization.ID)).
+		Update("organization_id", organizationID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Organization{}).Where("parent_id = ?", organization.ID).
+		Update("parent_id", organization.ParentID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(organization).Error
+}
+
+// OrganizationRollup aggregates the organization and all its subsidiaries, recursively, with
+// the leads among them visible to the caller and their deals
+func OrganizationRollup(ctx context.Context, organizationID string) (*generated.OrganizationRollup, error) {
+	id, err := strconv.ParseUint(organizationID, 10, 64)
+	if err != nil {
+		return nil, errors.New("organization not found")
+	}
+	tree := organizationTree(initializers.DB, uint(id))
+
+	var organizations int64
+	if err := initializers.DB.Model(&models.Organization{}).Where("id IN (?)", tree).Count(&organizations).Error; err != nil {
+		return nil, fmt.Errorf("failed to count organizations: %w", err)
+	}
+
+	var revenueRows []struct {
+		Currency string
+		Total    decimal.Decimal
+	}
+	if err := initializers.DB.Model(&models.Organization{}).
+		Where("id IN (?) AND annual_revenue IS NOT NULL", tree).
+		Select("annual_revenue_currency AS currency, SUM(annual_revenue) AS total").
+		Group("annual_revenue_currency").Order("annual_revenue_currency").Scan(&revenueRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum annual revenues: %w", err)
+	}
+
+	scoped, err := LeadScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Leads reference their organization by its ID as text
+	scoped = scoped.Where("leads.organization_id IN (?)", initializers.DB.Table("(?) AS tree", tree).Select("CAST(tree.id AS TEXT)")).
+		Select("leads.lead_id, leads.lead_stage")
+
+	var leadRows []struct {
+		Leads     int64
+		OpenLeads int64
+		WonLeads  int64
+	}
+	if err := initializers.DB.Table("(?) AS scoped", scoped).
+		Select("COUNT(*) AS leads, "+
+			"COUNT(*) FILTER (WHERE lead_stage NOT IN ?) AS open_leads, "+
+			"COUNT(*) FILTER (WHERE lead_stage = ?) AS won_leads",
+			[]models.LeadStage{models.LeadStageClosedWon, models.LeadStageClosedLost}, models.LeadStageClosedWon).
+		Scan(&leadRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count leads: %w", err)
+	}
+
+	var dealRows []struct {
+		Currency string
+		Deals    int64
+		Total    decimal.Decimal
+	}
+	if err := initializers.DB.Table("deals").
+		Joins("JOIN (?) AS scoped ON scoped.lead_id = deals.lead_id", scoped).
+		Where("deals.deleted_at IS NULL").
+		Select("deals.deal_currency AS currency, COUNT(*) AS deals, SUM(deals.deal_amount) AS total").
+		Group("deals.deal_currency").Order("deals.deal_currency").Scan(&dealRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum deals: %w", err)
+	}
+
+	rollup := &generated.OrganizationRollup{
+		Organizations: int32(organizations),
+		DealValue:     make([]*scalars.Money, 0, len(dealRows)),
+		AnnualRevenue: make([]*scalars.Money, 0, len(revenueRows)),
+	}
+	if len(leadRows) > 0 {
+		rollup.Leads = int32(leadRows[0].Leads)
+		rollup.OpenLeads = int32(leadRows[0].OpenLeads)
+		rollup.WonLeads = int32(leadRows[0].WonLeads)
+	}
+	for _, row := range dealRows {
+		rollup.Deals += int32(row.Deals)
+		rollup.DealValue = append(rollup.DealValue, &scalars.Money{Amount: row.Total, Currency: row.Currency})
+	}
+	for _, row := range revenueRows {
+		rollup.AnnualRevenue = append(rollup.AnnualRevenue, &scalars.Money{Amount: row.Total, Currency: row.Currency})
+	}
+	return rollup, nil
+}
+
+// organizationTree selects the ID of the organization and of all its subsidiaries, recursively.
+// UNION drops the organizations already visited, so the query ends even if concurrent
+// updates ever made the hierarchy circular.
+func organizationTree(db *gorm.DB, organizationID uint) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE tree AS (
+		SELECT id FROM organizations WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT organizations.id FROM organizations JOIN tree ON organizations.parent_id = tree.id
+		WHERE organizations.deleted_at IS NULL
+	) SELECT id FROM tree`, organizationID)
+}
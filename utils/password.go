@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"unicode"
+)
+
+const minPasswordLength = 6
+
+// ValidatePassword enforces the password policy: at least 6 characters with one
+// uppercase letter, one lowercase letter, one number and one special character.
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return errors.New("password must be at least 6 characters long")
+	}
+
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special = true
+		}
+	}
+
+	if !upper || !lower || !digit || !special {
+		return errors.New("password must contain one uppercase letter, one lowercase letter, one number and one special character")
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+// Stage requirements a lead must meet before it may enter a stage
+const (
+	RequireReason       = "reason"
+	RequireAssignee     = "leadAssignedTo"
+	RequireOrganization = "organization"
+	RequireEmail        = "email"
+	RequirePhone        = "phone"
+	RequireNotes        = "leadNotes"
+)
+
+var stageRequirements = map[string]func(lead *models.Lead, reason string) bool{
+	RequireReason:       func(lead *models.Lead, reason string) bool { return strings.TrimSpace(reason) != "" },
+	RequireAssignee:     func(lead *models.Lead, reason string) bool { return lead.LeadAssignedTo != "" },
+	RequireOrganization: func(lead *models.Lead, reason string) bool { return lead.OrganizationID != "" },
+	RequireEmail:        func(lead *models.Lead, reason string) bool { return strings.TrimSpace(lead.Email) != "" },
+	RequirePhone:        func(lead *models.Lead, reason string) bool { return strings.TrimSpace(lead.Phone) != "" },
+	RequireNotes:        func(lead *models.Lead, reason string) bool { return strings.TrimSpace(lead.LeadNotes) != "" },
+}
+
+// StageRule lists the stages a lead may move to from a stage and what it needs to enter it
+type StageRule struct {
+	Transitions []models.LeadStage `json:"transitions"`
+	Requires    []string           `json:"requires"`
+}
+
+// LeadPipeline is the lead stage state machine
+type LeadPipeline struct {
+	InitialStages []models.LeadStage             `json:"initialStages"`
+	Stages        map[models.LeadStage]StageRule `json:"stages"`
+}
+
+// DefaultLeadPipeline is used unless LEAD_PIPELINE_CONFIG points to a JSON file with the same shape
+var DefaultLeadPipeline = LeadPipeline{
+	InitialStages: []models.LeadStage{models.LeadStageNew, models.LeadStageInProgress},
+	Stages: map[models.LeadStage]StageRule{
+		models.LeadStageNew: {
+			Transitions: []models.LeadStage{models.LeadStageInProgress, models.LeadStageFollowUp, models.LeadStageClosedLost},
+		},
+		models.LeadStageInProgress: {
+			Transitions: []models.LeadStage{models.LeadStageFollowUp, models.LeadStageClosedWon, models.LeadStageClosedLost},
+			Requires:    []string{RequireAssignee},
+		},
+		models.LeadStageFollowUp: {
+			Transitions: []models.LeadStage{models.LeadStageInProgress, models.LeadStageClosedWon, models.LeadStageClosedLost},
+			Requires:    []string{RequireAssignee},
+		},
+		models.LeadStageClosedWon: {
+			Requires: []string{RequireAssignee, RequireOrganization},
+		},
+		models.LeadStageClosedLost: {
+			// Lost leads can be reopened
+			Transitions: []models.LeadStage{models.LeadStageNew, models.LeadStageInProgress},
+			Requires:    []string{RequireReason},
+		},
+	},
+}
+
+var allLeadStages = []models.LeadStage{
+	models.LeadStageNew,
+	models.LeadStageInProgress,
+	models.LeadStageFollowUp,
+	models.LeadStageClosedWon,
+	models.LeadStageClosedLost,
+}
+
+var (
+	pipelineOnce sync.Once
+	pipeline     *LeadPipeline
+	pipelineErr  error
+)
+
+// LoadLeadPipeline loads and validates the lead pipeline. It is called on startup so a broken
+// configuration stops the server instead of failing stage changes later.
+func LoadLeadPipeline() (*LeadPipeline, error) {
+	pipelineOnce.Do(func() {
+		path := os.Getenv("LEAD_PIPELINE_CONFIG")
+		if path == "" {
+			pipeline = &DefaultLeadPipeline
+			return
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			pipelineErr = fmt.Errorf("failed to read lead pipeline config: %w", err)
+			return
+		}
+		var loaded LeadPipeline
+		if err := json.Unmarshal(raw, &loaded); err != nil {
+			pipelineErr = fmt.Errorf("invalid lead pipeline config: %w", err)
+			return
+		}
+		if err := loaded.validate(); err != nil {
+			pipelineErr = fmt.Errorf("invalid lead pipeline config: %w", err)
+			return
+		}
+		pipeline = &loaded
+	})
+	return pipeline, pipelineErr
+}
+
+func (p *LeadPipeline) validate() error {
+	known := func(stage models.LeadStage) bool {
+		for _, s := range allLeadStages {
+			if s == stage {
+				return true
+			}
+		}
+		return false
+	}
+	if len(p.InitialStages) == 0 {
+		return fmt.Errorf("initialStages must not be empty")
+	}
+	for _, stage := range p.InitialStages {
+		if !known(stage) {
+			return fmt.Errorf("unknown stage %q", stage)
+		}
+	}
+	for stage, rule := range p.Stages {
+		if !known(stage) {
+			return fmt.Errorf("unknown stage %q", stage)
+		}
+		for _, to := range rule.Transitions {
+			if !known(to) {
+				return fmt.Errorf("unknown stage %q in transitions of %s", to, stage)
+			}
+		}
+		for _, requirement := range rule.Requires {
+			if _, ok := stageRequirements[requirement]; !ok {
+				return fmt.Errorf("unknown requirement %q of %s", requirement, stage)
+			}
+		}
+	}
+	return nil
+}
+
+// CanTransition reports whether the pipeline allows moving a lead from one stage to another
+func (p *LeadPipeline) CanTransition(from, to models.LeadStage) bool {
+	for _, allowed := range p.Stages[from].Transitions {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRequirements returns the requirements of the stage the lead does not meet
+func (p *LeadPipeline) checkRequirements(stage models.LeadStage, lead *models.Lead, reason string) error {
+	var missing []string
+	for _, requirement := range p.Stages[stage].Requires {
+		if !stageRequirements[requirement](lead, reason) {
+			missing = append(missing, requirement)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("moving a lead to %s requires: %s", stage, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// IsInitial reports whether leads may be created in the stage
+func (p *LeadPipeline) IsInitial(stage models.LeadStage) bool {
+	for _, initial := range p.InitialStages {
+		if initial == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateInitialStage checks the stage a new lead is created in
+func (p *LeadPipeline) ValidateInitialStage(lead *models.Lead) error {
+	if !p.IsInitial(lead.LeadStage) {
+		return fmt.Errorf("leads cannot be created in stage %s", lead.LeadStage)
+	}
+	return p.checkRequirements(lead.LeadStage, lead, "")
+}
+
+// ValidateStageChange checks that the lead, with any pending changes applied, may move to the stage
+func (p *LeadPipeline) ValidateStageChange(lead *models.Lead, to models.LeadStage, reason string) error {
+	if lead.LeadStage == to {
+		return fmt.Errorf("lead is already in stage %s", to)
+	}
+	if !p.CanTransition(lead.LeadStage, to) {
+		return fmt.Errorf("invalid stage transition from %s to %s", lead.LeadStage, to)
+	}
+	return p.checkRequirements(to, lead, reason)
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	initializers "github.com/Zenithive/it-crm-backend/Initializers"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/internal/graphql/scalars"
+	"github.com/Zenithive/it-crm-backend/models"
+	"github.com/Zenithive/it-crm-backend/quote"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// maxQuoteCaseStudies is how many case studies a quote references at most
+const maxQuoteCaseStudies = 3
+
+// QuoteDocumentPath is where the download route serves a stored document of a quote
+func QuoteDocumentPath(quoteID uuid.UUID, format string) string {
+	return fmt.Sprintf("/quotes/%s/%s", quoteID, format)
+}
+
+// ConvertQuote maps a quote to its GraphQL shape
+func ConvertQuote(q *models.Quote) *generated.Quote {
+	result := &generated.Quote{
+		ID:        q.ID.String(),
+		DealID:    fmt.Sprintf("%d", q.DealID),
+		Version:   int32(q.Version),
+		Number:    q.Number,
+		Status:    generated.QuoteStatus(q.Status),
+		Total:     scalars.Money{Amount: q.Total, Currency: q.Currency},
+		HTMLURL:   QuoteDocumentPath(q.ID, "html"),
+		PDFURL:    QuoteDocumentPath(q.ID, "pdf"),
+		CreatedBy: ConvertUserSummary(q.CreatedBy),
+		CreatedAt: q.CreatedAt,
+		SentAt:    q.SentAt,
+		DecidedAt: q.DecidedAt,
+	}
+	if q.Template != nil {
+		result.Template = ConvertQuoteTemplate(q.Template)
+	}
+	return result
+}
+
+// ConvertQuoteTemplate maps a quote template to its GraphQL shape
+func ConvertQuoteTemplate(tmpl *models.QuoteTemplate) *generated.QuoteTemplate {
+	result := &generated.QuoteTemplate{
+		ID:        tmpl.ID.String(),
+		Name:      tmpl.Name,
+		Body:      tmpl.Body,
+		CreatedAt: tmpl.CreatedAt,
+		UpdatedAt: tmpl.UpdatedAt,
+	}
+	if tmpl.Description != "" {
+		result.Description = &tmpl.Description
+	}
+	return result
+}
+
+// FindScopedQuote loads a quote whose deal is within DealScope
+func FindScopedQuote(ctx context.Context, id string) (*models.Quote, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrQuoteNotFound
+	}
+	var q models.Quote
+	if err := initializers.DB.Preload("Template").Preload("CreatedBy").First(&q, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	if _, err := FindScopedDeal(ctx, fmt.Sprintf("%d", q.DealID)); err != nil {
+		return nil, ErrQuoteNotFound
+	}
+	return &q, nil
+}
+
+// FindEditableQuote loads a quote like FindScopedQuote and makes sure the current user may
+// change its deal
+func FindEditableQuote(ctx context.Context, id string) (*models.Quote, error) {
+	q, err := FindScopedQuote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := FindEditableDeal(ctx, fmt.Sprintf("%d", q.DealID)); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// BuildQuoteData collects what a quote shows: the deal with its line items, the lead and its
+// organization, and the case studies that match the campaign industry or the skills asked for.
+func BuildQuoteData(db *gorm.DB, deal *models.Deals, version int, issued time.Time) (quote.Data, error) {
+	data := quote.NewData(deal.ID, version, issued)
+	data.Currency = deal.DealCurrency
+	data.Total = quote.FormatAmount(deal.DealAmount)
+	data.Deal = quote.Deal{Name: deal.DealName, Requirements: deal.ProjectRequirements}
+	if deal.DealStartDate != nil {
+		data.Deal.StartDate = quote.FormatDate(*deal.DealStartDate)
+	}
+	if deal.DealEndDate != nil {
+		data.Deal.EndDate = quote.FormatDate(*deal.DealEndDate)
+	}
+
+	var lead models.Lead
+	if err := db.First(&lead, "lead_id = ?", deal.LeadID).Error; err != nil {
+		return quote.Data{}, fmt.Errorf("failed to load lead of deal: %w", err)
+	}
+	data.Client.ContactName = strings.TrimSpace(lead.FirstName + " " + lead.LastName)
+	data.Client.ContactEmail = lead.Email
+	data.Client.Country = lead.Country
+	// Leads reference organizations and campaigns by their ID as text
+	if orgID, err := strconv.ParseUint(lead.OrganizationID, 10, 64); err == nil {
+		var org models.Organization
+		err := db.First(&org, orgID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return quote.Data{}, fmt.Errorf("failed to load organization of lead: %w", err)
+		}
+		if err == nil {
+			data.Client.Organization = org.OrganizationName
+			data.Client.Email = org.OrganizationEmail
+			data.Client.Website = org.OrganizationWebsite
+			data.Client.City = org.City
+			data.Client.Country = org.Country
+		}
+	}
+	if data.Client.Organization == "" {
+		data.Client.Organization = data.Client.ContactName
+	}
+
+	var items []models.DealLineItem
+	if err := db.Preload("ResourceProfile.Skills").Preload("Skills").
+		Where("deal_id = ?", deal.ID).Order("created_at").Find(&items).Error; err != nil {
+		return quote.Data{}, fmt.Errorf("failed to load line items: %w", err)
+	}
+	var keywords []string
+	for i := range items {
+		line, skills := quoteLine(&items[i])
+		data.Lines = append(data.Lines, line)
+		keywords = append(keywords, skills...)
+	}
+
+	industry := ""
+	if campaignID, err := strconv.ParseUint(lead.CampaignID, 10, 64); err == nil {
+		var campaign models.Campaign
+		if err := db.First(&campaign, campaignID).Error; err == nil {
+			industry = campaign.IndustryTargeted
+		}
+	}
+	studies, err := relevantCaseStudies(db, industry, keywords)
+	if err != nil {
+		return quote.Data{}, fmt.Errorf("failed to load case studies: %w", err)
+	}
+	for _, study := range studies {
+		data.CaseStudies = append(data.CaseStudies, quote.CaseStudy{
+			ProjectName: study.ProjectName,
+			ClientName:  study.ClientName,
+			TechStack:   study.TechStack,
+			Duration:    study.ProjectDuration,
+			KeyOutcomes: study.KeyOutcomes,
+		})
+	}
+	return data, nil
+}
+
+// quoteLine formats a line item for a quote and returns the skills it asks for
+func quoteLine(item *models.DealLineItem) (quote.Line, []string) {
+	position := item.Role
+	if item.ResourceProfile != nil {
+		name := strings.TrimSpace(item.ResourceProfile.FirstName + " " + item.ResourceProfile.LastName)
+		if position != "" {
+			position = name + " (" + position + ")"
+		} else {
+			position = name
+		}
+	}
+
+	skills := item.Skills
+	if len(skills) == 0 && item.ResourceProfile != nil {
+		skills = item.ResourceProfile.Skills
+	}
+	names := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		names = append(names, skill.Name)
+	}
+
+	unit := strings.ToLower(string(item.Unit))
+	if !item.Quantity.Equal(decimal.NewFromInt(1)) {
+		unit += "s"
+	}
+
+	period := ""
+	if item.StartDate != nil && item.EndDate != nil {
+		period = quote.FormatDate(*item.StartDate) + " - " + quote.FormatDate(*item.EndDate)
+	} else if item.StartDate != nil {
+		period = "From " + quote.FormatDate(*item.StartDate)
+	} else if item.EndDate != nil {
+		period = "Until " + quote.FormatDate(*item.EndDate)
+	}
+
+	return quote.Line{
+		Position: position,
+		Skills:   strings.Join(names, ", "),
+		Period:   period,
+		Quantity: item.Quantity.String(),
+		Unit:     unit,
+		Rate:     quote.FormatAmount(item.Rate),
+		Total:    quote.FormatAmount(item.Total()),
+		Notes:    item.Description,
+	}, names
+}
+
+// relevantCaseStudies ranks the case studies by how well they match: the industry counts
+// twice, every skill found in the tags or tech stack once. Case studies without a match
+// are left out.
+func relevantCaseStudies(db *gorm.DB, industry string, keywords []string) ([]models.CaseStudy, error) {
+	var studies []models.CaseStudy
+	if err := db.Find(&studies).Error; err != nil {
+		return nil, err
+	}
+
+	industry = strings.ToLower(strings.TrimSpace(industry))
+	type ranked struct {
+		study models.CaseStudy
+		score int
+	}
+	var matches []ranked
+	for _, study := range studies {
+		score := 0
+		if industry != "" && strings.EqualFold(strings.TrimSpace(study.IndustryTarget), industry) {
+			score += 2
+		}
+		haystack := strings.ToLower(study.Tags + " " + study.TechStack)
+		seen := map[string]bool{}
+		for _, keyword := range keywords {
+			keyword = strings.ToLower(keyword)
+			if keyword != "" && !seen[keyword] && strings.Contains(haystack, keyword) {
+				seen[keyword] = true
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, ranked{study, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result := []models.CaseStudy{}
+	for i := 0; i < len(matches) && i < maxQuoteCaseStudies; i++ {
+		result = append(result, matches[i].study)
+	}
+	return result, nil
+}
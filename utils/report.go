@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+)
+
+const defaultReportDays = 90
+
+// ReportPeriod returns the bounds of the days of a report period as a half-open range: the
+// start of its first day and the start of the day after its last one. Without a period the
+// last 90 days up to and including today are used.
+func ReportPeriod(period *generated.DateRangeInput) (time.Time, time.Time, error) {
+	if period == nil {
+		end := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+		return end.AddDate(0, 0, -defaultReportDays), end, nil
+	}
+	if period.To.Before(period.From) {
+		return time.Time{}, time.Time{}, errors.New("period must not end before it starts")
+	}
+	return period.From, period.To.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+)
+
+func TestReportPeriod(t *testing.T) {
+	day := func(value string) time.Time {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	start, end, err := ReportPeriod(&generated.DateRangeInput{From: day("2026-03-01"), To: day("2026-03-31")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(day("2026-03-01")) || !end.Equal(day("2026-04-01")) {
+		t.Fatalf("got %s to %s, want the whole of March", start, end)
+	}
+
+	start, end, err = ReportPeriod(&generated.DateRangeInput{From: day("2026-03-01"), To: day("2026-03-01")})
+	if err != nil || end.Sub(start) != 24*time.Hour {
+		t.Fatalf("a single day period got %s to %s, %v", start, end, err)
+	}
+
+	if _, _, err := ReportPeriod(&generated.DateRangeInput{From: day("2026-03-02"), To: day("2026-03-01")}); err == nil {
+		t.Fatal("a period ending before it starts was accepted")
+	}
+
+	start, end, err = ReportPeriod(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if !end.Equal(tomorrow) || !start.Equal(tomorrow.AddDate(0, 0, -90)) {
+		t.Fatalf("the default period is %s to %s, want the last 90 days including today", start, end)
+	}
+}
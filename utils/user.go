@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/Zenithive/it-crm-backend/internal/graphql/generated"
+	"github.com/Zenithive/it-crm-backend/models"
+)
+
+// ConvertUserSummary maps an optional user relation to its GraphQL shape without campaigns
+func ConvertUserSummary(user *models.User) *generated.User {
+	if user == nil {
+		return nil
+	}
+	return &generated.User{
+		UserID:   fmt.Sprintf("%d", user.ID),
+		GoogleID: &user.GoogleId,
+		Name:     user.Name,
+		Email:    user.Email,
+		Phone:    user.Phone,
+		Role:     user.Role,
+	}
+}